@@ -0,0 +1,646 @@
+package heatwave
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SyncPolicy selects how often the append log is flushed to stable storage
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every record, no acknowledged write is lost
+	SyncAlways SyncPolicy = iota
+	// SyncEverySecond fsyncs once per second, at most a second of writes is lost
+	SyncEverySecond
+	// SyncOSBuffered leaves flushing to the operating system
+	SyncOSBuffered
+)
+
+// Append log record operations
+const (
+	logOpSet byte = iota + 1
+	logOpDelete
+	logOpClear
+	logOpSetVersioned // logOpSet followed by the item version
+	logOpExpire       // New expiry of an existing item
+)
+
+// logHeaderSize is the size of the length and checksum preceding each record
+const logHeaderSize = 8
+
+// logSealed is set in the length of a record whose payload is sealed with
+// AES-GCM, see WithSnapshotEncryption
+const logSealed uint32 = 1 << 31
+
+// logSealAD is the additional data of sealed append log records
+const logSealAD = "heatwave append log"
+
+// logRecord is a decoded append log record
+type logRecord struct {
+	op        byte
+	key       string
+	expiredAt *time.Time
+	createdAt time.Time
+	updatedAt time.Time
+	version   uint64 // Item version of a set, zero when not recorded
+	value     []byte
+}
+
+// appendLog is an append-only file of bucket writes
+// Each record is framed as payload length and CRC-32 followed by the payload,
+// so a record torn by a crash is detected on replay.
+type appendLog struct {
+	mutex  sync.Mutex
+	path   string
+	file   *os.File
+	policy SyncPolicy
+	aead   cipher.AEAD   // Seals records, nil when not encrypted
+	dirty  bool          // Whether records were written since the last fsync
+	stop   chan struct{} // Closed to stop the sync goroutine
+	done   chan struct{} // Closed when the sync goroutine exits
+}
+
+// openAppendLog opens path for appending, creating it if needed
+// spawn starts the sync goroutine of SyncEverySecond.
+func openAppendLog(path string, policy SyncPolicy, spawn func(role string, fn func())) (*appendLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &appendLog{
+		path:   path,
+		file:   file,
+		policy: policy,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if policy == SyncEverySecond {
+		spawn(roleLogSync, l.syncLoop)
+	} else {
+		close(l.done)
+	}
+	return l, nil
+}
+
+// append writes one framed record
+func (l *appendLog) append(record []byte) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, err := l.file.Write(record); err != nil {
+		return err
+	}
+	if l.policy == SyncAlways {
+		return l.file.Sync()
+	}
+	l.dirty = true
+	return nil
+}
+
+// syncLoop fsyncs the file once per second while there are new records
+func (l *appendLog) syncLoop() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.mutex.Lock()
+			if l.dirty {
+				_ = l.file.Sync()
+				l.dirty = false
+			}
+			l.mutex.Unlock()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// rewrite atomically replaces the log with the records produced by write
+func (l *appendLog) rewrite(write func(w io.Writer) error) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	tmp := l.path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, l.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	_ = l.file.Close()
+	l.file = file
+	l.dirty = false
+	return nil
+}
+
+// close stops the sync goroutine, flushes and closes the file
+func (l *appendLog) close() error {
+	close(l.stop)
+	<-l.done
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	err := l.file.Sync()
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// logFixedSize is the size of the fixed fields at the start of a payload:
+// operation, expiry, creation and update time
+const logFixedSize = 1 + 3*8
+
+// encodeRecord frames a record for the append log
+func encodeRecord(rec logRecord) []byte {
+	return frameRecord(encodePayload(rec), 0)
+}
+
+// sealRecord frames a record with its payload sealed by aead
+func sealRecord(aead cipher.AEAD, rec logRecord) ([]byte, error) {
+	payload := encodePayload(rec)
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return frameRecord(aead.Seal(nonce, nonce, payload, []byte(logSealAD)), logSealed), nil
+}
+
+// openRecord decrypts a sealed payload with the first of aeads that
+// authenticates it
+func openRecord(aeads []cipher.AEAD, sealed []byte) ([]byte, error) {
+	for _, aead := range aeads {
+		if len(sealed) < aead.NonceSize() {
+			break
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if payload, err := aead.Open(nil, nonce, ciphertext, []byte(logSealAD)); err == nil {
+			return payload, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: append log record", ErrSnapshotDecrypt)
+}
+
+// frameRecord prepends the length, tagged with flags, and checksum to payload
+func frameRecord(payload []byte, flags uint32) []byte {
+	record := make([]byte, logHeaderSize, logHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload))|flags)
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	return append(record, payload...)
+}
+
+// encodePayload encodes a record without its frame
+// A set with a version is written as logOpSetVersioned.
+func encodePayload(rec logRecord) []byte {
+	payload := make([]byte, 0, logFixedSize+8+binary.MaxVarintLen64+len(rec.key)+len(rec.value))
+	op := rec.op
+	if op == logOpSet && rec.version != 0 {
+		op = logOpSetVersioned
+	}
+	payload = append(payload, op)
+	var expiry int64
+	if rec.expiredAt != nil {
+		expiry = rec.expiredAt.UnixNano()
+	}
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expiry))
+	payload = binary.BigEndian.AppendUint64(payload, uint64(unixNano(rec.createdAt)))
+	payload = binary.BigEndian.AppendUint64(payload, uint64(unixNano(rec.updatedAt)))
+	if op == logOpSetVersioned {
+		payload = binary.BigEndian.AppendUint64(payload, rec.version)
+	}
+	payload = binary.AppendUvarint(payload, uint64(len(rec.key)))
+	payload = append(payload, rec.key...)
+	return append(payload, rec.value...)
+}
+
+// decodeRecord parses a record payload
+func decodeRecord(payload []byte) (logRecord, error) {
+	if len(payload) < logFixedSize {
+		return logRecord{}, errors.New("short record")
+	}
+	rec := logRecord{op: payload[0]}
+	if expiry := int64(binary.BigEndian.Uint64(payload[1:9])); expiry != 0 {
+		t := time.Unix(0, expiry)
+		rec.expiredAt = &t
+	}
+	rec.createdAt = fromUnixNano(int64(binary.BigEndian.Uint64(payload[9:17])))
+	rec.updatedAt = fromUnixNano(int64(binary.BigEndian.Uint64(payload[17:25])))
+	fixed := logFixedSize
+	if rec.op == logOpSetVersioned {
+		if len(payload) < logFixedSize+8 {
+			return logRecord{}, errors.New("short record")
+		}
+		rec.op = logOpSet
+		rec.version = binary.BigEndian.Uint64(payload[logFixedSize:])
+		fixed += 8
+	}
+	keyLen, n := binary.Uvarint(payload[fixed:])
+	if n <= 0 || uint64(len(payload)-fixed-n) < keyLen {
+		return logRecord{}, errors.New("bad key length")
+	}
+	start := fixed + n
+	rec.key = string(payload[start : start+int(keyLen)])
+	rec.value = payload[start+int(keyLen):]
+	return rec, nil
+}
+
+// unixNano returns t in nanoseconds, mapping the zero time to 0
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano is the inverse of unixNano
+func fromUnixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// readRecords calls fn for each intact record in r and returns the offset
+// just past the last one
+// Reading stops at a torn or corrupt final record, torn reports whether
+// there was one; a corrupt record followed by more data fails with
+// ErrCorruptLog. Sealed records are opened with aeads; one that none of them
+// authenticates fails with ErrSnapshotDecrypt, and without aeads with
+// ErrSnapshotEncrypted.
+func readRecords(r io.Reader, aeads []cipher.AEAD, fn func(rec logRecord) error) (offset int64, torn bool, err error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, logHeaderSize)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if err == io.EOF {
+				return offset, false, nil
+			}
+			if err == io.ErrUnexpectedEOF {
+				return offset, true, nil
+			}
+			return offset, false, err
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		payload := make([]byte, length&^logSealed)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return offset, true, nil
+			}
+			return offset, false, err
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			return corruptRecord(br, offset)
+		}
+		size := len(payload)
+		if length&logSealed != 0 {
+			if aeads == nil {
+				return offset, false, fmt.Errorf("%w: append log record", ErrSnapshotEncrypted)
+			}
+			if payload, err = openRecord(aeads, payload); err != nil {
+				return offset, false, err
+			}
+		}
+		rec, err := decodeRecord(payload)
+		if err != nil {
+			return corruptRecord(br, offset)
+		}
+		if err := fn(rec); err != nil {
+			return offset, false, err
+		}
+		offset += int64(logHeaderSize + size)
+	}
+}
+
+// corruptRecord reports a record at offset that failed its checksum or
+// didn't decode
+// Only the final record can be torn by a crash mid-write; with more data
+// behind it the record was damaged and the error wraps ErrCorruptLog.
+func corruptRecord(br *bufio.Reader, offset int64) (int64, bool, error) {
+	if _, err := br.Peek(1); err != nil {
+		return offset, true, nil
+	}
+	return offset, false, fmt.Errorf("%w: bad record at offset %d", ErrCorruptLog, offset)
+}
+
+// ReplayLog applies the records of the append log at path to the bucket and
+// returns how many were applied
+// A torn or corrupt record at the end, as left by a crash mid-write, is
+// truncated away. A corrupt record anywhere else fails with ErrCorruptLog
+// after applying the records before it, and the file is left untouched. Expiry times are absolute, so entries that expired while
+// the process was down are dropped instead of being resurrected. A missing
+// file is not an error. Buckets configured with WithAppendLog replay their log
+// at construction.
+func (b *Bucket[T]) ReplayLog(path string) (int, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	b.lock()
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return 0, err
+	}
+
+	var aeads []cipher.AEAD
+	if len(b.sealKeys) > 0 {
+		if aeads, err = newAEADs(b.sealKeys); err != nil {
+			return 0, err
+		}
+	}
+
+	codec := b.codecOrDefault()
+	applied := 0
+	lapsed := make(map[string]logRecord)
+	offset, torn, err := readRecords(file, aeads, func(rec logRecord) error {
+		rec = resumeLapsed(lapsed, rec, b.now())
+		ok, err := b.applyRecordLocked(rec, codec)
+		if err != nil {
+			return fmt.Errorf("heatwave: replay %q: %w", rec.key, err)
+		}
+		if ok {
+			applied++
+		}
+		return nil
+	})
+	if err != nil {
+		return applied, err
+	}
+	if torn {
+		b.log(LogWarn, "truncating torn append log record", "path", path, "offset", offset)
+		if err := os.Truncate(path, offset); err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+// resumeLapsed tracks the writes of a replay that had expired by now and
+// turns an expiry record extending one of them back into the write
+// A Touch after the write may have moved its deadline past now, which only
+// the later expiry record tells.
+func resumeLapsed(lapsed map[string]logRecord, rec logRecord, now time.Time) logRecord {
+	switch rec.op {
+	case logOpClear:
+		clear(lapsed)
+		return rec
+	case logOpExpire:
+		set, ok := lapsed[rec.key]
+		if !ok {
+			return rec
+		}
+		set.expiredAt = rec.expiredAt
+		rec = set
+	}
+	delete(lapsed, rec.key)
+	if rec.op == logOpSet && rec.expiredAt != nil && now.After(*rec.expiredAt) {
+		lapsed[rec.key] = rec
+	}
+	return rec
+}
+
+// applyRecordLocked applies one replayed or restored record and reports
+// whether it was applied
+// A value refused by the key or value size limits is skipped and logged;
+// accepted values go through the copier like any other write.
+// Must be called with b.mutex held
+func (b *Bucket[T]) applyRecordLocked(rec logRecord, codec Codec[T]) (bool, error) {
+	switch rec.op {
+	case logOpSet:
+		if rec.expiredAt != nil && b.now().After(*rec.expiredAt) {
+			// The write expired while the process was down, but it still
+			// replaced whatever the key held before
+			if item, exists := b.cache[rec.key]; exists {
+				b.removeLocked(item, ReasonExpired)
+			}
+			return true, nil
+		}
+		value, err := codec.Decode(rec.value)
+		if err != nil {
+			return false, err
+		}
+		if value, err = b.admit(rec.key, value); err != nil {
+			b.log(LogWarn, "skipping rejected record", "key", rec.key, "err", err)
+			return false, nil
+		}
+		b.stageLocked(b.stageSpill(rec.key, value))
+		item, err := b.setLocked(rec.key, value, rec.expiredAt)
+		if err != nil {
+			return false, err
+		}
+		// Keep the original timestamps and version instead of the replay's
+		if !rec.createdAt.IsZero() {
+			item.createdAt = rec.createdAt
+			item.updatedAt = rec.updatedAt
+		}
+		if rec.version != 0 {
+			item.version = rec.version
+		}
+		return true, nil
+	case logOpExpire:
+		item, exists := b.cache[rec.key]
+		if !exists {
+			return false, nil
+		}
+		item.expiredAt = rec.expiredAt
+		if item.expired(b.now()) {
+			b.removeLocked(item, ReasonExpired)
+		} else {
+			b.scheduleLocked(item)
+		}
+	case logOpDelete:
+		if item, exists := b.cache[rec.key]; exists {
+			b.removeLocked(item, ReasonDeleted)
+		}
+	case logOpClear:
+		b.clearLocked()
+	default:
+		return false, fmt.Errorf("unknown log operation %d", rec.op)
+	}
+	return true, nil
+}
+
+// CompactLog rewrites the append log from the current contents
+// The log otherwise grows with every write; compaction keeps one record per
+// live item. Writes are blocked while the log is rewritten.
+func (b *Bucket[T]) CompactLog() error {
+	b.lock()
+	defer b.unlock()
+
+	if b.isClosed() {
+		return ErrBucketClosed
+	}
+	if b.aof == nil {
+		return ErrNoAppendLog
+	}
+
+	codec := b.codecOrDefault()
+	now := b.now()
+	return b.aof.rewrite(func(w io.Writer) error {
+		for key, item := range b.cache {
+			if item.expired(now) {
+				continue
+			}
+			value, err := codec.Encode(b.valueOf(item))
+			if err != nil {
+				return fmt.Errorf("heatwave: encode %q: %w", key, err)
+			}
+			record, err := b.logRecordBytes(setRecord(item, value))
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// logSetLocked appends a write of item to the append log
+// Must be called with b.mutex held
+func (b *Bucket[T]) logSetLocked(item *CacheItem[T]) {
+	if b.aof == nil {
+		return
+	}
+	value, err := b.codecOrDefault().Encode(b.valueOf(item))
+	if err != nil {
+		b.log(LogError, "append log encode failed", "key", item.key, "err", err)
+		return
+	}
+	b.appendRecordLocked(setRecord(item, value))
+}
+
+// setRecord returns the log record for a write of item with the encoded value
+func setRecord[T any](item *CacheItem[T], value []byte) logRecord {
+	return logRecord{
+		op:        logOpSet,
+		key:       item.key,
+		expiredAt: item.expiredAt,
+		createdAt: item.createdAt,
+		updatedAt: item.updatedAt,
+		version:   item.version,
+		value:     value,
+	}
+}
+
+// logExpiryLocked appends the current expiry of item to the append log, for
+// changes that move the deadline without rewriting the value
+// Must be called with b.mutex held
+func (b *Bucket[T]) logExpiryLocked(item *CacheItem[T]) {
+	if b.aof != nil {
+		b.appendRecordLocked(logRecord{op: logOpExpire, key: item.key, expiredAt: item.expiredAt})
+	}
+}
+
+// logDeleteLocked appends a deletion of key to the append log
+// Must be called with b.mutex held
+func (b *Bucket[T]) logDeleteLocked(key string) {
+	if b.aof != nil {
+		b.appendRecordLocked(logRecord{op: logOpDelete, key: key})
+	}
+}
+
+// logClearLocked appends a Clear to the append log
+// Must be called with b.mutex held
+func (b *Bucket[T]) logClearLocked() {
+	if b.aof != nil {
+		b.appendRecordLocked(logRecord{op: logOpClear})
+	}
+}
+
+// appendRecordLocked writes a record, logging failures
+// Must be called with b.mutex held
+func (b *Bucket[T]) appendRecordLocked(rec logRecord) {
+	record, err := b.logRecordBytes(rec)
+	if err == nil {
+		err = b.aof.append(record)
+	}
+	if err != nil {
+		b.log(LogError, "append log write failed", "path", b.aof.path, "err", err)
+	}
+}
+
+// logRecordBytes frames rec for the append log, sealed when the bucket
+// encrypts at rest
+func (b *Bucket[T]) logRecordBytes(rec logRecord) ([]byte, error) {
+	if b.aof == nil || b.aof.aead == nil {
+		return encodeRecord(rec), nil
+	}
+	return sealRecord(b.aof.aead, rec)
+}
+
+// openLog replays the configured append log and opens it for appending
+func (b *Bucket[T]) openLog() {
+	if _, err := b.ReplayLog(b.aofPath); err != nil {
+		b.log(LogError, "append log replay failed", "path", b.aofPath, "err", err)
+	}
+	aof, err := openAppendLog(b.aofPath, b.aofPolicy, b.spawn)
+	if err != nil {
+		b.log(LogError, "append log open failed", "path", b.aofPath, "err", err)
+		return
+	}
+	if len(b.sealKeys) > 0 {
+		if aof.aead, err = newAEAD(b.sealKeys[0]); err != nil {
+			b.log(LogError, "append log key invalid", "path", b.aofPath, "err", err)
+			_ = aof.close()
+			return
+		}
+	}
+	b.aof = aof
+}
+
+// WithAppendLog persists every write to an append-only log at path
+// Nail, Unnail and Clear each append a record with the value encoded by the
+// bucket's Codec; evictions and expirations are not logged. The log is
+// replayed when the bucket is created and closed by Close. Use CompactLog to
+// keep it from growing without bound. Errors are reported through the
+// logger set with WithLogger. With WithSnapshotEncryption every record is
+// sealed with AES-GCM as well.
+func WithAppendLog[T any](path string, syncPolicy SyncPolicy) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.aofPath = path
+		b.aofPolicy = syncPolicy
+	}
+}
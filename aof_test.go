@@ -0,0 +1,196 @@
+package heatwave
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fileSize returns the size of the file at path
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestAppendLogReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.aof")
+
+	w := NewBucket[int](WithAppendLog[int](path, SyncAlways))
+	_ = w.Nail("a", 1)
+	_ = w.Nail("b", 2)
+	_ = w.Nail("a", 3)
+	_, _ = w.Unnail("b")
+	_ = w.Nail("c", 4)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewBucket[int](WithAppendLog[int](path, SyncEverySecond))
+	if v, ok := r.Bring("a"); !ok || v != 3 {
+		t.Fatalf("Bring(a) = %d, %v, want 3, true", v, ok)
+	}
+	if exists(r, "b") || r.Size() != 2 {
+		t.Fatalf("replayed %d items, want a and c", r.Size())
+	}
+
+	r.Clear()
+	_ = r.Nail("d", 5)
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	again := NewBucket[int](WithAppendLog[int](path, SyncOSBuffered))
+	defer again.Close()
+	if again.Size() != 1 || !exists(again, "d") {
+		t.Fatalf("after Clear the log replayed %d items, want only d", again.Size())
+	}
+}
+
+func TestAppendLogDropsItemsExpiredWhileDown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.aof")
+	clock := NewManualClock(time.Unix(1000, 0))
+
+	w := NewBucket[int](WithClock[int](clock), WithAppendLog[int](path, SyncAlways), WithBucketExpire[int](time.Minute))
+	_ = w.NailWithTTL("short", 1, time.Second)
+	_ = w.Nail("long", 2)
+	_ = w.Nail("touched", 3)
+	clock.Advance(50 * time.Second)
+	w.Touch("touched") // Now expires 110s after the start
+	_ = w.Close()
+
+	clock.Advance(20 * time.Second)
+	r := NewBucket[int](WithClock[int](clock), WithAppendLog[int](path, SyncAlways), WithBucketExpire[int](time.Minute))
+	defer r.Close()
+	if exists(r, "short") || exists(r, "long") {
+		t.Fatal("replay resurrected items that expired while the bucket was closed")
+	}
+	if !exists(r, "touched") {
+		t.Fatal("the expiry set by Touch wasn't logged")
+	}
+}
+
+func TestAppendLogTruncatesTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.aof")
+
+	w := NewBucket[int](WithAppendLog[int](path, SyncAlways))
+	_ = w.Nail("a", 1)
+	_ = w.Close()
+	intact := fileSize(t, path)
+
+	w = NewBucket[int](WithAppendLog[int](path, SyncAlways))
+	_ = w.Nail("b", 2)
+	_ = w.Close()
+	// Simulate a crash in the middle of writing the second record
+	if err := os.Truncate(path, fileSize(t, path)-3); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewBucket[int]()
+	defer r.Close()
+	applied, err := r.ReplayLog(path)
+	if err != nil {
+		t.Fatalf("ReplayLog: %v", err)
+	}
+	if applied != 1 || !exists(r, "a") || exists(r, "b") {
+		t.Fatalf("applied %d records, want only a", applied)
+	}
+	if size := fileSize(t, path); size != intact {
+		t.Fatalf("log size after replay = %d, want %d", size, intact)
+	}
+}
+
+func TestAppendLogKeepsRecordsAfterCorruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.aof")
+
+	w := NewBucket[int](WithAppendLog[int](path, SyncAlways))
+	_ = w.Nail("a", 1)
+	first := fileSize(t, path)
+	_ = w.Nail("b", 2)
+	_ = w.Nail("c", 3)
+	_ = w.Close()
+
+	// Damage the payload of the record in the middle
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[first+logHeaderSize] ^= 0xff
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewBucket[int]()
+	defer r.Close()
+	applied, err := r.ReplayLog(path)
+	if !errors.Is(err, ErrCorruptLog) {
+		t.Fatalf("ReplayLog = %v, want ErrCorruptLog", err)
+	}
+	if applied != 1 || !exists(r, "a") {
+		t.Fatalf("applied %d records, want the one before the damage", applied)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(data) {
+		t.Fatal("ReplayLog changed a log with a corrupt record in the middle")
+	}
+
+	// A bad checksum on the final record counts as torn
+	data[first+logHeaderSize] ^= 0xff
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r2 := NewBucket[int]()
+	defer r2.Close()
+	if applied, err := r2.ReplayLog(path); err != nil || applied != 2 {
+		t.Fatalf("ReplayLog with a damaged final record = %d, %v, want 2, nil", applied, err)
+	}
+	if exists(r2, "c") || fileSize(t, path) >= int64(len(data)) {
+		t.Fatal("the damaged final record wasn't truncated")
+	}
+}
+
+func TestCompactLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.aof")
+
+	w := NewBucket[int](WithAppendLog[int](path, SyncOSBuffered))
+	for i := 0; i < 100; i++ {
+		_ = w.Nail("k"+strconv.Itoa(i%5), i)
+	}
+	before := fileSize(t, path)
+	if err := w.CompactLog(); err != nil {
+		t.Fatalf("CompactLog: %v", err)
+	}
+	if after := fileSize(t, path); after*10 > before {
+		t.Fatalf("log shrank from %d to only %d bytes", before, after)
+	}
+	_ = w.Nail("k0", 1000)
+	_ = w.Close()
+
+	r := NewBucket[int](WithAppendLog[int](path, SyncOSBuffered))
+	defer r.Close()
+	if r.Size() != 5 {
+		t.Fatalf("Size = %d, want 5", r.Size())
+	}
+	if v, _ := r.Bring("k0"); v != 1000 {
+		t.Fatalf("Bring(k0) = %d, want the write made after compaction", v)
+	}
+	if v, _ := r.Bring("k4"); v != 99 {
+		t.Fatalf("Bring(k4) = %d, want 99", v)
+	}
+
+	plain := NewBucket[int]()
+	defer plain.Close()
+	if err := plain.CompactLog(); !errors.Is(err, ErrNoAppendLog) {
+		t.Fatalf("CompactLog without a log = %v, want ErrNoAppendLog", err)
+	}
+}
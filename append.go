@@ -0,0 +1,52 @@
+package heatwave
+
+// AppendValue appends elems to the slice stored at id under the bucket lock
+// and returns its new length
+// A missing or expired key is created. The TTL is refreshed and the item is
+// marked as accessed like any other write. The stored slice is rebuilt rather
+// than grown in place, so slices returned earlier by Bring are never
+// modified.
+func AppendValue[E any](b *Bucket[[]E], id string, elems ...E) (int, error) {
+	return AppendValueCapped(b, id, 0, elems...)
+}
+
+// AppendValueCapped is AppendValue that keeps at most maxLen elements,
+// dropping the oldest ones from the front
+// A non-positive maxLen means unlimited.
+func AppendValueCapped[E any](b *Bucket[[]E], id string, maxLen int, elems ...E) (int, error) {
+	b.lock()
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return 0, err
+	}
+
+	var current []E
+	if item, exists := b.cache[id]; exists && !item.expired(b.now()) {
+		current = b.valueOf(item)
+	}
+
+	total := len(current) + len(elems)
+	drop := 0
+	if maxLen > 0 && total > maxLen {
+		drop = total - maxLen
+	}
+	out := make([]E, 0, total-drop)
+	if drop < len(current) {
+		out = append(out, current[drop:]...)
+		out = append(out, elems...)
+	} else {
+		out = append(out, elems[drop-len(current):]...)
+	}
+
+	data, err := b.admit(id, out)
+	if err != nil {
+		return len(current), err
+	}
+	// The value depends on the current one, so it is spilled under the lock
+	b.stageLocked(b.stageSpill(id, data))
+	if _, err := b.setLocked(id, data, b.expiryFor(b.outdated)); err != nil {
+		return len(current), err
+	}
+	return len(data), nil
+}
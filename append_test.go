@@ -0,0 +1,98 @@
+package heatwave
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendValue(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[[]int](WithClock[[]int](clock), WithCleanupDisabled[[]int](), WithBucketExpire[[]int](time.Minute))
+	defer b.Close()
+
+	if n, err := AppendValue(b, "k", 1, 2); err != nil || n != 2 {
+		t.Fatalf("AppendValue on a missing key = %d, %v, want 2, nil", n, err)
+	}
+	first, _ := b.Bring("k")
+	clock.Advance(50 * time.Second)
+	if n, _ := AppendValue(b, "k", 3); n != 3 {
+		t.Fatalf("AppendValue = %d, want 3", n)
+	}
+	if v, _ := b.Bring("k"); len(v) != 3 || v[0] != 1 || v[2] != 3 {
+		t.Fatalf("Bring(k) = %v, want [1 2 3]", v)
+	}
+	// Slices returned earlier are never modified
+	if len(first) != 2 {
+		t.Fatalf("earlier slice = %v, want [1 2]", first)
+	}
+
+	// The append refreshed the TTL
+	clock.Advance(50 * time.Second)
+	if !exists(b, "k") {
+		t.Fatal("AppendValue didn't refresh the TTL")
+	}
+	// An expired key starts over
+	clock.Advance(time.Hour)
+	if n, _ := AppendValue(b, "k", 9); n != 1 {
+		t.Fatalf("AppendValue on an expired key = %d, want 1", n)
+	}
+}
+
+func TestAppendValueCapped(t *testing.T) {
+	b := NewBucket[[]int]()
+	defer b.Close()
+
+	_, _ = AppendValueCapped(b, "k", 3, 1, 2)
+	if n, _ := AppendValueCapped(b, "k", 3, 3, 4); n != 3 {
+		t.Fatalf("AppendValueCapped = %d, want the cap 3", n)
+	}
+	if v, _ := b.Bring("k"); len(v) != 3 || v[0] != 2 || v[2] != 4 {
+		t.Fatalf("Bring(k) = %v, want [2 3 4]", v)
+	}
+	// More new elements than the cap keeps only the newest ones
+	_, _ = AppendValueCapped(b, "k", 3, 5, 6, 7, 8)
+	if v, _ := b.Bring("k"); len(v) != 3 || v[0] != 6 || v[2] != 8 {
+		t.Fatalf("Bring(k) = %v, want [6 7 8]", v)
+	}
+}
+
+func TestAppendValueSizingAndAccess(t *testing.T) {
+	b := NewBucket[[]int](
+		WithMaxSize[[]int](2),
+		WithMaxBytes[[]int](1<<20, func(v []int) int64 { return int64(8 * len(v)) }),
+	)
+	defer b.Close()
+
+	_, _ = AppendValue(b, "a", 1)
+	_, _ = AppendValue(b, "b", 1)
+	_, _ = AppendValue(b, "a", 2, 3) // a grows and becomes most recently used
+	if bytes := b.Stats().Bytes; bytes != 32 {
+		t.Fatalf("Bytes = %d, want 32 for 4 elements", bytes)
+	}
+	_ = b.Nail("c", nil) // evicts b
+	if !exists(b, "a") || exists(b, "b") {
+		t.Fatal("AppendValue didn't mark the item as recently used")
+	}
+}
+
+func TestAppendValueConcurrent(t *testing.T) {
+	b := NewBucket[[]int]()
+	defer b.Close()
+
+	const writers, appends = 8, 100
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < appends; i++ {
+				_, _ = AppendValue(b, "k", i)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := b.Bring("k"); len(v) != writers*appends {
+		t.Fatalf("len = %d, want %d with no lost appends", len(v), writers*appends)
+	}
+}
@@ -0,0 +1,92 @@
+package heatwave
+
+// defaultAsyncQueueSize is the buffer size of the NailAsync queue
+const defaultAsyncQueueSize = 1024
+
+// AsyncFullPolicy controls what NailAsync does when its queue is full
+type AsyncFullPolicy int
+
+const (
+	// AsyncFallbackSync performs the write synchronously when the queue is full
+	AsyncFallbackSync AsyncFullPolicy = iota
+	// AsyncDrop discards the write when the queue is full
+	AsyncDrop
+)
+
+// asyncWrite is a queued NailAsync request
+type asyncWrite[T any] struct {
+	id   string
+	data T
+}
+
+// NailAsync queues a write and returns immediately
+// The write is applied by a background writer goroutine that is started on
+// first use. Errors are not reported. When the queue is full the configured
+// AsyncFullPolicy applies. Close applies all queued writes before clearing.
+func (b *Bucket[T]) NailAsync(id string, data T) {
+	b.asyncOnce.Do(b.startAsyncWriter)
+
+	b.asyncMutex.RLock()
+	if b.asyncClosed {
+		b.asyncMutex.RUnlock()
+		return
+	}
+	select {
+	case b.asyncQueue <- asyncWrite[T]{id: id, data: data}:
+		b.asyncMutex.RUnlock()
+		return
+	default:
+	}
+	b.asyncMutex.RUnlock()
+
+	if b.asyncPolicy == AsyncFallbackSync {
+		_ = b.Nail(id, data)
+	}
+}
+
+// startAsyncWriter creates the queue and starts the writer goroutine
+func (b *Bucket[T]) startAsyncWriter() {
+	b.asyncMutex.Lock()
+	defer b.asyncMutex.Unlock()
+
+	if b.asyncClosed {
+		return
+	}
+	b.asyncQueue = make(chan asyncWrite[T], b.asyncQueueSize)
+	b.asyncDone = make(chan struct{})
+	queue, done := b.asyncQueue, b.asyncDone
+	b.spawn(roleAsyncWriter, func() { b.runAsyncWriter(queue, done) })
+}
+
+// runAsyncWriter applies queued writes until the queue is closed
+func (b *Bucket[T]) runAsyncWriter(queue <-chan asyncWrite[T], done chan<- struct{}) {
+	defer close(done)
+	for w := range queue {
+		_ = b.Nail(w.id, w.data)
+	}
+}
+
+// drainAsync stops accepting async writes and waits for queued ones to apply
+func (b *Bucket[T]) drainAsync() {
+	b.asyncMutex.Lock()
+	if !b.asyncClosed {
+		b.asyncClosed = true
+		if b.asyncQueue != nil {
+			close(b.asyncQueue)
+		}
+	}
+	done := b.asyncDone
+	b.asyncMutex.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// WithAsyncQueue configures the NailAsync queue size and full-queue policy
+func WithAsyncQueue[T any](size int, policy AsyncFullPolicy) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.asyncQueueSize = size
+		b.asyncPolicy = policy
+	}
+}
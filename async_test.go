@@ -0,0 +1,62 @@
+package heatwave
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNailAsyncBecomesVisible(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	for i := 0; i < 100; i++ {
+		b.NailAsync(strconv.Itoa(i), i)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if v, ok := b.Bring("99"); ok {
+			if v != 99 {
+				t.Fatalf("Bring(99) = %d, want 99", v)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("async write never became visible")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := b.Bring(strconv.Itoa(i)); !ok || v != i {
+			t.Fatalf("Bring(%d) = %d, %v", i, v, ok)
+		}
+	}
+}
+
+func TestCloseDrainsAsyncWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.aof")
+	b := NewBucket[int](WithAppendLog[int](path, SyncOSBuffered), WithAsyncQueue[int](4096, AsyncDrop))
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		b.NailAsync(strconv.Itoa(i), i)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every queued write must have been applied, and so logged, before Close
+	r := NewBucket[int](WithMaxSize[int](n))
+	defer r.Close()
+	applied, err := r.ReplayLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != n || r.Size() != n {
+		t.Fatalf("replayed %d records into %d items, want %d", applied, r.Size(), n)
+	}
+
+	b.NailAsync("late", 1)
+}
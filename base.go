@@ -0,0 +1,53 @@
+package heatwave
+
+import "slices"
+
+// BaseUpdater is an embeddable Updater that keeps items in insertion order
+// Custom strategies can embed it and override only the methods they care
+// about, typically Evict. Access is a no-op, Remove is a linear scan and the
+// default Evict removes the oldest item.
+type BaseUpdater[T any] struct {
+	Items []*CacheItem[T] // Tracked items in insertion order
+}
+
+// Add appends item
+func (u *BaseUpdater[T]) Add(item *CacheItem[T]) {
+	u.Items = append(u.Items, item)
+}
+
+// Access does nothing
+func (u *BaseUpdater[T]) Access(item *CacheItem[T]) {}
+
+// Remove removes item with a linear scan
+func (u *BaseUpdater[T]) Remove(item *CacheItem[T]) {
+	if i := slices.Index(u.Items, item); i >= 0 {
+		u.RemoveAt(i)
+	}
+}
+
+// Evict removes and returns the oldest item
+func (u *BaseUpdater[T]) Evict() *CacheItem[T] {
+	if len(u.Items) == 0 {
+		return nil
+	}
+	return u.RemoveAt(0)
+}
+
+// Size returns the number of tracked items
+func (u *BaseUpdater[T]) Size() int {
+	return len(u.Items)
+}
+
+// Clear removes all items
+func (u *BaseUpdater[T]) Clear() {
+	clear(u.Items)
+	u.Items = u.Items[:0]
+}
+
+// RemoveAt removes and returns the item at position i, for use by custom
+// Evict implementations
+func (u *BaseUpdater[T]) RemoveAt(i int) *CacheItem[T] {
+	item := u.Items[i]
+	u.Items = slices.Delete(u.Items, i, i+1)
+	return item
+}
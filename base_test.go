@@ -0,0 +1,51 @@
+package heatwave
+
+import "testing"
+
+// newestFirst is a custom strategy that only overrides Evict
+type newestFirst[T any] struct {
+	BaseUpdater[T]
+}
+
+func (u *newestFirst[T]) Evict() *CacheItem[T] {
+	if len(u.Items) == 0 {
+		return nil
+	}
+	return u.RemoveAt(len(u.Items) - 1)
+}
+
+func TestBaseUpdaterEmbedding(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2), WithUpdater[int](&newestFirst[int]{}))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	b.Bring("b") // Access is a no-op
+	_ = b.Nail("c", 3)
+	if !exists(b, "a") || exists(b, "b") || !exists(b, "c") {
+		t.Fatal("the overridden Evict wasn't used")
+	}
+
+	// The embedded Remove and Clear keep the updater in sync with the bucket
+	_, _ = b.Unnail("a")
+	if b.Size() != 1 || b.updater.Size() != 1 {
+		t.Fatalf("Size = %d, updater size = %d after Unnail, want 1", b.Size(), b.updater.Size())
+	}
+	b.Clear()
+	if b.updater.Size() != 0 {
+		t.Fatalf("updater size = %d after Clear, want 0", b.updater.Size())
+	}
+}
+
+func TestBaseUpdaterDefaultEvictsOldest(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2), WithUpdater[int](&BaseUpdater[int]{}))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	b.Bring("a")
+	_ = b.Nail("c", 3)
+	if exists(b, "a") || !exists(b, "b") {
+		t.Fatal("the default Evict didn't remove the oldest item")
+	}
+}
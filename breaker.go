@@ -0,0 +1,154 @@
+package heatwave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breakerStateNames are the state names used in log events
+var breakerStateNames = [...]string{
+	breakerClosed:   "closed",
+	breakerOpen:     "open",
+	breakerHalfOpen: "half-open",
+}
+
+// circuitBreaker stops calling a failing loader for a while
+// After threshold consecutive failures it opens and rejects calls for
+// openDuration, then lets a single trial call through: success closes it
+// again, failure reopens it.
+type circuitBreaker struct {
+	mutex        sync.Mutex
+	threshold    int
+	openDuration time.Duration
+	state        int
+	failures     int                // Consecutive failures while closed
+	openedAt     time.Time          // When the breaker last opened
+	now          func() time.Time   // Bucket clock
+	onTransition func(from, to int) // Called outside the mutex on state changes
+}
+
+// allow reports whether a call may proceed, moving an expired open breaker to
+// half-open and admitting its trial call
+func (c *circuitBreaker) allow() error {
+	c.mutex.Lock()
+	from := c.state
+	err := c.allowLocked()
+	to := c.state
+	c.mutex.Unlock()
+
+	c.transition(from, to)
+	return err
+}
+
+// allowLocked is allow with c.mutex held
+func (c *circuitBreaker) allowLocked() error {
+	switch c.state {
+	case breakerOpen:
+		if c.now().Sub(c.openedAt) < c.openDuration {
+			return ErrCircuitOpen
+		}
+		c.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		// The trial call is still running
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of an allowed call
+// ErrNotFound means the backend answered and counts as a success.
+func (c *circuitBreaker) record(err error) {
+	c.mutex.Lock()
+	from := c.state
+	c.recordLocked(err)
+	to := c.state
+	c.mutex.Unlock()
+
+	c.transition(from, to)
+}
+
+// recordLocked is record with c.mutex held
+func (c *circuitBreaker) recordLocked(err error) {
+	if err == nil || errors.Is(err, ErrNotFound) {
+		c.state = breakerClosed
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.state == breakerHalfOpen || c.failures >= c.threshold {
+		c.state = breakerOpen
+		c.openedAt = c.now()
+		c.failures = 0
+	}
+}
+
+// transition reports a state change to onTransition
+func (c *circuitBreaker) transition(from, to int) {
+	if from != to && c.onTransition != nil {
+		c.onTransition(from, to)
+	}
+}
+
+// breakerTransition counts and logs a state change of the loader breaker
+func (b *Bucket[T]) breakerTransition(from, to int) {
+	level := LogInfo
+	if to == breakerOpen {
+		b.counters.breakerOpens.Add(1)
+		level = LogWarn
+	}
+	b.log(level, "loader circuit breaker changed state",
+		"from", breakerStateNames[from], "to", breakerStateNames[to])
+}
+
+// callLoader invokes loader through the circuit breaker when one is set,
+// retrying failures under WithStoreRetry until ctx is done
+func (b *Bucket[T]) callLoader(ctx context.Context, loader func() (T, error)) (T, error) {
+	if b.retry != nil {
+		loader = b.retrying(ctx, loader)
+	}
+	if b.breaker == nil {
+		return loader()
+	}
+	if err := b.breaker.allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			b.breaker.record(fmt.Errorf("heatwave: loader panicked: %v", r))
+			panic(r)
+		}
+	}()
+	value, err := loader()
+	b.breaker.record(err)
+	return value, err
+}
+
+// WithLoaderCircuitBreaker makes loads fail fast with ErrCircuitOpen after
+// failureThreshold consecutive loader failures
+// The breaker stays open for openDuration, measured on the bucket clock,
+// then admits a single trial load: success closes it, failure opens it
+// again. ErrNotFound is not a failure.
+// The breaker is shared by all keys and applies to GetOrLoad, Load,
+// BringContext and auto-fill.
+func WithLoaderCircuitBreaker[T any](failureThreshold int, openDuration time.Duration) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.breaker = &circuitBreaker{
+			threshold:    max(failureThreshold, 1),
+			openDuration: openDuration,
+			now:          b.now,
+			onTransition: b.breakerTransition,
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package heatwave
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoaderCircuitBreakerTransitions(t *testing.T) {
+	const openFor = time.Minute
+	logs := &captureLogger{}
+	b := NewBucket[int](WithDeterministic[int](1), WithLoaderCircuitBreaker[int](2, openFor), WithLogger[int](logs.log))
+	defer b.Close()
+	clock := b.Clock().(*ManualClock)
+
+	backendDown := errors.New("backend down")
+	calls := 0
+	failing := func() (int, error) {
+		calls++
+		return 0, backendDown
+	}
+
+	// Closed: failures below the threshold reach the loader
+	for _, key := range []string{"a", "b"} {
+		if _, err := b.GetOrLoad(key, failing); !errors.Is(err, backendDown) {
+			t.Fatalf("GetOrLoad(%s) = %v, want the loader error", key, err)
+		}
+	}
+	// Open: calls fail fast without reaching the loader
+	if _, err := b.GetOrLoad("c", failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetOrLoad on an open breaker = %v, want ErrCircuitOpen", err)
+	}
+	if calls != 2 {
+		t.Fatalf("loader called %d times, want 2", calls)
+	}
+
+	// Still open until openFor has passed
+	clock.Advance(openFor - time.Second)
+	if _, err := b.GetOrLoad("c", failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetOrLoad before openFor passed = %v, want ErrCircuitOpen", err)
+	}
+
+	// Half-open: a failing trial opens the breaker again at once
+	clock.Advance(time.Second)
+	if _, err := b.GetOrLoad("c", failing); !errors.Is(err, backendDown) {
+		t.Fatalf("trial GetOrLoad = %v, want the loader error", err)
+	}
+	if _, err := b.GetOrLoad("c", failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetOrLoad after a failed trial = %v, want ErrCircuitOpen", err)
+	}
+	if n := b.Stats().BreakerOpens; n != 2 {
+		t.Fatalf("BreakerOpens = %d, want 2", n)
+	}
+
+	// Half-open: a successful trial closes it
+	clock.Advance(openFor)
+	if v, err := b.GetOrLoad("c", func() (int, error) { return 7, nil }); err != nil || v != 7 {
+		t.Fatalf("trial GetOrLoad = %d, %v, want 7, nil", v, err)
+	}
+	// Closed again, so one failure doesn't open it
+	if _, err := b.GetOrLoad("d", failing); !errors.Is(err, backendDown) {
+		t.Fatalf("GetOrLoad after recovery = %v, want the loader error", err)
+	}
+	if _, err := b.GetOrLoad("e", func() (int, error) { return 1, nil }); err != nil {
+		t.Fatalf("GetOrLoad below the threshold = %v", err)
+	}
+
+	var transitions []string
+	logs.mutex.Lock()
+	for _, e := range logs.entries {
+		if e.msg == "loader circuit breaker changed state" {
+			transitions = append(transitions, e.kv["to"].(string))
+		}
+	}
+	logs.mutex.Unlock()
+	want := []string{"open", "half-open", "open", "half-open", "closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+func TestLoaderCircuitBreakerHalfOpenAdmitsOneTrial(t *testing.T) {
+	const openFor = time.Minute
+	b := NewBucket[int](WithDeterministic[int](1), WithLoaderCircuitBreaker[int](1, openFor))
+	defer b.Close()
+
+	_, _ = b.GetOrLoad("a", func() (int, error) { return 0, errors.New("down") })
+	b.Clock().(*ManualClock).Advance(openFor)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := b.GetOrLoad("trial", func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		done <- err
+	}()
+	<-started
+	if _, err := b.GetOrLoad("other", func() (int, error) { return 2, nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetOrLoad during the trial = %v, want ErrCircuitOpen", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("trial GetOrLoad = %v", err)
+	}
+	if _, err := b.GetOrLoad("other", func() (int, error) { return 2, nil }); err != nil {
+		t.Fatalf("GetOrLoad after a successful trial = %v", err)
+	}
+}
+
+func TestLoaderCircuitBreakerIgnoresNotFound(t *testing.T) {
+	b := NewBucket[int](WithLoaderCircuitBreaker[int](1, time.Minute))
+	defer b.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := b.GetOrLoad(key, func() (int, error) { return 0, ErrNotFound }); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("GetOrLoad(%s) = %v, want ErrNotFound", key, err)
+		}
+	}
+}
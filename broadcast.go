@@ -0,0 +1,186 @@
+package heatwave
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+)
+
+// InvalidationEvent tells other replicas to drop a key
+type InvalidationEvent struct {
+	Bucket string // Name of the bucket the key belongs to
+	Key    string
+	Origin string // ID of the publishing bucket, used to ignore own events
+}
+
+// Broadcaster distributes invalidation events between bucket replicas
+// Transports such as Redis pub/sub or NATS can implement it; Subscribe is
+// called once per bucket at construction.
+type Broadcaster interface {
+	// Publish sends an event to all subscribers
+	Publish(event InvalidationEvent) error
+	// Subscribe registers fn to receive published events
+	Subscribe(fn func(event InvalidationEvent))
+}
+
+// publishLocked queues an invalidation of id, must be called with b.mutex held
+func (b *Bucket[T]) publishLocked(id string) {
+	if b.broadcaster != nil {
+		b.invalidations = append(b.invalidations, id)
+	}
+}
+
+// publish sends queued invalidations, errors are counted in Stats
+func (b *Bucket[T]) publish(keys []string) {
+	for _, key := range keys {
+		event := InvalidationEvent{Bucket: b.name, Key: key, Origin: b.origin}
+		if err := b.broadcaster.Publish(event); err != nil {
+			b.counters.publishErrors.Add(1)
+		}
+	}
+}
+
+// receive applies an invalidation event from another replica
+// The key is removed without publishing again, which prevents loops.
+func (b *Bucket[T]) receive(event InvalidationEvent) {
+	if event.Origin == b.origin || event.Bucket != b.name {
+		return
+	}
+
+	b.lock()
+	defer b.unlock()
+
+	if b.isClosed() {
+		return
+	}
+	if item, exists := b.cache[event.Key]; exists {
+		b.updater.Remove(item)
+		b.forgetLocked(item, ReasonDeleted)
+	}
+}
+
+// newOrigin returns a random bucket ID
+func newOrigin() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// WithBroadcaster publishes local writes and deletes to other replicas and
+// removes keys invalidated by them
+// Every local Nail and Unnail publishes an event; evictions and expirations
+// are local decisions and don't.
+func WithBroadcaster[T any](br Broadcaster) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.broadcaster = br
+	}
+}
+
+// MemoryBroadcaster delivers events synchronously within the process
+// It records every published event, which makes it handy in tests.
+type MemoryBroadcaster struct {
+	mutex       sync.Mutex
+	subscribers []func(event InvalidationEvent)
+	events      []InvalidationEvent
+}
+
+// NewMemoryBroadcaster creates an in-memory broadcaster
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{}
+}
+
+// Publish delivers the event to every subscriber before returning
+func (m *MemoryBroadcaster) Publish(event InvalidationEvent) error {
+	m.mutex.Lock()
+	m.events = append(m.events, event)
+	subscribers := slices.Clone(m.subscribers)
+	m.mutex.Unlock()
+
+	for _, fn := range subscribers {
+		fn(event)
+	}
+	return nil
+}
+
+// Subscribe registers fn to receive events
+func (m *MemoryBroadcaster) Subscribe(fn func(event InvalidationEvent)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// Events returns a copy of all published events
+func (m *MemoryBroadcaster) Events() []InvalidationEvent {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return slices.Clone(m.events)
+}
+
+// ChannelBroadcaster delivers events asynchronously through a buffered
+// channel drained by its own goroutine
+type ChannelBroadcaster struct {
+	events      chan InvalidationEvent
+	done        chan struct{}
+	mutex       sync.RWMutex // Protects closed against concurrent Publish
+	closed      bool
+	subMutex    sync.Mutex // Protects subscribers
+	subscribers []func(event InvalidationEvent)
+}
+
+// NewChannelBroadcaster creates a channel-based broadcaster
+// Publish blocks when more than buffer events are waiting for delivery.
+func NewChannelBroadcaster(buffer int) *ChannelBroadcaster {
+	c := &ChannelBroadcaster{
+		events: make(chan InvalidationEvent, buffer),
+		done:   make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// Publish queues the event for delivery
+func (c *ChannelBroadcaster) Publish(event InvalidationEvent) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.closed {
+		return ErrBroadcasterClosed
+	}
+	c.events <- event
+	return nil
+}
+
+// Subscribe registers fn to receive events
+func (c *ChannelBroadcaster) Subscribe(fn func(event InvalidationEvent)) {
+	c.subMutex.Lock()
+	defer c.subMutex.Unlock()
+	c.subscribers = append(c.subscribers, fn)
+}
+
+// Close stops accepting events and waits until queued ones are delivered
+func (c *ChannelBroadcaster) Close() error {
+	c.mutex.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.events)
+	}
+	c.mutex.Unlock()
+
+	<-c.done
+	return nil
+}
+
+// run delivers queued events to the subscribers
+func (c *ChannelBroadcaster) run() {
+	defer close(c.done)
+	for event := range c.events {
+		c.subMutex.Lock()
+		subscribers := c.subscribers
+		c.subMutex.Unlock()
+
+		for _, fn := range subscribers {
+			fn(event)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package heatwave
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryBroadcasterInvalidatesReplicas(t *testing.T) {
+	br := NewMemoryBroadcaster()
+	a := NewBucket[int](WithBucketName[int]("users"), WithBroadcaster[int](br))
+	defer a.Close()
+	b := NewBucket[int](WithBucketName[int]("users"), WithBroadcaster[int](br))
+	defer b.Close()
+	other := NewBucket[int](WithBucketName[int]("orders"), WithBroadcaster[int](br))
+	defer other.Close()
+
+	_ = b.Nail("k", 1)
+	_ = other.Nail("k", 1)
+	_ = a.Nail("k", 2)
+
+	// The writer keeps its value, the replica drops its stale copy and a
+	// bucket with another name is left alone
+	if v, ok := a.Bring("k"); !ok || v != 2 {
+		t.Fatalf("writer Bring(k) = %d, %v, want 2, true", v, ok)
+	}
+	if _, ok := b.Bring("k"); ok {
+		t.Fatal("replica kept an invalidated key")
+	}
+	if _, ok := other.Bring("k"); !ok {
+		t.Fatal("bucket with another name dropped its key")
+	}
+
+	_, _ = a.Unnail("k")
+	// Receiving doesn't publish again, so only the three local writes and
+	// the delete were broadcast
+	if n := len(br.Events()); n != 4 {
+		t.Fatalf("published %d events, want 4", n)
+	}
+}
+
+func TestChannelBroadcaster(t *testing.T) {
+	br := NewChannelBroadcaster(16)
+	var seen []InvalidationEvent
+	br.Subscribe(func(event InvalidationEvent) {
+		seen = append(seen, event)
+	})
+	b := NewBucket[int](WithBucketName[int]("users"), WithBroadcaster[int](br))
+	defer b.Close()
+
+	_ = b.Nail("own", 1)
+	_ = b.Nail("k", 1)
+	if err := br.Publish(InvalidationEvent{Bucket: "users", Key: "k", Origin: "replica"}); err != nil {
+		t.Fatal(err)
+	}
+	// Close waits for queued events to be delivered
+	if err := br.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 3 {
+		t.Fatalf("delivered %d events, want 3", len(seen))
+	}
+	if _, ok := b.Bring("own"); !ok {
+		t.Fatal("bucket removed a key after its own event")
+	}
+	if _, ok := b.Bring("k"); ok {
+		t.Fatal("bucket kept a key invalidated by a replica")
+	}
+	if err := br.Publish(InvalidationEvent{Key: "k"}); !errors.Is(err, ErrBroadcasterClosed) {
+		t.Fatalf("Publish after Close = %v, want ErrBroadcasterClosed", err)
+	}
+}
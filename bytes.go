@@ -0,0 +1,90 @@
+package heatwave
+
+import "time"
+
+// accountLocked records the size of the value item is about to hold
+// Must be called with b.mutex held
+func (b *Bucket[T]) accountLocked(item *CacheItem[T], data T) {
+	if b.valueSizer == nil {
+		return
+	}
+	size := b.valueSizer(data)
+	b.totalBytes += size - item.size
+	item.size = size
+}
+
+// trimBytesLocked evicts items until incoming more bytes fit into maxBytes
+// Must be called with b.mutex held
+func (b *Bucket[T]) trimBytesLocked(incoming int64) int {
+	evicted := 0
+	for b.maxBytes > 0 && b.totalBytes+incoming > b.maxBytes && b.updater.Size() > 0 {
+		evictedItem := b.updater.Evict()
+		if evictedItem == nil {
+			break
+		}
+		b.forgetLocked(evictedItem, ReasonEvicted)
+		evicted++
+	}
+	return evicted
+}
+
+// Bytes returns the total size of the held values as measured by the sizer
+// set with WithMaxBytes or WithMaxValueBytes, zero without one
+func (b *Bucket[T]) Bytes() int64 {
+	b.rlock()
+	defer b.mutex.RUnlock()
+	return b.totalBytes
+}
+
+// trimLoop runs the background byte budget trimming until the bucket closes
+func (b *Bucket[T]) trimLoop() {
+	ticker := time.NewTicker(b.trimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if b.isClosed() {
+				return
+			}
+			if !b.frozen.Load() {
+				b.trim()
+			}
+		case <-b.stopCleanup:
+			return
+		}
+	}
+}
+
+// trim evicts items until the bucket is within its byte budget
+func (b *Bucket[T]) trim() {
+	b.lockCleanup()
+	defer b.unlock()
+
+	if b.closed.Load() {
+		return
+	}
+	if evicted := b.trimBytesLocked(0); evicted > 0 {
+		b.log(LogDebug, "trimmed to byte budget", "count", evicted, "bytes", b.totalBytes)
+	}
+}
+
+// WithMaxBytes limits the total size of the held values to limit bytes as
+// measured by sizer
+// Inserts evict items until the new value fits. Updates that grow a value
+// are not trimmed right away; use WithBackgroundTrim to bring the bucket back
+// under the budget between inserts. sizer is shared with WithMaxValueBytes.
+func WithMaxBytes[T any](limit int64, sizer func(T) int64) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.maxBytes = limit
+		b.valueSizer = sizer
+	}
+}
+
+// WithBackgroundTrim evicts items every interval until the bucket is within
+// the budget set by WithMaxBytes, independently of writes
+func WithBackgroundTrim[T any](interval time.Duration) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.trimInterval = interval
+	}
+}
@@ -0,0 +1,32 @@
+package heatwave
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackgroundTrim(t *testing.T) {
+	b := NewBucket[string](
+		WithMaxBytes[string](100, func(s string) int64 { return int64(len(s)) }),
+		WithBackgroundTrim[string](time.Millisecond),
+	)
+	defer b.Close()
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		_ = b.Nail(key, strings.Repeat("x", 20))
+	}
+	// Growing an existing value is not trimmed on write
+	_ = b.Nail("d", strings.Repeat("x", 60))
+	if n := b.Bytes(); n != 120 {
+		t.Fatalf("Bytes = %d after growing update, want 120", n)
+	}
+
+	waitFor(t, "background trim", func() bool { return b.Bytes() <= 100 })
+	if _, ok := b.Bring("d"); !ok {
+		t.Fatal("trim evicted the most recently used item")
+	}
+	if _, ok := b.Bring("a"); ok {
+		t.Fatal("trim kept the least recently used item")
+	}
+}
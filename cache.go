@@ -0,0 +1,26 @@
+package heatwave
+
+// Cache is the minimal surface of a bucket, meant for code that should not
+// depend on *Bucket[T] directly, e.g. to swap in a fake in unit tests
+// *Bucket[T] and *ShardedBucket[T] implement it. The method set is kept
+// stable: new bucket features are added to Bucket, not here, so
+// implementations outside this package don't break.
+type Cache[T any] interface {
+	// Nail stores data under id
+	Nail(id string, data T, opts ...NailOption) error
+	// Bring returns the data stored under id and whether it was found
+	Bring(id string) (T, bool)
+	// Unnail removes id and reports whether it was present
+	Unnail(id string) (bool, error)
+	// Size returns the number of items held
+	Size() int
+	// Clear removes all items
+	Clear()
+	// Close releases the resources of the cache
+	Close() error
+}
+
+var (
+	_ Cache[any] = (*Bucket[any])(nil)
+	_ Cache[any] = (*ShardedBucket[any])(nil)
+)
@@ -0,0 +1,301 @@
+package heatwave
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCleanupRunning(t *testing.T) {
+	b := NewBucket[int]()
+	if !b.CleanupRunning() {
+		t.Fatal("CleanupRunning = false for a new bucket")
+	}
+	_ = b.Close()
+	// The goroutine exits asynchronously after Close signals it
+	waitFor(t, "the cleanup goroutine to exit", func() bool { return !b.CleanupRunning() })
+
+	for name, opt := range map[string]NewBucketOption[int]{
+		"WithCleanupDisabled":    WithCleanupDisabled[int](),
+		"WithCleanupInterval(0)": WithCleanupInterval[int](0),
+		"WithDeterministic":      WithDeterministic[int](1),
+	} {
+		d := NewBucket[int](opt)
+		if d.CleanupRunning() {
+			t.Errorf("CleanupRunning = true with %s", name)
+		}
+		_ = d.Close()
+	}
+}
+
+// countingUpdater counts the Remove calls of the wrapped updater per key
+type countingUpdater[T any] struct {
+	Updater[T]
+	removes map[string]int
+}
+
+func (u *countingUpdater[T]) Remove(item *CacheItem[T]) {
+	u.removes[item.key]++
+	u.Updater.Remove(item)
+}
+
+func TestCleanupRemovesEachItemOnce(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	updater := &countingUpdater[int]{Updater: newLRUUpdater[int](), removes: make(map[string]int)}
+	evicted := make(map[string]int)
+	expired := make(map[string]int)
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithUpdater[int](updater),
+		WithOnEvict(func(key string, value int, reason RemovalReason) {
+			evicted[key]++
+		}),
+		WithOnExpire(func(key string, value int) {
+			expired[key]++
+		}),
+	)
+	defer b.Close()
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		if i%10 == 0 {
+			_ = b.NailWithTTL(key, i, time.Second)
+		} else {
+			_ = b.Nail(key, i)
+		}
+	}
+	clock.Advance(2 * time.Second)
+
+	if n := b.CleanupNow(); n != 10 {
+		t.Fatalf("CleanupNow = %d, want 10", n)
+	}
+	if n := b.CleanupNow(); n != 0 {
+		t.Fatalf("second CleanupNow = %d, want 0", n)
+	}
+	for i := 0; i < 100; i += 10 {
+		key := strconv.Itoa(i)
+		if updater.removes[key] != 1 || evicted[key] != 1 || expired[key] != 1 {
+			t.Fatalf("%s removed %d times, evicted %d, expired %d, want once each",
+				key, updater.removes[key], evicted[key], expired[key])
+		}
+	}
+	if len(updater.removes) != 10 || len(evicted) != 10 || len(expired) != 10 {
+		t.Fatalf("removed %d, evicted %d, expired %d keys, want 10 each",
+			len(updater.removes), len(evicted), len(expired))
+	}
+	if b.Size() != 90 || updater.Size() != 90 {
+		t.Fatalf("Size = %d, updater size = %d, want 90", b.Size(), updater.Size())
+	}
+}
+
+// BenchmarkCleanup measures a cleanup pass over 1M items of which 10% expired
+func BenchmarkCleanup(b *testing.B) {
+	const (
+		size    = 1_000_000
+		expired = size / 10
+	)
+	clock := NewManualClock(time.Unix(0, 0))
+	bucket := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithMaxSize[int](size))
+	defer bucket.Close()
+	for i := expired; i < size; i++ {
+		_ = bucket.NailWithTTL(strconv.Itoa(i), i, 24*time.Hour)
+	}
+	keys := make([]string, expired)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for j, key := range keys {
+			_ = bucket.NailWithTTL(key, j, time.Second)
+		}
+		clock.Advance(2 * time.Second)
+		b.StartTimer()
+		if n := bucket.CleanupNow(); n != expired {
+			b.Fatalf("CleanupNow = %d, want %d", n, expired)
+		}
+	}
+}
+
+func TestPauseCleanup(t *testing.T) {
+	b := NewBucket[int](WithDeterministic[int](1))
+	defer b.Close()
+	clock := b.Clock().(*ManualClock)
+
+	b.PauseCleanup()
+	_ = b.NailWithTTL("a", 1, time.Second)
+	clock.Advance(2 * time.Second)
+	if !b.cleanupIsPaused() {
+		t.Fatal("cleanup not paused")
+	}
+	if n := b.Stats().Expirations; n != 0 {
+		t.Fatalf("paused cleanup removed %d items", n)
+	}
+
+	b.ResumeCleanup()
+	if b.cleanupIsPaused() {
+		t.Fatal("cleanup still paused after ResumeCleanup")
+	}
+	if n := b.CleanupNow(); n != 1 {
+		t.Fatalf("CleanupNow removed %d items after resuming, want 1", n)
+	}
+}
+
+func TestPausedCleanupStillExpiresLazily(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupInterval[int](5*time.Millisecond))
+	defer b.Close()
+
+	b.PauseCleanup()
+	_ = b.NailWithTTL("a", 1, time.Second)
+	clock.Advance(2 * time.Second)
+	if _, ok := b.Bring("a"); ok {
+		t.Fatal("Bring returned an expired item while cleanup was paused")
+	}
+	if n := b.Stats().Expirations; n != 1 {
+		t.Fatalf("Expirations = %d, want the lazy removal counted", n)
+	}
+}
+
+func TestCleanupJitter(t *testing.T) {
+	const interval = time.Minute
+	delays := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		b := NewBucket[int](WithCleanupInterval[int](interval))
+		d := b.cleanupDelay()
+		_ = b.Close()
+		if d < 0 || d >= interval {
+			t.Fatalf("first sweep delayed by %v, want within [0, %v)", d, interval)
+		}
+		delays[d] = true
+	}
+	// Buckets created together start their sweeps at different instants
+	if len(delays) < 19 {
+		t.Fatalf("20 buckets got only %d distinct first sweep delays", len(delays))
+	}
+
+	b := NewBucket[int](WithCleanupInterval[int](interval), WithCleanupJitter[int](false))
+	defer b.Close()
+	if d := b.cleanupDelay(); d != 0 {
+		t.Fatalf("first sweep delayed by %v without jitter, want 0", d)
+	}
+}
+
+func TestTouchRacingCleanup(t *testing.T) {
+	const keys = 200
+	b := NewBucket[int](WithBucketExpire[int](time.Minute), WithPreciseExpiry[int]())
+	defer b.Close()
+
+	for round := 0; round < 5; round++ {
+		for i := 0; i < keys; i++ {
+			_ = b.NailWithTTL(strconv.Itoa(i), i, time.Millisecond)
+		}
+		// Touches land around the deadlines while cleanup removes the items
+		// that expired first
+		touched := make(chan string, keys)
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < keys; i += 4 {
+					key := strconv.Itoa(i)
+					if b.Touch(key) {
+						touched <- key
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(touched)
+
+		n := 0
+		for key := range touched {
+			if !exists(b, key) {
+				t.Fatalf("cleanup removed %s after it was touched", key)
+			}
+			n++
+		}
+		waitFor(t, "cleanup of the untouched items", func() bool { return b.Size() == n })
+		b.Clear()
+	}
+}
+
+// BenchmarkBringDuringCleanup measures reads of a mostly live bucket while
+// cleanup passes run back to back
+func BenchmarkBringDuringCleanup(b *testing.B) {
+	const (
+		size    = 100_000
+		expired = size / 100
+	)
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	for _, tc := range []struct {
+		name    string
+		cleanup bool
+	}{
+		{"Idle", false},
+		{"Cleanup", true},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			clock := NewManualClock(time.Unix(0, 0))
+			bucket := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithMaxSize[int](size))
+			defer bucket.Close()
+			for i, key := range keys {
+				_ = bucket.NailWithTTL(key, i, time.Hour)
+			}
+
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			if tc.cleanup {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						for _, key := range keys[:expired] {
+							_ = bucket.NailWithTTL(key, 0, time.Second)
+						}
+						clock.Advance(2 * time.Second)
+						bucket.CleanupNow()
+					}
+				}()
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := expired
+				for pb.Next() {
+					_, _ = bucket.Bring(keys[expired+i%(size-expired)])
+					i++
+				}
+			})
+			b.StopTimer()
+			close(stop)
+			wg.Wait()
+		})
+	}
+}
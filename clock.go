@@ -0,0 +1,192 @@
+package heatwave
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the bucket the current time
+// Expiry deadlines, item timestamps and TTL arithmetic use the clock, while
+// latency metrics and timeouts always use the monotonic wall clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// now returns the current time of the bucket's clock
+func (b *Bucket[T]) now() time.Time {
+	if b.clock == nil {
+		return time.Now()
+	}
+	return b.clock.Now()
+}
+
+// clocked is implemented by updaters that timestamp accesses
+type clocked interface {
+	useClock(now func() time.Time)
+}
+
+// clockUpdater hands the bucket's clock to u if it timestamps accesses
+// It must be called before any item is added to u.
+func (b *Bucket[T]) clockUpdater(u Updater[T]) {
+	if c, ok := u.(clocked); ok {
+		c.useClock(b.now)
+	}
+}
+
+// timerClock is implemented by clocks that can wait on their own time
+type timerClock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+// after returns a channel that receives once d has passed on the bucket
+// clock and a function that stops the wait
+// Clocks that don't implement After wait on the wall clock.
+func (b *Bucket[T]) after(d time.Duration) (<-chan time.Time, func()) {
+	if c, ok := b.clock.(timerClock); ok {
+		return c.After(d), func() {}
+	}
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
+// ManualClock is a Clock that only moves when told to, for tests
+type ManualClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []clockWaiter // Pending After calls
+}
+
+// clockWaiter is a pending After call of a ManualClock
+type clockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewManualClock returns a clock standing at start
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d, or back for a negative d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	c.fireLocked()
+}
+
+// Set moves the clock to t
+func (c *ManualClock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = t
+	c.fireLocked()
+}
+
+// After returns a channel that receives the clock's time once it has been
+// moved d past the current time, right away for a non-positive d
+// Waits of a bucket using the clock, such as WithStoreRetry backoff, go
+// through After, so a test releases them by advancing the clock.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, clockWaiter{deadline: c.now.Add(d), ch: ch})
+	c.fireLocked()
+	return ch
+}
+
+// fireLocked releases the waiters whose deadline the clock has reached
+// Must be called with c.mutex held
+func (c *ManualClock) fireLocked() {
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if c.now.Before(w.deadline) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// Clock returns the clock the bucket reads the time from, nil for the wall
+// clock
+func (b *Bucket[T]) Clock() Clock {
+	return b.clock
+}
+
+// coarseClock caches the bucket time at a fixed resolution
+type coarseClock struct {
+	resolution time.Duration
+	nanos      atomic.Int64  // Cached time in Unix nanoseconds
+	stop       chan struct{} // Closed to stop the refresh goroutine
+}
+
+// expiryNow returns the time read paths compare deadlines against, the
+// cached time under WithCoarseClock
+// It may lag the bucket clock by up to the resolution, so items are never
+// considered expired early. Deadlines are always computed from now.
+func (b *Bucket[T]) expiryNow() time.Time {
+	if b.coarse == nil {
+		return b.now()
+	}
+	return time.Unix(0, b.coarse.nanos.Load())
+}
+
+// startCoarseClock caches the time and starts refreshing it
+func (b *Bucket[T]) startCoarseClock() {
+	b.coarse.nanos.Store(b.now().UnixNano())
+	b.spawn(roleCoarseClock, b.coarseClockLoop)
+}
+
+// coarseClockLoop refreshes the cached time every resolution until the
+// bucket is closed
+func (b *Bucket[T]) coarseClockLoop() {
+	ticker := time.NewTicker(b.coarse.resolution)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.coarse.nanos.Store(b.now().UnixNano())
+		case <-b.coarse.stop:
+			return
+		}
+	}
+}
+
+// WithClock sets the clock used for expiry and item timestamps
+// It is meant for tests and for deployments whose notion of time differs
+// from the local wall clock. The clock may jump in either direction; items
+// expire once the clock passes their deadline. A nil clock uses time.Now.
+func WithClock[T any](c Clock) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.clock = c
+	}
+}
+
+// WithCoarseClock makes hot path expiry checks read a cached time refreshed
+// every resolution instead of asking the clock on every call
+// Bring, Nail and the other single key reads and writes compare deadlines
+// against the cached time, so an item may outlive its deadline by up to
+// resolution but never expires early. TTLs are still assigned from the exact
+// time, so items neither gain nor lose lifetime, and the cleanup goroutine
+// keeps using the exact time. The refresh runs on its own goroutine until
+// Close. A non-positive resolution disables the cache.
+func WithCoarseClock[T any](resolution time.Duration) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		if resolution <= 0 {
+			b.coarse = nil
+			return
+		}
+		b.coarse = &coarseClock{resolution: resolution, stop: make(chan struct{})}
+	}
+}
@@ -0,0 +1,66 @@
+package heatwave
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCoarseClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithCoarseClock[int](time.Millisecond))
+	defer b.Close()
+
+	_ = b.NailWithTTL("a", 1, time.Second)
+	// Deadlines come from the exact time even before the cache refreshes
+	clock.Advance(500 * time.Millisecond)
+	_ = b.NailWithTTL("b", 2, time.Second)
+	info, _ := b.ItemInfo("b")
+	if want := time.Unix(0, 0).Add(1500 * time.Millisecond); !info.ExpiresAt.Equal(want) {
+		t.Fatalf("b expires at %v, want %v", info.ExpiresAt, want)
+	}
+
+	clock.Advance(time.Second)
+	waitFor(t, "the cached time to catch up", func() bool {
+		_, ok := b.Bring("a")
+		return !ok
+	})
+	if _, ok := b.Bring("b"); !ok {
+		t.Fatal("b expired before its deadline")
+	}
+}
+
+// BenchmarkCoarseClock compares hot path reads against the exact and the
+// cached time
+func BenchmarkCoarseClock(b *testing.B) {
+	const size = 1024
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	for _, tc := range []struct {
+		name string
+		opts []NewBucketOption[int]
+	}{
+		{"Exact", nil},
+		{"Coarse", []NewBucketOption[int]{WithCoarseClock[int](time.Millisecond)}},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			opts := append([]NewBucketOption[int]{WithMaxSize[int](size), WithBucketExpire[int](time.Hour)}, tc.opts...)
+			bucket := NewBucket[int](opts...)
+			defer bucket.Close()
+			for i, key := range keys {
+				_ = bucket.Nail(key, i)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					_, _ = bucket.Bring(keys[i%size])
+					i++
+				}
+			})
+		})
+	}
+}
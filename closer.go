@@ -0,0 +1,46 @@
+package heatwave
+
+import (
+	"io"
+	"reflect"
+)
+
+// closeValue closes value if it implements io.Closer, logging failures
+func (b *Bucket[T]) closeValue(key string, value T) {
+	closer, ok := any(value).(io.Closer)
+	if !ok {
+		return
+	}
+	b.guard(func() {
+		if err := closer.Close(); err != nil {
+			b.log(LogWarn, "closing removed value failed", "key", key, "err", err)
+		}
+	})
+}
+
+// sameCloser reports whether a and b are the same io.Closer, in which case
+// rewriting a key with it must not close it
+func sameCloser[T any](a, b T) bool {
+	ca, ok := any(a).(io.Closer)
+	if !ok {
+		return false
+	}
+	cb, ok := any(b).(io.Closer)
+	if !ok || reflect.TypeOf(ca) != reflect.TypeOf(cb) || !reflect.TypeOf(ca).Comparable() {
+		return false
+	}
+	return ca == cb
+}
+
+// WithCloseEvictedValues closes values implementing io.Closer once they leave
+// the bucket
+// This covers evictions, expirations, deletions, Clear, Close and values
+// replaced by an update, where the old value is closed after the new one is
+// installed. Values are closed after the lock is released; errors are logged
+// through the logger set with WithLogger and never returned. Values handed
+// out by Drain are not closed.
+func WithCloseEvictedValues[T any]() NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.closeEvicted = true
+	}
+}
@@ -0,0 +1,125 @@
+package heatwave
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// resource is an io.Closer counting its Close calls
+type resource struct {
+	name    string
+	closes  atomic.Int32
+	onClose func()
+	err     error
+}
+
+func (r *resource) Close() error {
+	r.closes.Add(1)
+	if r.onClose != nil {
+		r.onClose()
+	}
+	return r.err
+}
+
+func TestCloseEvictedValues(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[*resource](
+		WithClock[*resource](clock),
+		WithCleanupDisabled[*resource](),
+		WithMaxSize[*resource](2),
+		WithCloseEvictedValues[*resource](),
+	)
+
+	evicted, expired, deleted := &resource{}, &resource{}, &resource{}
+	_ = b.Nail("evicted", evicted)
+	_ = b.NailWithTTL("expired", expired, time.Second)
+	_ = b.Nail("deleted", deleted) // evicts evicted
+	clock.Advance(2 * time.Second)
+	b.CleanupNow()
+	_, _ = b.Unnail("deleted")
+
+	cleared, kept := &resource{}, &resource{}
+	_ = b.Nail("cleared", cleared)
+	b.Clear()
+	_ = b.Nail("kept", kept)
+	_ = b.Nail("kept", kept) // Rewriting the same value doesn't close it
+	if n := kept.closes.Load(); n != 0 {
+		t.Fatalf("value rewritten with itself closed %d times", n)
+	}
+	// Close waits for the reclaimer of Clear and closes what is left
+	_ = b.Close()
+
+	for name, r := range map[string]*resource{
+		"evicted": evicted, "expired": expired, "deleted": deleted, "cleared": cleared, "kept": kept,
+	} {
+		if n := r.closes.Load(); n != 1 {
+			t.Fatalf("%s value closed %d times, want once", name, n)
+		}
+	}
+}
+
+func TestCloseReplacedValueAfterInstall(t *testing.T) {
+	logs := &captureLogger{}
+	b := NewBucket[*resource](WithCloseEvictedValues[*resource](), WithLogger[*resource](logs.log))
+	defer b.Close()
+
+	old, replacement := &resource{name: "old", err: errors.New("busy")}, &resource{name: "new"}
+	var seen string
+	old.onClose = func() {
+		// Runs outside the lock, so the bucket can be read
+		if v, ok := b.Bring("k"); ok {
+			seen = v.name
+		}
+	}
+	_ = b.Nail("k", old)
+	_ = b.Nail("k", replacement)
+
+	if old.closes.Load() != 1 || replacement.closes.Load() != 0 {
+		t.Fatalf("closes = %d old, %d new, want 1 and 0", old.closes.Load(), replacement.closes.Load())
+	}
+	if seen != "new" {
+		t.Fatalf("the bucket held %q while the old value was closed, want new", seen)
+	}
+	if _, ok := logs.find("closing removed value failed"); !ok {
+		t.Fatal("the Close error wasn't logged")
+	}
+}
+
+func TestCloseEvictedValuesOnceUnderRace(t *testing.T) {
+	const n = 200
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[*resource](WithClock[*resource](clock), WithCleanupDisabled[*resource](), WithCloseEvictedValues[*resource]())
+	defer b.Close()
+
+	values := make([]*resource, n)
+	for i := range values {
+		values[i] = &resource{}
+		_ = b.NailWithTTL(strconv.Itoa(i), values[i], time.Second)
+	}
+	clock.Advance(2 * time.Second)
+
+	// Cleanup and deletes race on the same expired items
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		b.CleanupNow()
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range values {
+			_, _ = b.Unnail(strconv.Itoa(i))
+		}
+	}()
+	wg.Wait()
+
+	for i, v := range values {
+		if n := v.closes.Load(); n != 1 {
+			t.Fatalf("value %d closed %d times, want once", i, n)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package heatwave
+
+import "encoding/json"
+
+// Codec converts values to and from bytes for persistence
+type Codec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// JSONCodec encodes values with encoding/json, it is the default codec
+type JSONCodec[T any] struct{}
+
+// Encode marshals value as JSON
+func (JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode unmarshals a JSON value
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// codecOrDefault returns the configured codec, falling back to JSONCodec
+func (b *Bucket[T]) codecOrDefault() Codec[T] {
+	if b.codec != nil {
+		return b.codec
+	}
+	return JSONCodec[T]{}
+}
+
+// WithCodec sets the codec used to persist values
+func WithCodec[T any](codec Codec[T]) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.codec = codec
+	}
+}
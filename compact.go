@@ -0,0 +1,104 @@
+package heatwave
+
+// compactRatio is how far the item count must fall below its peak, as a
+// divisor, before Compact rebuilds the maps
+const compactRatio = 2
+
+// compactEntryBytes approximates the map memory of one item in the bucket map
+// and the updater index, used to estimate what Compact reclaims
+const compactEntryBytes = 64
+
+// CompactingUpdater is an optional extension of Updater for strategies that
+// keep maps or slices which can be rebuilt at their current size
+type CompactingUpdater[T any] interface {
+	Updater[T]
+	// Compact rebuilds the internal indexes to release memory, keeping the
+	// eviction order
+	Compact()
+}
+
+// Compact rebuilds the bucket map and the updater's indexes at their current
+// size and returns the approximate number of bytes reclaimed
+// Go maps never shrink, so a bucket that once held many more items keeps
+// their memory. Compact only does work once the item count has dropped below
+// half of its peak since the last Clear or Compact, and returns 0 otherwise.
+// It runs under the write lock in time proportional to the current size;
+// the eviction order and all items are kept. Updaters that don't implement
+// CompactingUpdater keep their indexes.
+func (b *Bucket[T]) Compact() int64 {
+	b.lock()
+	defer b.unlock()
+
+	if b.isClosed() || len(b.cache)*compactRatio > b.peakSize {
+		return 0
+	}
+
+	reclaimed := int64(b.peakSize-len(b.cache)) * compactEntryBytes
+	b.cache = compactMap(b.cache, b.initialCapacity)
+	if c, ok := b.updater.(CompactingUpdater[T]); ok {
+		c.Compact()
+	}
+	b.peakSize = len(b.cache)
+	return reclaimed
+}
+
+// compactMap returns a copy of m sized for its current contents, or for
+// capacity if that is larger
+func compactMap[K comparable, V any](m map[K]V, capacity int) map[K]V {
+	out := make(map[K]V, max(len(m), capacity))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// newCacheMap returns an empty bucket map sized for the initial capacity
+func (b *Bucket[T]) newCacheMap() map[string]*CacheItem[T] {
+	return make(map[string]*CacheItem[T], b.initialCapacity)
+}
+
+// WithInitialCapacity sizes the bucket map for n items up front, avoiding
+// rehashing while the bucket fills up
+// The map is sized again after Clear, and Compact never shrinks it below n.
+func WithInitialCapacity[T any](n int) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.initialCapacity = max(n, 0)
+	}
+}
+
+// Compact rebuilds the node index
+func (l *lru[T]) Compact() {
+	l.nodeMap = compactMap(l.nodeMap, 0)
+}
+
+// Compact rebuilds the node index
+func (f *fifo[T]) Compact() {
+	f.nodeMap = compactMap(f.nodeMap, 0)
+}
+
+// Compact rebuilds the entry index and the heap
+func (d *decayingLFU[T]) Compact() {
+	d.entries = compactMap(d.entries, 0)
+	d.heap = append(make(lfuHeap[T], 0, len(d.heap)), d.heap...)
+}
+
+// Compact rebuilds the position index and the entry slice
+func (s *sampledLRU[T]) Compact() {
+	s.index = compactMap(s.index, 0)
+	s.entries = append(make([]*sampledEntry[T], 0, len(s.entries)), s.entries...)
+}
+
+// Compact rebuilds the position index and the entry slice
+func (s *scoredUpdater[T]) Compact() {
+	s.index = compactMap(s.index, 0)
+	s.entries = append(make([]*scoredEntry[T], 0, len(s.entries)), s.entries...)
+}
+
+// Compact compacts every level that supports it
+func (p *priorityUpdater[T]) Compact() {
+	for _, u := range p.levels {
+		if c, ok := u.(CompactingUpdater[T]); ok {
+			c.Compact()
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package heatwave
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// ascendKeys returns the keys of b in ascending eviction order
+func ascendKeys[T any](b *Bucket[T]) []string {
+	b.rlock()
+	defer b.mutex.RUnlock()
+	ordered, _ := b.orderedUpdater()
+	var keys []string
+	ordered.Ascend(func(item *CacheItem[T]) bool {
+		keys = append(keys, item.key)
+		return true
+	})
+	return keys
+}
+
+func TestCompactKeepsEvictionOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []NewBucketOption[int]
+		order []string
+	}{
+		// The read of 3 moves it to the back of the LRU order only
+		{name: "lru", order: []string{"14", "15", "16", "17", "18", "19", "3"}},
+		{name: "fifo", opts: []NewBucketOption[int]{WithFIFOUpdater[int]()}, order: []string{"3", "14", "15", "16", "17", "18", "19"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var evicted []string
+			opts := append([]NewBucketOption[int]{
+				WithMaxSize[int](20),
+				WithInitialCapacity[int](4),
+				WithOnEvict(func(key string, value int, reason RemovalReason) {
+					if reason == ReasonEvicted {
+						evicted = append(evicted, key)
+					}
+				}),
+			}, tt.opts...)
+			b := NewBucket[int](opts...)
+			defer b.Close()
+
+			for i := 0; i < 20; i++ {
+				_ = b.Nail(strconv.Itoa(i), i)
+			}
+			b.Bring("3")
+			// Still above half of the peak of 20 items
+			for i := 0; i < 9; i++ {
+				if i != 3 {
+					_, _ = b.Unnail(strconv.Itoa(i))
+				}
+			}
+			if reclaimed := b.Compact(); reclaimed != 0 {
+				t.Fatalf("Compact at 12 of 20 items = %d, want 0", reclaimed)
+			}
+			for i := 9; i < 14; i++ {
+				_, _ = b.Unnail(strconv.Itoa(i))
+			}
+
+			if got := ascendKeys(b); !slices.Equal(got, tt.order) {
+				t.Fatalf("order before Compact = %v, want %v", got, tt.order)
+			}
+			if key, _, _ := b.EvictionCandidate(); key != tt.order[0] {
+				t.Fatalf("EvictionCandidate before Compact = %s, want %s", key, tt.order[0])
+			}
+
+			if reclaimed := b.Compact(); reclaimed != 13*compactEntryBytes {
+				t.Fatalf("Compact = %d, want %d", reclaimed, 13*compactEntryBytes)
+			}
+			if got := ascendKeys(b); !slices.Equal(got, tt.order) {
+				t.Fatalf("order after Compact = %v, want %v", got, tt.order)
+			}
+			if key, _, _ := b.EvictionCandidate(); key != tt.order[0] {
+				t.Fatalf("EvictionCandidate after Compact = %s, want %s", key, tt.order[0])
+			}
+			// The peak is reset, so a second Compact has nothing to do
+			if reclaimed := b.Compact(); reclaimed != 0 {
+				t.Fatalf("second Compact = %d, want 0", reclaimed)
+			}
+
+			// Fill the bucket back up and check who goes first
+			for i := 20; i < 34; i++ {
+				_ = b.Nail(strconv.Itoa(i), i)
+			}
+			if !slices.Equal(evicted, tt.order[:1]) {
+				t.Fatalf("evicted %v, want %v", evicted, tt.order[:1])
+			}
+			for _, key := range tt.order[1:] {
+				if v, ok := b.Bring(key); !ok || strconv.Itoa(v) != key {
+					t.Fatalf("Bring(%s) = %d, %v after Compact", key, v, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestWithInitialCapacity(t *testing.T) {
+	b := NewBucket[int](WithInitialCapacity[int](-1))
+	defer b.Close()
+	if b.initialCapacity != 0 {
+		t.Fatalf("initialCapacity = %d, want a negative capacity clamped to 0", b.initialCapacity)
+	}
+
+	b = NewBucket[int](WithInitialCapacity[int](64))
+	defer b.Close()
+	for i := 0; i < 10; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+	for i := 0; i < 8; i++ {
+		_, _ = b.Unnail(strconv.Itoa(i))
+	}
+	if reclaimed := b.Compact(); reclaimed != 8*compactEntryBytes {
+		t.Fatalf("Compact = %d, want %d", reclaimed, 8*compactEntryBytes)
+	}
+	if b.Size() != 2 {
+		t.Fatalf("Size = %d after Compact, want 2", b.Size())
+	}
+}
@@ -0,0 +1,57 @@
+package heatwave
+
+import "time"
+
+// NailIfNewer stores data only if version is newer than the stored version
+// Absent and expired keys always accept the write. Plain Nail writes reset
+// the stored version, so any versioned write after them is applied. It
+// reports whether the write was applied.
+func (b *Bucket[T]) NailIfNewer(id string, data T, version time.Time) bool {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	b.lockStaged(id, data)
+	defer b.unlock()
+
+	if b.writable() != nil {
+		return false
+	}
+	data, err := b.admit(id, data)
+	if err != nil {
+		return false
+	}
+
+	if item, exists := b.cache[id]; exists && !item.expired(b.now()) {
+		if !version.After(item.sourceTime) {
+			return false
+		}
+	}
+
+	item, err := b.setLocked(id, data, b.expiryFor(b.outdated))
+	if err != nil {
+		return false
+	}
+	item.sourceTime = version
+	return true
+}
+
+// CompareAndDelete removes id only if its live value equals expected
+// The comparison and removal happen atomically under the write lock, so an
+// entry written concurrently by someone else is never deleted. Missing and
+// expired keys return false.
+func CompareAndDelete[T comparable](b *Bucket[T], id string, expected T) bool {
+	b.lock()
+	defer b.unlock()
+
+	if b.writable() != nil {
+		return false
+	}
+
+	item, exists := b.cache[id]
+	if !exists || item.expired(b.now()) || b.valueOf(item) != expected {
+		return false
+	}
+	b.removeLocked(item, ReasonDeleted)
+	return true
+}
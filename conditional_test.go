@@ -0,0 +1,86 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNailIfNewerKeepsNewestValue(t *testing.T) {
+	b := NewBucket[string]()
+	defer b.Close()
+
+	base := time.Unix(1000, 0)
+	writes := []struct {
+		value   string
+		version time.Time
+		applied bool
+	}{
+		{"v2", base.Add(2 * time.Second), true},
+		{"v1", base.Add(time.Second), false}, // Arrives late
+		{"v3", base.Add(3 * time.Second), true},
+		{"v3-dup", base.Add(3 * time.Second), false}, // Not strictly newer
+	}
+	for _, w := range writes {
+		if got := b.NailIfNewer("k", w.value, w.version); got != w.applied {
+			t.Fatalf("NailIfNewer(%s) = %v, want %v", w.value, got, w.applied)
+		}
+	}
+	if v, _ := b.Bring("k"); v != "v3" {
+		t.Fatalf("Bring = %q, want v3", v)
+	}
+
+	// A plain Nail resets the version, so any versioned write wins again
+	_ = b.Nail("k", "plain")
+	if !b.NailIfNewer("k", "old", base) {
+		t.Fatal("versioned write after a plain Nail was rejected")
+	}
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	b := NewBucket[string]()
+	defer b.Close()
+
+	_ = b.Nail("k", "a")
+	if CompareAndDelete(b, "k", "b") {
+		t.Fatal("CompareAndDelete removed a different value")
+	}
+	if !CompareAndDelete(b, "k", "a") {
+		t.Fatal("CompareAndDelete didn't remove the expected value")
+	}
+	if exists(b, "k") {
+		t.Fatal("key still present")
+	}
+}
+
+func TestCompareAndDeleteNotifiesEviction(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	var reasons []RemovalReason
+	b := NewBucket[string](
+		WithClock[string](clock),
+		WithCleanupDisabled[string](),
+		WithOnEvict(func(key string, value string, reason RemovalReason) {
+			reasons = append(reasons, reason)
+		}),
+	)
+	defer b.Close()
+
+	_ = b.Nail("k", "a")
+	if !CompareAndDelete(b, "k", "a") {
+		t.Fatal("CompareAndDelete didn't remove the expected value")
+	}
+	if len(reasons) != 1 || reasons[0] != ReasonDeleted {
+		t.Fatalf("eviction reasons = %v, want [deleted]", reasons)
+	}
+	if b.Size() != 0 {
+		t.Fatalf("Size = %d, want 0", b.Size())
+	}
+
+	if CompareAndDelete(b, "missing", "") {
+		t.Fatal("CompareAndDelete removed a missing key")
+	}
+	_ = b.NailWithTTL("e", "a", time.Second)
+	clock.Advance(2 * time.Second)
+	if CompareAndDelete(b, "e", "a") {
+		t.Fatal("CompareAndDelete removed an expired key")
+	}
+}
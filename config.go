@@ -0,0 +1,119 @@
+package heatwave
+
+import (
+	"fmt"
+	"time"
+)
+
+// Strategy names a built-in eviction strategy for Config
+type Strategy int
+
+const (
+	// StrategyLRU evicts the least recently used item, the default
+	StrategyLRU Strategy = iota
+	// StrategyFIFO evicts the oldest inserted item, see WithFIFOUpdater
+	StrategyFIFO
+	// StrategySampledLRU approximates LRU by sampling, see
+	// WithSampledLRUUpdater
+	StrategySampledLRU
+	// StrategyDecayingLFU evicts the least frequently used item, see
+	// WithDecayingLFUUpdater
+	StrategyDecayingLFU
+)
+
+// Config is the plain form of the most common bucket options
+// Zero fields keep the defaults, so only the settings that matter need to be
+// spelled out and no type parameter has to be repeated.
+type Config struct {
+	Name            string        // Name of the bucket
+	MaxSize         int           // Maximum number of items, zero keeps 1000
+	TTL             time.Duration // Default TTL, zero keeps 5 minutes
+	NeverExpire     bool          // Items never expire by time, TTL must be zero
+	CleanupInterval time.Duration // Housekeeping interval, zero keeps one minute
+	Strategy        Strategy      // Eviction strategy
+	SampleSize      int           // Sample size of StrategySampledLRU, zero keeps the default
+	HalfLife        time.Duration // Decay half-life of StrategyDecayingLFU, zero disables decay
+}
+
+// NewBucketWithConfig creates a bucket from cfg
+// The options in extra are applied after cfg and override it, which covers
+// everything Config doesn't, e.g.
+//
+//	heatwave.NewBucketWithConfig(heatwave.Config{MaxSize: 3},
+//		heatwave.WithOnEvict(func(key string, value string, reason heatwave.RemovalReason) {}))
+//
+// It panics where NewBucketWithConfigE returns an error.
+func NewBucketWithConfig[T any](cfg Config, extra ...NewBucketOption[T]) *Bucket[T] {
+	b, err := NewBucketWithConfigE(cfg, extra...)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// NewBucketWithConfigE is NewBucketWithConfig that fails with
+// ErrInvalidConfig when cfg doesn't pass Validate, and like NewBucketE
+func NewBucketWithConfigE[T any](cfg Config, extra ...NewBucketOption[T]) (*Bucket[T], error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return NewBucketE(append(configOptions[T](cfg), extra...)...)
+}
+
+// Validate reports settings that can't be honoured, wrapping
+// ErrInvalidConfig
+// Negative sizes and durations are rejected, as are a TTL together with
+// NeverExpire, an unknown Strategy and a SampleSize or HalfLife for a
+// strategy that doesn't use it.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.MaxSize < 0:
+		return fmt.Errorf("%w: negative MaxSize %d", ErrInvalidConfig, cfg.MaxSize)
+	case cfg.TTL < 0:
+		return fmt.Errorf("%w: negative TTL %v", ErrInvalidConfig, cfg.TTL)
+	case cfg.CleanupInterval < 0:
+		return fmt.Errorf("%w: negative CleanupInterval %v", ErrInvalidConfig, cfg.CleanupInterval)
+	case cfg.SampleSize < 0:
+		return fmt.Errorf("%w: negative SampleSize %d", ErrInvalidConfig, cfg.SampleSize)
+	case cfg.HalfLife < 0:
+		return fmt.Errorf("%w: negative HalfLife %v", ErrInvalidConfig, cfg.HalfLife)
+	case cfg.NeverExpire && cfg.TTL > 0:
+		return fmt.Errorf("%w: TTL set together with NeverExpire", ErrInvalidConfig)
+	case cfg.Strategy < StrategyLRU || cfg.Strategy > StrategyDecayingLFU:
+		return fmt.Errorf("%w: unknown Strategy %d", ErrInvalidConfig, cfg.Strategy)
+	case cfg.SampleSize > 0 && cfg.Strategy != StrategySampledLRU:
+		return fmt.Errorf("%w: SampleSize needs StrategySampledLRU", ErrInvalidConfig)
+	case cfg.HalfLife > 0 && cfg.Strategy != StrategyDecayingLFU:
+		return fmt.Errorf("%w: HalfLife needs StrategyDecayingLFU", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// configOptions translates cfg into options
+func configOptions[T any](cfg Config) []NewBucketOption[T] {
+	var opts []NewBucketOption[T]
+	if cfg.Name != "" {
+		opts = append(opts, WithBucketName[T](cfg.Name))
+	}
+	if cfg.MaxSize > 0 {
+		opts = append(opts, WithMaxSize[T](cfg.MaxSize))
+	}
+	switch {
+	case cfg.NeverExpire:
+		opts = append(opts, WithBucketNeverExpire[T]())
+	case cfg.TTL > 0:
+		opts = append(opts, WithBucketExpire[T](cfg.TTL))
+	}
+	if cfg.CleanupInterval > 0 {
+		opts = append(opts, WithCleanupInterval[T](cfg.CleanupInterval))
+	}
+	switch cfg.Strategy {
+	case StrategyFIFO:
+		opts = append(opts, WithFIFOUpdater[T]())
+	case StrategySampledLRU:
+		opts = append(opts, WithSampledLRUUpdater[T](cfg.SampleSize))
+	case StrategyDecayingLFU:
+		opts = append(opts, WithDecayingLFUUpdater[T](cfg.HalfLife))
+	}
+	return opts
+}
@@ -0,0 +1,154 @@
+package heatwave
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewBucketWithConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		check func(t *testing.T, b *Bucket[int])
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, b *Bucket[int]) {
+				if b.maxSize != defaultMaxSize || *b.outdated != defaultOutdated || b.cleanupInterval != defaultCleanupInterval {
+					t.Fatalf("maxSize = %d, TTL = %v, cleanup = %v, want the defaults", b.maxSize, *b.outdated, b.cleanupInterval)
+				}
+				if _, ok := b.updater.(*lru[int]); !ok {
+					t.Fatalf("updater = %T, want LRU", b.updater)
+				}
+			},
+		},
+		{
+			name: "name",
+			cfg:  Config{Name: "sessions"},
+			check: func(t *testing.T, b *Bucket[int]) {
+				if b.name != "sessions" {
+					t.Fatalf("name = %q", b.name)
+				}
+			},
+		},
+		{
+			name: "max size",
+			cfg:  Config{MaxSize: 3},
+			check: func(t *testing.T, b *Bucket[int]) {
+				if b.maxSize != 3 {
+					t.Fatalf("maxSize = %d, want 3", b.maxSize)
+				}
+			},
+		},
+		{
+			name: "ttl",
+			cfg:  Config{TTL: time.Hour},
+			check: func(t *testing.T, b *Bucket[int]) {
+				if b.outdated == nil || *b.outdated != time.Hour {
+					t.Fatalf("TTL = %v, want 1h", b.outdated)
+				}
+			},
+		},
+		{
+			name: "never expire",
+			cfg:  Config{NeverExpire: true},
+			check: func(t *testing.T, b *Bucket[int]) {
+				if b.outdated != nil {
+					t.Fatalf("TTL = %v, want none", *b.outdated)
+				}
+			},
+		},
+		{
+			name: "cleanup interval",
+			cfg:  Config{CleanupInterval: time.Second},
+			check: func(t *testing.T, b *Bucket[int]) {
+				if b.cleanupInterval != time.Second {
+					t.Fatalf("cleanupInterval = %v, want 1s", b.cleanupInterval)
+				}
+			},
+		},
+		{
+			name: "fifo",
+			cfg:  Config{Strategy: StrategyFIFO},
+			check: func(t *testing.T, b *Bucket[int]) {
+				if _, ok := b.updater.(*fifo[int]); !ok {
+					t.Fatalf("updater = %T, want FIFO", b.updater)
+				}
+			},
+		},
+		{
+			name: "sampled lru",
+			cfg:  Config{Strategy: StrategySampledLRU, SampleSize: 7},
+			check: func(t *testing.T, b *Bucket[int]) {
+				s, ok := b.updater.(*sampledLRU[int])
+				if !ok || s.sampleSize != 7 {
+					t.Fatalf("updater = %#v, want sampled LRU with 7 samples", b.updater)
+				}
+			},
+		},
+		{
+			name: "decaying lfu",
+			cfg:  Config{Strategy: StrategyDecayingLFU, HalfLife: time.Minute},
+			check: func(t *testing.T, b *Bucket[int]) {
+				l, ok := b.updater.(*decayingLFU[int])
+				if !ok || l.halfLife != time.Minute {
+					t.Fatalf("updater = %#v, want decaying LFU with a 1m half-life", b.updater)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBucketWithConfigE[int](tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer b.Close()
+			tt.check(t, b)
+		})
+	}
+}
+
+func TestNewBucketWithConfigExtraOverrides(t *testing.T) {
+	b := NewBucketWithConfig(Config{MaxSize: 3, Strategy: StrategyFIFO}, WithMaxSize[int](5))
+	defer b.Close()
+	if b.maxSize != 5 {
+		t.Fatalf("maxSize = %d, want the extra option's 5", b.maxSize)
+	}
+	if _, ok := b.updater.(*fifo[int]); !ok {
+		t.Fatalf("updater = %T, want the config's FIFO", b.updater)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	invalid := map[string]Config{
+		"negative max size":            {MaxSize: -1},
+		"negative ttl":                 {TTL: -time.Second},
+		"negative cleanup interval":    {CleanupInterval: -time.Second},
+		"negative sample size":         {Strategy: StrategySampledLRU, SampleSize: -1},
+		"negative half-life":           {Strategy: StrategyDecayingLFU, HalfLife: -time.Second},
+		"ttl with never expire":        {TTL: time.Hour, NeverExpire: true},
+		"unknown strategy":             {Strategy: Strategy(42)},
+		"sample size without sampling": {SampleSize: 5},
+		"half-life without lfu":        {Strategy: StrategyFIFO, HalfLife: time.Minute},
+	}
+	for name, cfg := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if err := cfg.Validate(); !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("Validate = %v, want ErrInvalidConfig", err)
+			}
+			if b, err := NewBucketWithConfigE[int](cfg); b != nil || !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("NewBucketWithConfigE = %v, %v, want ErrInvalidConfig", b, err)
+			}
+		})
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("NewBucketWithConfig didn't panic on an invalid config")
+		}
+	}()
+	NewBucketWithConfig[int](Config{MaxSize: -1})
+}
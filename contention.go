@@ -0,0 +1,92 @@
+package heatwave
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// ContentionStats describes sampled lock wait times per path
+type ContentionStats struct {
+	Read    LatencySummary // Read lock acquisitions by readers
+	Write   LatencySummary // Write lock acquisitions by Nail, Bring and other mutators
+	Cleanup LatencySummary // Write lock acquisitions by the cleanup goroutine
+}
+
+// contention samples lock wait times
+type contention struct {
+	rate    float64
+	read    histogram
+	write   histogram
+	cleanup histogram
+}
+
+// sample reports whether the current acquisition should be timed
+func (c *contention) sample() bool {
+	return c.rate >= 1 || rand.Float64() < c.rate
+}
+
+// reset zeroes every histogram
+func (c *contention) reset() {
+	c.read.reset()
+	c.write.reset()
+	c.cleanup.reset()
+}
+
+// lock acquires the write lock, sampling the wait time when profiling
+func (b *Bucket[T]) lock() {
+	if b.contention == nil || !b.contention.sample() {
+		b.mutex.Lock()
+		return
+	}
+	start := time.Now()
+	b.mutex.Lock()
+	b.contention.write.observe(start)
+}
+
+// rlock acquires the read lock, sampling the wait time when profiling
+func (b *Bucket[T]) rlock() {
+	if b.contention == nil || !b.contention.sample() {
+		b.mutex.RLock()
+		return
+	}
+	start := time.Now()
+	b.mutex.RLock()
+	b.contention.read.observe(start)
+}
+
+// lockCleanup acquires the write lock for the cleanup goroutine
+func (b *Bucket[T]) lockCleanup() {
+	if b.contention == nil || !b.contention.sample() {
+		b.mutex.Lock()
+		return
+	}
+	start := time.Now()
+	b.mutex.Lock()
+	b.contention.cleanup.observe(start)
+}
+
+// ContentionStats returns sampled lock wait times
+// It returns zero values unless WithContentionProfiling is enabled.
+func (b *Bucket[T]) ContentionStats() ContentionStats {
+	if b.contention == nil {
+		return ContentionStats{}
+	}
+	return ContentionStats{
+		Read:    b.contention.read.summary(),
+		Write:   b.contention.write.summary(),
+		Cleanup: b.contention.cleanup.summary(),
+	}
+}
+
+// WithContentionProfiling samples lock acquisitions at sampleRate (0 to 1)
+// and records how long they waited for the bucket mutex
+// At low rates the cost of unsampled acquisitions is a single random number.
+// A non-positive rate disables profiling.
+func WithContentionProfiling[T any](sampleRate float64) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.contention = nil
+		if sampleRate > 0 {
+			b.contention = &contention{rate: sampleRate}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package heatwave
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestContentionProfiling(t *testing.T) {
+	b := NewBucket[int](WithCleanupDisabled[int](), WithContentionProfiling[int](1))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	if s := b.ContentionStats(); s.Write.Count != 1 || s.Read.Count != 0 {
+		t.Fatalf("after Nail %+v, want one write acquisition", s)
+	}
+	b.ExistsMany([]string{"a"})
+	if s := b.ContentionStats(); s.Write.Count != 1 || s.Read.Count != 1 {
+		t.Fatalf("after ExistsMany %+v, want one read acquisition", s)
+	}
+	b.CleanupNow()
+	if s := b.ContentionStats(); s.Write.Count != 1 || s.Cleanup.Count != 1 {
+		t.Fatalf("after CleanupNow %+v, want one cleanup acquisition", s)
+	}
+
+	off := NewBucket[int](WithContentionProfiling[int](0))
+	defer off.Close()
+	_ = off.Nail("a", 1)
+	if s := off.ContentionStats(); s != (ContentionStats{}) {
+		t.Fatalf("ContentionStats with profiling disabled = %+v", s)
+	}
+}
+
+// BenchmarkContentionProfiling measures the overhead of sampling lock waits
+// under parallel Bring and Nail traffic
+func BenchmarkContentionProfiling(b *testing.B) {
+	const size = 1024
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	for _, tc := range []struct {
+		name string
+		rate float64
+	}{
+		{"Off", 0},
+		{"Sample1Percent", 0.01},
+		{"SampleAll", 1},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			bucket := NewBucket[int](WithMaxSize[int](size), WithContentionProfiling[int](tc.rate))
+			defer bucket.Close()
+			for i, key := range keys {
+				_ = bucket.Nail(key, i)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := keys[i%size]
+					if i%10 == 0 {
+						_ = bucket.Nail(key, i)
+					} else {
+						bucket.Bring(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package heatwave
+
+// CopyDirection selects when a bucket clones values
+type CopyDirection int
+
+const (
+	// CopyOnWrite clones values passed to Nail and other write paths
+	CopyOnWrite CopyDirection = 1 << iota
+	// CopyOnRead clones values returned from Bring and other read paths
+	CopyOnRead
+	// CopyBoth clones values in both directions
+	CopyBoth = CopyOnWrite | CopyOnRead
+)
+
+// WithValueCopier clones values so callers can't mutate the cached copy
+// The copier is only invoked when the option is set, so buckets without it
+// pay nothing.
+func WithValueCopier[T any](copier func(T) T, dir CopyDirection) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.copyIn, b.copyOut = nil, nil
+		if copier == nil {
+			return
+		}
+		if dir&CopyOnWrite != 0 {
+			b.copyIn = copier
+		}
+		if dir&CopyOnRead != 0 {
+			b.copyOut = copier
+		}
+	}
+}
+
+// CopyBytes is a copier for []byte buckets
+func CopyBytes(v []byte) []byte {
+	if v == nil {
+		return nil
+	}
+	out := make([]byte, len(v))
+	copy(out, v)
+	return out
+}
+
+// CloneValue is a copier for types implementing a Clone method
+func CloneValue[T interface{ Clone() T }](v T) T {
+	return v.Clone()
+}
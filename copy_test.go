@@ -0,0 +1,146 @@
+package heatwave
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestValueCopierDirections(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		dir          CopyDirection
+		writeAliased bool // A mutation after Nail reaches the cached copy
+		readAliased  bool // A mutation after Bring reaches the cached copy
+	}{
+		{"CopyOnWrite", CopyOnWrite, false, true},
+		{"CopyOnRead", CopyOnRead, true, false},
+		{"CopyBoth", CopyBoth, false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBucket[[]byte](WithValueCopier(CopyBytes, tc.dir))
+			defer b.Close()
+
+			in := []byte("abc")
+			_ = b.Nail("k", in)
+			in[0] = 'X'
+			got, _ := b.Bring("k")
+			if aliased := got[0] == 'X'; aliased != tc.writeAliased {
+				t.Fatalf("mutation after Nail reached the cache = %v, want %v", aliased, tc.writeAliased)
+			}
+
+			_ = b.Nail("k", []byte("abc"))
+			out, _ := b.Bring("k")
+			out[0] = 'Y'
+			got, _ = b.Bring("k")
+			if aliased := got[0] == 'Y'; aliased != tc.readAliased {
+				t.Fatalf("mutation after Bring reached the cache = %v, want %v", aliased, tc.readAliased)
+			}
+		})
+	}
+}
+
+// cloneable is a value with a Clone method
+type cloneable struct {
+	tags []string
+}
+
+func (c *cloneable) Clone() *cloneable {
+	return &cloneable{tags: append([]string(nil), c.tags...)}
+}
+
+func TestCloneValue(t *testing.T) {
+	b := NewBucket[*cloneable](WithValueCopier(CloneValue[*cloneable], CopyBoth))
+	defer b.Close()
+
+	v := &cloneable{tags: []string{"a"}}
+	_ = b.Nail("k", v)
+	v.tags[0] = "mutated"
+	got, _ := b.Bring("k")
+	if got == v || got.tags[0] != "a" {
+		t.Fatalf("Bring = %v, want an unaliased clone holding a", got.tags)
+	}
+	got.tags[0] = "mutated"
+	if again, _ := b.Bring("k"); again.tags[0] != "a" {
+		t.Fatal("mutating a read value changed the cached copy")
+	}
+}
+
+func TestValueCopierLoadPaths(t *testing.T) {
+	loaded := []byte("abc")
+	b := NewBucket[[]byte](
+		WithValueCopier(CopyBytes, CopyOnRead),
+		WithLoader(func(id string) ([]byte, error) { return loaded, nil }),
+	)
+	defer b.Close()
+
+	for name, load := range map[string]func(id string) ([]byte, error){
+		"GetOrLoad": func(id string) ([]byte, error) {
+			return b.GetOrLoad(id, func() ([]byte, error) { return loaded, nil })
+		},
+		"Load": b.Load,
+		"BringContext": func(id string) ([]byte, error) {
+			return b.BringContext(context.Background(), id)
+		},
+		"Refresh": func(id string) ([]byte, error) {
+			return b.Refresh(id, func() ([]byte, error) { return loaded, nil })
+		},
+	} {
+		out, err := load(name)
+		if err != nil {
+			t.Fatalf("%s = %v", name, err)
+		}
+		out[0] = 'X'
+		if got, _ := b.Bring(name); !bytes.Equal(got, []byte("abc")) {
+			t.Fatalf("mutating the result of %s changed the cached copy to %q", name, got)
+		}
+	}
+}
+
+func TestValueCopierBatchAndSnapshotPaths(t *testing.T) {
+	b := NewBucket[[]byte](WithValueCopier(CopyBytes, CopyBoth))
+	defer b.Close()
+
+	warm := []byte("warm")
+	_ = b.Warm([]WarmEntry[[]byte]{{Key: "warm", Value: warm}})
+	warm[0] = 'X'
+	txn := []byte("txn")
+	_ = b.Txn(func(tx *Tx[[]byte]) error { return tx.Set("txn", txn) })
+	txn[0] = 'X'
+	for key, want := range map[string]string{"warm": "warm", "txn": "txn"} {
+		if got, _ := b.Bring(key); string(got) != want {
+			t.Fatalf("%s = %q after mutating the written slice, want %q", key, got, want)
+		}
+	}
+
+	m := b.ToMap()
+	m["warm"][0] = 'X'
+	if got, _ := b.Bring("warm"); string(got) != "warm" {
+		t.Fatalf("mutating ToMap output changed the cached copy to %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := b.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	r := NewBucket[[]byte](WithValueCopier(CopyBytes, CopyOnRead))
+	defer r.Close()
+	if _, err := r.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := r.Bring("warm")
+	out[0] = 'X'
+	if got, _ := r.Bring("warm"); string(got) != "warm" {
+		t.Fatalf("mutating a restored value changed the cached copy to %q", got)
+	}
+}
+
+func TestNoCopierDoesNotAllocate(t *testing.T) {
+	b := NewBucket[[]byte]()
+	defer b.Close()
+	_ = b.Nail("k", []byte("abc"))
+
+	if n := testing.AllocsPerRun(100, func() { b.Bring("k") }); n != 0 {
+		t.Fatalf("Bring without a copier allocates %v times", n)
+	}
+}
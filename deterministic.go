@@ -0,0 +1,61 @@
+package heatwave
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// deterministicEpoch is where the clock of WithDeterministic starts
+var deterministicEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// seedable is implemented by updaters that make random choices
+type seedable interface {
+	seed(rng *rand.Rand)
+}
+
+// seedUpdater hands the bucket's seeded source to u if it makes random
+// choices, leaving it on the global source otherwise
+func (b *Bucket[T]) seedUpdater(u Updater[T]) {
+	if s, ok := u.(seedable); ok && b.rng != nil {
+		s.seed(b.rng)
+	}
+}
+
+// intN returns a random int in [0, n) from rng, or the global source when
+// rng is nil
+func intN(rng *rand.Rand, n int) int {
+	if rng == nil {
+		return rand.IntN(n)
+	}
+	return rng.IntN(n)
+}
+
+// CleanupNow runs one cleanup pass on the calling goroutine and returns how
+// many items it removed
+// It removes the items that are due, runs the expire interceptor and drops
+// stale loader errors, as the background goroutine does every cleanup
+// interval. A closed or frozen bucket is left alone.
+func (b *Bucket[T]) CleanupNow() int {
+	if b.isClosed() || b.frozen.Load() {
+		return 0
+	}
+	return b.cleanupExpired(true)
+}
+
+// WithDeterministic makes the bucket reproducible for tests and fuzzing
+// Unless WithClock set one, the bucket reads the time from a ManualClock
+// standing at 2000-01-01 UTC, reachable through Bucket.Clock. No cleanup
+// goroutine is started, expired items are removed lazily and by CleanupNow,
+// and sampled and scored eviction draw from a source seeded with seed, so
+// the same calls always make the same eviction decisions. Only meant for
+// tests.
+func WithDeterministic[T any](seed int64) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		if b.clock == nil {
+			b.clock = NewManualClock(deterministicEpoch)
+		}
+		b.cleanupDisabled = true
+		b.cleanupJitter = false
+		b.rng = rand.New(rand.NewPCG(uint64(seed), uint64(seed)))
+	}
+}
@@ -0,0 +1,55 @@
+package heatwave
+
+import (
+	"strconv"
+	"testing"
+)
+
+// sampledEvictions replays a fixed workload on a deterministic sampled LRU
+// bucket and returns the evicted keys in order
+func sampledEvictions(seed int64) []string {
+	var evicted []string
+	b := NewBucket[int](
+		WithDeterministic[int](seed),
+		WithMaxSize[int](100),
+		WithSampledLRUUpdater[int](5),
+		WithOnEvict(func(key string, value int, reason RemovalReason) {
+			if reason == ReasonEvicted {
+				evicted = append(evicted, key)
+			}
+		}),
+	)
+	defer b.Close()
+
+	for i := 0; i < 300; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+		if i%3 == 0 {
+			b.Bring(strconv.Itoa(i / 2))
+		}
+	}
+	return evicted
+}
+
+func TestDeterministicEvictionIsReproducible(t *testing.T) {
+	first := sampledEvictions(7)
+	if len(first) != 200 {
+		t.Fatalf("evicted %d items, want 200", len(first))
+	}
+	second := sampledEvictions(7)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("eviction %d differs between runs: %s and %s", i, first[i], second[i])
+		}
+	}
+}
+
+func TestDeterministicStartsNoGoroutines(t *testing.T) {
+	b := NewBucket[int](WithDeterministic[int](1))
+	defer b.Close()
+	if n := b.GoroutineCount(); n != 0 {
+		t.Fatalf("GoroutineCount = %d, want 0", n)
+	}
+	if _, ok := b.Clock().(*ManualClock); !ok {
+		t.Fatalf("Clock = %T, want *ManualClock", b.Clock())
+	}
+}
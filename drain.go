@@ -0,0 +1,34 @@
+package heatwave
+
+// Drain removes every item and returns the live ones
+// It is Clear with a return value, meant for handing the contents over to
+// another node. Drained items are not reported to removal observers since
+// they are handed to the caller, expired ones are reported as expired.
+func (b *Bucket[T]) Drain() map[string]T {
+	b.lock()
+	defer b.unlock()
+
+	if b.writable() != nil {
+		return map[string]T{}
+	}
+
+	now := b.now()
+	out := make(map[string]T, len(b.cache))
+	for key, item := range b.cache {
+		if item.expired(now) {
+			b.counters.expirations.Add(1)
+			b.recordLocked(item, ReasonExpired)
+			continue
+		}
+		out[key] = b.readValue(item)
+	}
+	b.dropAllSpillsLocked()
+	b.cache = b.newCacheMap()
+	b.keys.entries = nil
+	b.peakSize = 0
+	b.updater.Clear()
+	b.totalBytes = 0
+	b.resetExpiriesLocked()
+	b.logClearLocked()
+	return out
+}
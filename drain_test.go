@@ -0,0 +1,37 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_ = b.NailWithTTL("gone", 3, time.Second)
+	clock.Advance(2 * time.Second)
+
+	got := b.Drain()
+	if len(got) != 2 || got["a"] != 1 || got["b"] != 2 {
+		t.Fatalf("Drain = %v, want map[a:1 b:2]", got)
+	}
+	if b.Size() != 0 {
+		t.Fatalf("Size after Drain = %d, want 0", b.Size())
+	}
+	if _, ok := b.Bring("a"); ok {
+		t.Fatal("drained key still readable")
+	}
+
+	// The bucket stays usable
+	_ = b.Nail("c", 4)
+	if v, ok := b.Bring("c"); !ok || v != 4 {
+		t.Fatalf("Bring(c) after Drain = %d, %v", v, ok)
+	}
+	if got := b.Drain(); len(got) != 1 {
+		t.Fatalf("second Drain = %v, want one entry", got)
+	}
+}
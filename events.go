@@ -0,0 +1,187 @@
+package heatwave
+
+// RemovalReason describes why an item left the bucket
+type RemovalReason int
+
+const (
+	// ReasonEvicted means the item was evicted to make room for another one
+	ReasonEvicted RemovalReason = iota
+	// ReasonExpired means the item's TTL passed
+	ReasonExpired
+	// ReasonDeleted means the item was removed explicitly
+	ReasonDeleted
+	// ReasonCleared means the item was removed by Clear
+	ReasonCleared
+)
+
+// String returns the name of the reason
+func (r RemovalReason) String() string {
+	switch r {
+	case ReasonEvicted:
+		return "evicted"
+	case ReasonExpired:
+		return "expired"
+	case ReasonDeleted:
+		return "deleted"
+	case ReasonCleared:
+		return "cleared"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictCallback is called after an item leaves the bucket
+type EvictCallback[T any] func(key string, value T, reason RemovalReason)
+
+// ExpireCallback is called after an item is removed because its TTL passed
+type ExpireCallback[T any] func(key string, value T)
+
+// removal is a removal recorded under the lock and dispatched after it
+type removal[T any] struct {
+	key      string
+	value    T
+	reason   RemovalReason
+	replaced bool // Value overwritten by an update, only closed and not reported
+}
+
+// removeLocked removes item from the updater and the map
+// Explicit deletions are published to other replicas.
+// Must be called with b.mutex held
+func (b *Bucket[T]) removeLocked(item *CacheItem[T], reason RemovalReason) {
+	b.updater.Remove(item)
+	b.forgetLocked(item, reason)
+	if reason == ReasonDeleted {
+		b.publishLocked(item.key)
+	}
+}
+
+// forgetLocked removes an item the updater has already dropped from the map,
+// updates counters and records the removal for observers
+// Must be called with b.mutex held
+func (b *Bucket[T]) forgetLocked(item *CacheItem[T], reason RemovalReason) {
+	delete(b.cache, item.key)
+	b.totalBytes -= item.size
+	b.unscheduleLocked(item)
+	switch reason {
+	case ReasonEvicted:
+		b.counters.evictions.Add(1)
+	case ReasonExpired:
+		b.counters.expirations.Add(1)
+	case ReasonDeleted:
+		b.logDeleteLocked(item.key)
+	}
+	b.traceRemovalLocked(item.key, reason)
+	b.recordLocked(item, reason)
+	b.dropSpillLocked(item)
+}
+
+// recordLocked queues a removal for observers, must be called with b.mutex held
+func (b *Bucket[T]) recordLocked(item *CacheItem[T], reason RemovalReason) {
+	if b.observed() {
+		b.pending = append(b.pending, removal[T]{key: item.key, value: b.valueOf(item), reason: reason})
+	}
+}
+
+// recordReplacedLocked queues the old value of an updated item for closing
+// Must be called with b.mutex held
+func (b *Bucket[T]) recordReplacedLocked(key string, old, data T) {
+	if b.closeEvicted && !sameCloser(old, data) {
+		b.pending = append(b.pending, removal[T]{key: key, value: old, replaced: true})
+	}
+}
+
+// observed reports whether anyone listens for removals
+func (b *Bucket[T]) observed() bool {
+	return b.traceHook != nil || b.onEvict != nil || b.onExpire != nil || b.logger != nil || b.closeEvicted ||
+		b.expiryWatched.Load()
+}
+
+// unlock releases the write lock and then dispatches recorded removals
+// Observers never run while b.mutex is held. A spill file staged for a write
+// that didn't happen is deleted.
+func (b *Bucket[T]) unlock() {
+	pending, invalidations := b.pending, b.invalidations
+	b.pending, b.invalidations = nil, nil
+	var group *BudgetGroup
+	if b.groupGrew {
+		group, b.groupGrew = b.group, false
+	}
+	violations := b.debugCheckLocked()
+	staged := b.stagedSpill
+	b.stagedSpill = nil
+	b.mutex.Unlock()
+
+	if staged != nil {
+		// The write the file was staged for didn't happen
+		b.removeSpill(staged)
+	}
+
+	if violations != nil {
+		panic(violations)
+	}
+	if group != nil {
+		group.enforce()
+	}
+	if len(pending) > 0 {
+		b.dispatch(pending)
+	}
+	if len(invalidations) > 0 {
+		b.publish(invalidations)
+	}
+}
+
+// dispatch notifies observers of removals
+func (b *Bucket[T]) dispatch(removals []removal[T]) {
+	for _, r := range removals {
+		if b.closeEvicted {
+			b.closeValue(r.key, r.value)
+		}
+		if r.replaced {
+			continue
+		}
+		if b.onEvict != nil {
+			b.guard(func() { b.onEvict(r.key, r.value, r.reason) })
+		}
+		if b.onExpire != nil && r.reason == ReasonExpired {
+			b.guard(func() { b.onExpire(r.key, r.value) })
+		}
+		if r.reason == ReasonExpired && b.expiryWatched.Load() {
+			b.notifyExpired(r.key)
+		}
+		if b.traceHook != nil {
+			b.guard(func() { b.traceHook.OnEvict(r.key, r.reason) })
+		}
+		if b.logger != nil && r.reason == ReasonEvicted {
+			b.log(LogDebug, "item evicted", "key", r.key, "reason", r.reason.String())
+		}
+	}
+}
+
+// guard runs a user hook, recovering and counting panics
+func (b *Bucket[T]) guard(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.counters.hookPanics.Add(1)
+		}
+	}()
+	fn()
+}
+
+// WithOnExpire sets a callback invoked only for items removed because their
+// TTL passed, by the cleanup pass or a read that finds them expired
+// Capacity evictions, deletions and Clear don't trigger it. Like WithOnEvict
+// it runs outside the bucket lock and panics are recovered.
+func WithOnExpire[T any](fn ExpireCallback[T]) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.onExpire = fn
+	}
+}
+
+// WithOnEvict sets a callback invoked after any item leaves the bucket
+// The callback runs outside the bucket lock, panics are recovered and counted
+// in Stats.HookPanics.
+func WithOnEvict[T any](fn EvictCallback[T]) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.onEvict = fn
+	}
+}
@@ -0,0 +1,48 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnExpireOnlyForExpiry(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	var expired []string
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithMaxSize[int](3),
+		WithOnExpire(func(key string, value int) {
+			expired = append(expired, key)
+		}),
+	)
+	defer b.Close()
+
+	_ = b.NailWithTTL("lazy", 1, time.Second)
+	_ = b.NailWithTTL("swept", 2, time.Second)
+	_ = b.Nail("deleted", 3)
+	_, _ = b.Unnail("deleted")
+	clock.Advance(2 * time.Second)
+
+	if _, ok := b.Bring("lazy"); ok {
+		t.Fatal("Bring returned an expired item")
+	}
+	if n := b.CleanupNow(); n != 1 {
+		t.Fatalf("CleanupNow = %d, want 1", n)
+	}
+	if len(expired) != 2 || expired[0] != "lazy" || expired[1] != "swept" {
+		t.Fatalf("expired = %v, want [lazy swept]", expired)
+	}
+
+	// Capacity evictions and Clear don't count as expiry
+	for _, key := range []string{"a", "b", "c", "d"} {
+		_ = b.Nail(key, 0)
+	}
+	if ev := b.Stats().Evictions; ev != 1 {
+		t.Fatalf("Evictions = %d, want 1", ev)
+	}
+	b.Clear()
+	if len(expired) != 2 {
+		t.Fatalf("expired = %v after eviction and Clear, want [lazy swept]", expired)
+	}
+}
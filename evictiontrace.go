@@ -0,0 +1,70 @@
+package heatwave
+
+import "time"
+
+// TraceEntry records one eviction or expiration
+type TraceEntry struct {
+	Key    string
+	Reason RemovalReason
+	Time   time.Time
+	Size   int // Number of items in the bucket right after the removal
+}
+
+// evictionTrace is a fixed-size ring buffer of recent removals
+type evictionTrace struct {
+	entries []TraceEntry
+	next    int  // Slot written next
+	full    bool // Whether the buffer has wrapped around
+}
+
+// add records an entry, overwriting the oldest one when full
+func (t *evictionTrace) add(entry TraceEntry) {
+	t.entries[t.next] = entry
+	t.next++
+	if t.next == len(t.entries) {
+		t.next = 0
+		t.full = true
+	}
+}
+
+// snapshot returns the entries from oldest to newest
+func (t *evictionTrace) snapshot() []TraceEntry {
+	if !t.full {
+		return append([]TraceEntry(nil), t.entries[:t.next]...)
+	}
+	out := make([]TraceEntry, 0, len(t.entries))
+	out = append(out, t.entries[t.next:]...)
+	return append(out, t.entries[:t.next]...)
+}
+
+// traceRemovalLocked records an eviction or expiration in the trace
+// Must be called with b.mutex held
+func (b *Bucket[T]) traceRemovalLocked(key string, reason RemovalReason) {
+	if b.evictionTrace == nil || (reason != ReasonEvicted && reason != ReasonExpired) {
+		return
+	}
+	b.evictionTrace.add(TraceEntry{Key: key, Reason: reason, Time: b.now(), Size: len(b.cache)})
+}
+
+// EvictionTrace returns the recorded evictions and expirations from oldest to
+// newest, or nil when WithEvictionTrace is not set
+func (b *Bucket[T]) EvictionTrace() []TraceEntry {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.evictionTrace == nil {
+		return nil
+	}
+	return b.evictionTrace.snapshot()
+}
+
+// WithEvictionTrace keeps the last capacity evictions and expirations in a
+// ring buffer for debugging, see EvictionTrace
+func WithEvictionTrace[T any](capacity int) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.evictionTrace = nil
+		if capacity > 0 {
+			b.evictionTrace = &evictionTrace{entries: make([]TraceEntry, capacity)}
+		}
+	}
+}
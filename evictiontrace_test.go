@@ -0,0 +1,53 @@
+package heatwave
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEvictionTrace(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithMaxSize[int](2), WithEvictionTrace[int](3))
+	defer b.Close()
+
+	for i := 0; i < 6; i++ {
+		clock.Advance(time.Second)
+		_ = b.Nail("k"+strconv.Itoa(i), i) // k0 to k3 are evicted
+	}
+	_, _ = b.Unnail("k4") // Deletions aren't traced
+
+	trace := b.EvictionTrace()
+	if len(trace) != 3 {
+		t.Fatalf("trace holds %d entries, want the capacity of 3", len(trace))
+	}
+	for i, e := range trace {
+		key := "k" + strconv.Itoa(i+1)
+		at := time.Unix(int64(i+4), 0)
+		if e.Key != key || e.Reason != ReasonEvicted || !e.Time.Equal(at) || e.Size != 1 {
+			t.Fatalf("trace[%d] = %+v, want %s evicted at %v with 1 item left", i, e, key, at)
+		}
+	}
+
+	_ = b.NailWithTTL("short", 9, time.Second)
+	clock.Advance(2 * time.Second)
+	b.CleanupNow()
+	trace = b.EvictionTrace()
+	if last := trace[len(trace)-1]; last.Key != "short" || last.Reason != ReasonExpired {
+		t.Fatalf("last trace entry = %+v, want the expiration of short", last)
+	}
+	if trace[0].Key != "k2" {
+		t.Fatalf("oldest trace entry = %+v, want k2 after wrapping around", trace[0])
+	}
+}
+
+func TestEvictionTraceDisabled(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](1))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	if trace := b.EvictionTrace(); trace != nil {
+		t.Fatalf("EvictionTrace without WithEvictionTrace = %v, want nil", trace)
+	}
+}
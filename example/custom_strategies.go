@@ -7,51 +7,21 @@ import (
 	"github.com/AeaZer/heatwave"
 )
 
-// RandomStrategy - also simpler now
+// RandomStrategy - embeds BaseUpdater and only overrides Evict
 type randomStrategy[T any] struct {
-	items []*heatwave.CacheItem[T]
+	heatwave.BaseUpdater[T]
 }
 
 func newRandomStrategy[T any]() *randomStrategy[T] {
-	return &randomStrategy[T]{
-		items: make([]*heatwave.CacheItem[T], 0),
-	}
-}
-
-func (r *randomStrategy[T]) Add(item *heatwave.CacheItem[T]) {
-	r.items = append(r.items, item)
-}
-
-func (r *randomStrategy[T]) Access(item *heatwave.CacheItem[T]) {
-	// Random strategy doesn't care about access patterns
-}
-
-func (r *randomStrategy[T]) Remove(item *heatwave.CacheItem[T]) {
-	for i, it := range r.items {
-		if it == item {
-			r.items = append(r.items[:i], r.items[i+1:]...)
-			break
-		}
-	}
+	return &randomStrategy[T]{}
 }
 
 func (r *randomStrategy[T]) Evict() *heatwave.CacheItem[T] {
-	if len(r.items) == 0 {
+	if r.Size() == 0 {
 		return nil
 	}
 	// Simple random selection (take last for simplicity)
-	idx := len(r.items) - 1
-	item := r.items[idx]
-	r.items = r.items[:idx]
-	return item
-}
-
-func (r *randomStrategy[T]) Size() int {
-	return len(r.items)
-}
-
-func (r *randomStrategy[T]) Clear() {
-	r.items = r.items[:0]
+	return r.RemoveAt(r.Size() - 1)
 }
 
 // FrequencyBasedStrategy - tracks access frequency
@@ -131,12 +101,12 @@ func demonstrateCustomStrategies() {
 
 	// Example 1: FIFO Strategy
 	fmt.Println("\n1. FIFO Strategy Demo:")
-	fifoCache := heatwave.NewBucket[string](
-		heatwave.WithBucketName[string]("custom-fifo"),
-		heatwave.WithMaxSize[string](3),
-		heatwave.WithFIFOUpdater[string](),
-		heatwave.WithBucketExpire[string](time.Second*10),
-	)
+	fifoCache := heatwave.NewBucketWithConfig[string](heatwave.Config{
+		Name:     "custom-fifo",
+		MaxSize:  3,
+		TTL:      10 * time.Second,
+		Strategy: heatwave.StrategyFIFO,
+	})
 	defer fifoCache.Close()
 
 	// Add items
@@ -160,12 +130,11 @@ func demonstrateCustomStrategies() {
 
 	// Example 2: Frequency-based Strategy
 	fmt.Println("\n2. Frequency-based Strategy Demo:")
-	freqCache := heatwave.NewBucket[int](
-		heatwave.WithBucketName[int]("frequency-cache"),
-		heatwave.WithMaxSize[int](3),
-		heatwave.WithUpdater[int](newFrequencyStrategy[int]()),
-		heatwave.WithBucketExpire[int](time.Second*10),
-	)
+	freqCache := heatwave.NewBucketWithConfig(heatwave.Config{
+		Name:    "frequency-cache",
+		MaxSize: 3,
+		TTL:     10 * time.Second,
+	}, heatwave.WithUpdater[int](newFrequencyStrategy[int]()))
 	defer freqCache.Close()
 
 	// Add items
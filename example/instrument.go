@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/AeaZer/heatwave"
+)
+
+// hitCounter is a minimal metrics sink
+type hitCounter struct {
+	hits, misses atomic.Int64
+}
+
+func demonstrateInstrument() {
+	fmt.Println("\n=== Instrumentation Demo ===")
+
+	bucket := heatwave.NewBucket[string]()
+	defer bucket.Close()
+
+	// Log every write and read with its latency
+	logged := heatwave.Instrument[string](bucket, heatwave.Hooks[string]{
+		AfterNail: func(key string, elapsed time.Duration, err error) {
+			slog.Info("cache nail", "key", key, "elapsed", elapsed, "err", err)
+		},
+		AfterBring: func(key string, elapsed time.Duration, found bool) {
+			slog.Info("cache bring", "key", key, "elapsed", elapsed, "found", found)
+		},
+	})
+
+	// Count hits and misses on top of the logging layer
+	var counter hitCounter
+	cache := heatwave.Instrument(logged, heatwave.Hooks[string]{
+		AfterBring: func(_ string, _ time.Duration, found bool) {
+			if found {
+				counter.hits.Add(1)
+			} else {
+				counter.misses.Add(1)
+			}
+		},
+	})
+
+	cache.Nail("greeting", "hello")
+	cache.Bring("greeting")
+	cache.Bring("missing")
+	fmt.Printf("hits=%d misses=%d\n", counter.hits.Load(), counter.misses.Load())
+
+	fmt.Println("\n=== Instrumentation Demo Complete ===")
+}
+
+func init() {
+	// Uncomment to run the demo
+	// demonstrateInstrument()
+}
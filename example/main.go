@@ -12,11 +12,11 @@ func main() {
 
 	// Example 1: Default lru Strategy with string values
 	fmt.Println("\n1. Default lru Strategy:")
-	lruCache := heatwave.NewBucket[string](
-		heatwave.WithBucketName[string]("lru-cache"),
-		heatwave.WithMaxSize[string](3),
-		heatwave.WithBucketExpire[string](time.Second*10),
-	)
+	lruCache := heatwave.NewBucketWithConfig[string](heatwave.Config{
+		Name:    "lru-cache",
+		MaxSize: 3,
+		TTL:     10 * time.Second,
+	})
 	defer lruCache.Close()
 
 	// Add items
@@ -44,11 +44,11 @@ func main() {
 
 	// Example 2: Working with different types - integers
 	fmt.Println("\n2. Integer Cache Example:")
-	intCache := heatwave.NewBucket[int](
-		heatwave.WithBucketName[int]("int-cache"),
-		heatwave.WithMaxSize[int](3),
-		heatwave.WithBucketExpire[int](time.Second*10),
-	)
+	intCache := heatwave.NewBucketWithConfig[int](heatwave.Config{
+		Name:    "int-cache",
+		MaxSize: 3,
+		TTL:     10 * time.Second,
+	})
 	defer intCache.Close()
 
 	// Add integer values
@@ -69,11 +69,11 @@ func main() {
 		Email string
 	}
 
-	userCache := heatwave.NewBucket[User](
-		heatwave.WithBucketName[User]("user-cache"),
-		heatwave.WithMaxSize[User](2),
-		heatwave.WithBucketExpire[User](time.Second*10),
-	)
+	userCache := heatwave.NewBucketWithConfig[User](heatwave.Config{
+		Name:    "user-cache",
+		MaxSize: 2,
+		TTL:     10 * time.Second,
+	})
 	defer userCache.Close()
 
 	// Add struct values
@@ -86,11 +86,11 @@ func main() {
 
 	// Example 4: Byte slice cache (similar to original)
 	fmt.Println("\n4. Byte Slice Cache Example:")
-	byteCache := heatwave.NewBucket[[]byte](
-		heatwave.WithBucketName[[]byte]("byte-cache"),
-		heatwave.WithMaxSize[[]byte](2),
-		heatwave.WithBucketExpire[[]byte](time.Second*10),
-	)
+	byteCache := heatwave.NewBucketWithConfig[[]byte](heatwave.Config{
+		Name:    "byte-cache",
+		MaxSize: 2,
+		TTL:     10 * time.Second,
+	})
 	defer byteCache.Close()
 
 	byteCache.Nail("data:1", []byte("Hello, World!"))
@@ -102,10 +102,10 @@ func main() {
 
 	// Example 5: Expiration Demo
 	fmt.Println("\n5. Expiration Demo:")
-	expCache := heatwave.NewBucket[string](
-		heatwave.WithBucketName[string]("exp-cache"),
-		heatwave.WithBucketExpire[string](time.Second*2), // 2 seconds TTL
-	)
+	expCache := heatwave.NewBucketWithConfig[string](heatwave.Config{
+		Name: "exp-cache",
+		TTL:  2 * time.Second,
+	})
 	defer expCache.Close()
 
 	expCache.Nail("temp", "temporary data")
@@ -122,10 +122,10 @@ func main() {
 
 	// Example 6: Interface{} cache for mixed types
 	fmt.Println("\n6. Mixed Types Cache Example:")
-	mixedCache := heatwave.NewBucket[interface{}](
-		heatwave.WithBucketName[interface{}]("mixed-cache"),
-		heatwave.WithMaxSize[interface{}](3),
-	)
+	mixedCache := heatwave.NewBucketWithConfig[interface{}](heatwave.Config{
+		Name:    "mixed-cache",
+		MaxSize: 3,
+	})
 	defer mixedCache.Close()
 
 	mixedCache.Nail("string", "Hello")
@@ -0,0 +1,91 @@
+package heatwave
+
+import "time"
+
+// maxInterceptsPerSweep bounds how many expired items one cleanup pass hands
+// to the expire interceptor, the rest wait for the next pass
+const maxInterceptsPerSweep = 256
+
+// ExpireInterceptor decides whether an expired item is kept
+// Returning keep=true reschedules the item to expire after newTTL instead of
+// removing it; a non-positive newTTL uses the bucket default.
+type ExpireInterceptor[T any] func(key string, value T) (newTTL time.Duration, keep bool)
+
+// expireCandidate is an expired item awaiting the interceptor's decision
+type expireCandidate[T any] struct {
+	item    *CacheItem[T]
+	key     string
+	value   T
+	version uint64 // Version seen when collected, a mismatch means it was rewritten
+}
+
+// candidateLocked captures item for the interceptor
+// Must be called with b.mutex held
+func (b *Bucket[T]) candidateLocked(item *CacheItem[T]) expireCandidate[T] {
+	return expireCandidate[T]{item: item, key: item.key, value: b.valueOf(item), version: item.version}
+}
+
+// interceptExpired runs the interceptor on candidates without holding the
+// lock, then applies the decisions and returns how many items were removed
+// Calls stop once half the cleanup interval has passed so that a slow
+// interceptor can't stall cleanup; undecided items are left for later.
+// Items deleted or rewritten meanwhile are left alone, so the interceptor
+// can't resurrect a key removed with Unnail.
+func (b *Bucket[T]) interceptExpired(candidates []expireCandidate[T]) int {
+	deadline := time.Now().Add(b.cleanupInterval / 2)
+	keep := make([]bool, 0, len(candidates))
+	ttls := make([]time.Duration, 0, len(candidates))
+	for i, c := range candidates {
+		if i > 0 && time.Now().After(deadline) {
+			break
+		}
+		var ttl time.Duration
+		var ok bool
+		b.guard(func() { ttl, ok = b.expireInterceptor(c.key, c.value) })
+		keep = append(keep, ok)
+		ttls = append(ttls, ttl)
+	}
+
+	b.lock()
+	defer b.unlock()
+
+	if b.writable() != nil {
+		b.rescheduleLocked(candidates)
+		return 0
+	}
+
+	now := b.now()
+	removed := 0
+	for i := range keep {
+		c := candidates[i]
+		if item, exists := b.cache[c.key]; !exists || item != c.item || item.version != c.version || !item.expired(now) {
+			continue
+		}
+		if keep[i] {
+			ttl := b.outdated
+			if ttls[i] > 0 {
+				ttl = &ttls[i]
+			}
+			c.item.expiredAt = b.capLifetime(c.item.createdAt, b.expiryFor(ttl))
+			b.scheduleLocked(c.item)
+			b.logExpiryLocked(c.item)
+			continue
+		}
+		b.removeLocked(c.item, ReasonExpired)
+		removed++
+	}
+	b.rescheduleLocked(candidates[len(keep):])
+	return removed
+}
+
+// WithExpireInterceptor gives expired items a last chance before removal
+// fn is called by the cleanup pass and by reads that find an expired item,
+// outside the bucket lock; returning keep=true extends the item by newTTL.
+// A read that finds an expired item waits for the decision, so it hits when
+// the item is kept. Panics count as keep=false and are recorded in
+// Stats.HookPanics.
+func WithExpireInterceptor[T any](fn ExpireInterceptor[T]) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.expireInterceptor = fn
+	}
+}
@@ -0,0 +1,49 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+// keepInterceptor keeps the keys in keep for a minute and lets the others
+// expire
+func keepInterceptor[T any](keep ...string) NewBucketOption[T] {
+	return WithExpireInterceptor(func(key string, value T) (time.Duration, bool) {
+		for _, k := range keep {
+			if k == key {
+				return time.Minute, true
+			}
+		}
+		return 0, false
+	})
+}
+
+func TestExpireInterceptorOnReads(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), keepInterceptor[int]("kept", "ref"))
+	defer b.Close()
+
+	_ = b.NailWithTTL("kept", 1, time.Second)
+	_ = b.NailWithTTL("ref", 2, time.Second)
+	_ = b.NailWithTTL("dropped", 3, time.Second)
+	clock.Advance(2 * time.Second)
+
+	if v, ok := b.Bring("kept"); !ok || v != 1 {
+		t.Fatalf("Bring(kept) = %d, %v, want 1, true", v, ok)
+	}
+	if p, ok := b.BringRef("ref"); !ok || *p != 2 {
+		t.Fatalf("BringRef(ref) = %v, want 2", ok)
+	}
+	if _, ok := b.BringRef("dropped"); ok {
+		t.Fatal("BringRef returned an item the interceptor let expire")
+	}
+	if exists(b, "dropped") {
+		t.Fatal("dropped is still held")
+	}
+
+	// The kept items got the interceptor's TTL
+	clock.Advance(59 * time.Second)
+	if !exists(b, "kept") || !exists(b, "ref") {
+		t.Fatal("kept items expired before the interceptor's TTL")
+	}
+}
@@ -0,0 +1,54 @@
+package heatwave
+
+// expiryFeed fans the keys of expired items out to ExpirationChannel
+// subscribers
+type expiryFeed struct {
+	channels []chan string
+	closed   bool
+}
+
+// ExpirationChannel returns a channel receiving the key of every item that
+// expires from now on, removed by cleanup or by a read that finds it expired
+// Sends never block: a key is dropped for a subscriber whose buffer is full.
+// Each call returns a new channel, all of which are closed by Close; on a
+// closed bucket the channel is returned closed. A negative buffer counts as
+// zero.
+func (b *Bucket[T]) ExpirationChannel(buffer int) <-chan string {
+	ch := make(chan string, max(buffer, 0))
+
+	b.expiryMutex.Lock()
+	defer b.expiryMutex.Unlock()
+
+	if b.expiryFeed.closed || b.isClosed() {
+		close(ch)
+		return ch
+	}
+	b.expiryFeed.channels = append(b.expiryFeed.channels, ch)
+	b.expiryWatched.Store(true)
+	return ch
+}
+
+// notifyExpired sends key to every expiration channel that has room
+func (b *Bucket[T]) notifyExpired(key string) {
+	b.expiryMutex.Lock()
+	defer b.expiryMutex.Unlock()
+
+	for _, ch := range b.expiryFeed.channels {
+		select {
+		case ch <- key:
+		default:
+		}
+	}
+}
+
+// closeExpirationChannels closes every expiration channel
+func (b *Bucket[T]) closeExpirationChannels() {
+	b.expiryMutex.Lock()
+	defer b.expiryMutex.Unlock()
+
+	for _, ch := range b.expiryFeed.channels {
+		close(ch)
+	}
+	b.expiryFeed = expiryFeed{closed: true}
+	b.expiryWatched.Store(false)
+}
@@ -0,0 +1,75 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpirationChannel(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	ch := b.ExpirationChannel(4)
+	_ = b.NailWithTTL("lazy", 1, time.Second)
+	_ = b.NailWithTTL("swept", 2, time.Second)
+	_ = b.NailWithTTL("live", 3, time.Hour)
+	clock.Advance(2 * time.Second)
+
+	// A read that finds the item expired delivers its key
+	if _, ok := b.Bring("lazy"); ok {
+		t.Fatal("Bring returned an expired item")
+	}
+	select {
+	case key := <-ch:
+		if key != "lazy" {
+			t.Fatalf("received %q, want lazy", key)
+		}
+	default:
+		t.Fatal("no key delivered for a lazily expired item")
+	}
+
+	// So does a cleanup pass
+	b.CleanupNow()
+	select {
+	case key := <-ch:
+		if key != "swept" {
+			t.Fatalf("received %q, want swept", key)
+		}
+	default:
+		t.Fatal("no key delivered for an item removed by cleanup")
+	}
+	// Deletes aren't expirations
+	_, _ = b.Unnail("live")
+	select {
+	case key := <-ch:
+		t.Fatalf("received %q for a deleted item", key)
+	default:
+	}
+}
+
+func TestExpirationChannelDropsWhenFull(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+
+	ch := b.ExpirationChannel(1)
+	_ = b.NailWithTTL("a", 1, time.Second)
+	_ = b.NailWithTTL("b", 2, time.Second)
+	clock.Advance(2 * time.Second)
+	if n := b.CleanupNow(); n != 2 {
+		t.Fatalf("CleanupNow = %d, want 2", n)
+	}
+	if len(ch) != 1 {
+		t.Fatalf("channel holds %d keys, want the buffer of 1", len(ch))
+	}
+
+	// Close closes the channel after what was buffered
+	_ = b.Close()
+	<-ch
+	if _, ok := <-ch; ok {
+		t.Fatal("channel still open after Close")
+	}
+	if _, ok := <-b.ExpirationChannel(1); ok {
+		t.Fatal("ExpirationChannel of a closed bucket isn't closed")
+	}
+}
@@ -0,0 +1,68 @@
+package heatwave
+
+import "slices"
+
+// ToMap returns a copy of the live items as a plain map
+// Access order is not changed and a closed bucket returns an empty map.
+func (b *Bucket[T]) ToMap() map[string]T {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return map[string]T{}
+	}
+
+	now := b.now()
+	out := make(map[string]T, len(b.cache))
+	for key, item := range b.cache {
+		if !item.expired(now) {
+			out[key] = b.readValue(item)
+		}
+	}
+	return out
+}
+
+// Filter returns the live items for which pred reports true as a plain map
+// pred sees each value as Bring would return it. It runs under the read
+// lock and must not call back into the bucket. Access order is not changed
+// and a closed bucket returns an empty map.
+func (b *Bucket[T]) Filter(pred func(key string, value T) bool) map[string]T {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	out := map[string]T{}
+	if b.isClosed() {
+		return out
+	}
+
+	now := b.now()
+	for key, item := range b.cache {
+		if item.expired(now) {
+			continue
+		}
+		if value := b.readValue(item); pred(key, value) {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// NewBucketFromMap creates a bucket seeded with the entries of m
+// Keys are inserted in sorted order so the resulting eviction order is
+// reproducible. When m holds more than maxSize entries the bucket evicts as
+// usual while seeding. Entries rejected by the configured limits are skipped
+// and logged. A nil m gives an empty bucket.
+func NewBucketFromMap[T any](m map[string]T, opts ...NewBucketOption[T]) *Bucket[T] {
+	b := NewBucket(opts...)
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if err := b.Nail(key, m[key]); err != nil {
+			b.log(LogWarn, "skipping seed entry", "key", key, "err", err)
+		}
+	}
+	return b
+}
@@ -0,0 +1,87 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToMap(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	if m := b.ToMap(); m == nil || len(m) != 0 {
+		t.Fatalf("ToMap of an empty bucket = %#v, want an empty non-nil map", m)
+	}
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_ = b.NailWithTTL("gone", 3, time.Second)
+	clock.Advance(2 * time.Second)
+
+	m := b.ToMap()
+	if len(m) != 2 || m["a"] != 1 || m["b"] != 2 {
+		t.Fatalf("ToMap = %v, want map[a:1 b:2]", m)
+	}
+	// The map is a copy
+	m["a"] = 10
+	if v, _ := b.Bring("a"); v != 1 {
+		t.Fatalf("Bring(a) = %d after changing the map, want 1", v)
+	}
+
+	_ = b.Close()
+	if m := b.ToMap(); m == nil || len(m) != 0 {
+		t.Fatalf("ToMap of a closed bucket = %#v, want an empty non-nil map", m)
+	}
+}
+
+func TestNewBucketFromMap(t *testing.T) {
+	b := NewBucketFromMap(map[string]int{"d": 4, "b": 2, "a": 1, "c": 3}, WithMaxSize[int](3), WithFIFOUpdater[int]())
+	defer b.Close()
+
+	// Keys are seeded in sorted order, so a is the one evicted
+	if b.Size() != 3 || exists(b, "a") {
+		t.Fatalf("seeded %v, want b, c and d", b.ToMap())
+	}
+	if key, _, _ := b.Oldest(); key != "b" {
+		t.Fatalf("Oldest = %q, want b", key)
+	}
+
+	empty := NewBucketFromMap[int](nil)
+	defer empty.Close()
+	if empty.Size() != 0 {
+		t.Fatalf("Size = %d for a nil map, want 0", empty.Size())
+	}
+	_ = empty.Nail("x", 1)
+	if !exists(empty, "x") {
+		t.Fatal("bucket built from a nil map isn't usable")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		_ = b.Nail(key, i)
+	}
+	_ = b.NailWithTTL("gone", 10, time.Second)
+	clock.Advance(2 * time.Second)
+
+	m := b.Filter(func(key string, value int) bool { return value%2 == 0 })
+	if _, ok := m["a"]; len(m) != 2 || !ok || m["c"] != 2 {
+		t.Fatalf("Filter = %v, want map[a:0 c:2]", m)
+	}
+
+	// The bucket and its access order are left as they were
+	if b.Size() != 5 {
+		t.Fatalf("Size = %d after Filter, want 5", b.Size())
+	}
+	if key, _, _ := b.Oldest(); key != "a" {
+		t.Fatalf("Oldest = %q after Filter, want a", key)
+	}
+	delete(m, "a")
+	if !exists(b, "a") {
+		t.Fatal("changing the result changed the bucket")
+	}
+}
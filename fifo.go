@@ -1,20 +1,44 @@
 package heatwave
 
-// fifo implements FIFO (First-In-First-Out) algorithm
+// fifoNode represents a node in the FIFO doubly linked list
+type fifoNode[T any] struct {
+	item *CacheItem[T]
+	prev *fifoNode[T]
+	next *fifoNode[T]
+}
+
+// fifo implements FIFO (First-In-First-Out) algorithm using doubly linked list
+// New items are added at head, the oldest item sits at tail.
 type fifo[T any] struct {
-	items []*CacheItem[T]
+	head    *fifoNode[T]
+	tail    *fifoNode[T]
+	size    int
+	nodeMap map[*CacheItem[T]]*fifoNode[T] // Map from CacheItem to fifoNode for O(1) removal
 }
 
 // newFIFO creates a new FIFO updater
 func newFIFO[T any]() *fifo[T] {
+	head := &fifoNode[T]{}
+	tail := &fifoNode[T]{}
+	head.next = tail
+	tail.prev = head
 	return &fifo[T]{
-		items: make([]*CacheItem[T], 0),
+		head:    head,
+		tail:    tail,
+		size:    0,
+		nodeMap: make(map[*CacheItem[T]]*fifoNode[T]),
 	}
 }
 
 // Add adds a new item to the FIFO updater
 func (f *fifo[T]) Add(item *CacheItem[T]) {
-	f.items = append(f.items, item)
+	node := &fifoNode[T]{item: item}
+	f.nodeMap[item] = node
+	node.prev = f.head
+	node.next = f.head.next
+	f.head.next.prev = node
+	f.head.next = node
+	f.size++
 }
 
 // Access does nothing in FIFO strategy (no reordering on access)
@@ -24,30 +48,67 @@ func (f *fifo[T]) Access(item *CacheItem[T]) {
 
 // Remove removes an item from the FIFO updater
 func (f *fifo[T]) Remove(item *CacheItem[T]) {
-	for i, it := range f.items {
-		if it == item {
-			f.items = append(f.items[:i], f.items[i+1:]...)
-			break
-		}
+	if node, exists := f.nodeMap[item]; exists {
+		f.removeNode(node)
+		delete(f.nodeMap, item)
 	}
 }
 
 // Evict returns the first item (oldest) for eviction
 func (f *fifo[T]) Evict() *CacheItem[T] {
-	if len(f.items) == 0 {
+	if f.size == 0 {
 		return nil
 	}
-	item := f.items[0]
-	f.items = f.items[1:]
-	return item
+	node := f.tail.prev
+	f.removeNode(node)
+	delete(f.nodeMap, node.item)
+	return node.item
+}
+
+// Peek returns the oldest item without removing it
+func (f *fifo[T]) Peek() *CacheItem[T] {
+	if f.size == 0 {
+		return nil
+	}
+	return f.tail.prev.item
+}
+
+// Ascend walks items from oldest to newest insertion
+func (f *fifo[T]) Ascend(fn func(item *CacheItem[T]) bool) {
+	for node := f.tail.prev; node != f.head; node = node.prev {
+		if !fn(node.item) {
+			return
+		}
+	}
+}
+
+// Descend walks items from newest to oldest insertion
+func (f *fifo[T]) Descend(fn func(item *CacheItem[T]) bool) {
+	for node := f.head.next; node != f.tail; node = node.next {
+		if !fn(node.item) {
+			return
+		}
+	}
 }
 
 // Size returns the current size
 func (f *fifo[T]) Size() int {
-	return len(f.items)
+	return f.size
 }
 
 // Clear removes all items from the updater
 func (f *fifo[T]) Clear() {
-	f.items = f.items[:0]
+	f.head.next = f.tail
+	f.tail.prev = f.head
+	f.size = 0
+	f.nodeMap = make(map[*CacheItem[T]]*fifoNode[T])
+}
+
+// removeNode removes a node from the list
+func (f *fifo[T]) removeNode(node *fifoNode[T]) {
+	node.prev.next = node.next
+	node.next.prev = node.prev
+	node.prev = nil
+	node.next = nil
+	f.size--
 }
@@ -0,0 +1,40 @@
+package heatwave
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Roles of the background goroutines, reported in the pprof label
+// heatwave.role next to heatwave.bucket
+const (
+	roleCleanup     = "cleanup"
+	roleTrim        = "trim"
+	roleCoarseClock = "coarse-clock"
+	roleAsyncWriter = "async-writer"
+	roleLogSync     = "log-sync"
+	roleReclaim     = "reclaim"
+	roleReadRepair  = "read-repair"
+	roleLoad        = "load"
+)
+
+// spawn runs fn on a new goroutine labelled with the bucket name and role,
+// counted by GoroutineCount until fn returns
+func (b *Bucket[T]) spawn(role string, fn func()) {
+	b.goroutines.Add(1)
+	labels := pprof.Labels("heatwave.bucket", b.name, "heatwave.role", role)
+	go pprof.Do(context.Background(), labels, func(context.Context) {
+		defer b.goroutines.Add(-1)
+		fn()
+	})
+}
+
+// GoroutineCount returns how many background goroutines the bucket owns
+// right now
+// It counts the cleanup, trim, coarse clock, async writer and log sync
+// loops as well as short-lived reclaims, read repairs and detached loads.
+// The loops exit shortly after Close, and the rest once their callback,
+// repair or load returns, so tests can use it to detect leaks.
+func (b *Bucket[T]) GoroutineCount() int {
+	return int(b.goroutines.Load())
+}
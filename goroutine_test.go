@@ -0,0 +1,42 @@
+package heatwave
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoroutineCount(t *testing.T) {
+	idle := NewBucket[int](WithCleanupDisabled[int]())
+	defer idle.Close()
+	if n := idle.GoroutineCount(); n != 0 {
+		t.Fatalf("GoroutineCount = %d without background work, want 0", n)
+	}
+
+	b := NewBucket[string](
+		WithBucketName[string]("orders"),
+		WithMaxBytes[string](1<<20, func(s string) int64 { return int64(len(s)) }),
+		WithBackgroundTrim[string](time.Hour),
+		WithCoarseClock[string](time.Hour),
+	)
+	if n := b.GoroutineCount(); n != 3 {
+		t.Fatalf("GoroutineCount = %d with cleanup, trim and coarse clock, want 3", n)
+	}
+
+	// Goroutines carry their labels once they start running
+	for _, role := range []string{roleCleanup, roleTrim, roleCoarseClock} {
+		label := `"heatwave.bucket":"orders", "heatwave.role":"` + role + `"`
+		waitFor(t, role+" goroutine labelled for the bucket", func() bool {
+			var profile bytes.Buffer
+			_ = pprof.Lookup("goroutine").WriteTo(&profile, 1)
+			return strings.Contains(profile.String(), label)
+		})
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "background goroutines to exit", func() bool { return b.GoroutineCount() == 0 })
+}
@@ -0,0 +1,194 @@
+package heatwave
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// GroupMember is a bucket that can join a BudgetGroup, *Bucket[T] of any T
+type GroupMember interface {
+	Size() int
+	joinGroup(g *BudgetGroup)
+	leaveGroup(g *BudgetGroup)
+	evictForGroup() bool
+}
+
+// BudgetGroup caps the combined number of items of several buckets
+// After an insert pushes the combined size over the limit, the group evicts
+// from the member with the lowest priority, the largest one among equals,
+// until it is back under the limit. Members keep their own limits as well.
+// Eviction runs on the writing goroutine after the bucket lock is released,
+// so the limit may be exceeded briefly while writes race. Only one goroutine
+// evicts at a time; inserts made meanwhile, including from eviction
+// callbacks, are handled by it before it stops.
+type BudgetGroup struct {
+	mutex     sync.Mutex
+	limit     int
+	members   []groupEntry
+	enforcing atomic.Bool // Set while a goroutine evicts for the group
+	pending   atomic.Bool // Set when the group must be checked again
+}
+
+// groupEntry is a member with its priority
+type groupEntry struct {
+	member   GroupMember
+	priority int
+}
+
+// NewBudgetGroup creates a group whose members hold at most limit items
+// together
+func NewBudgetGroup(limit int) *BudgetGroup {
+	return &BudgetGroup{limit: limit}
+}
+
+// Add registers b with priority zero
+func (g *BudgetGroup) Add(b GroupMember) {
+	g.AddWithPriority(b, 0)
+}
+
+// AddWithPriority registers b, members with a lower priority are evicted
+// from first
+// A bucket belongs to at most one group, adding it to another moves it.
+func (g *BudgetGroup) AddWithPriority(b GroupMember, priority int) {
+	g.mutex.Lock()
+	if i := g.index(b); i >= 0 {
+		g.members[i].priority = priority
+	} else {
+		g.members = append(g.members, groupEntry{member: b, priority: priority})
+	}
+	g.mutex.Unlock()
+
+	b.joinGroup(g)
+	g.enforce()
+}
+
+// Remove unregisters b and reports whether it was a member
+// Closed buckets leave their group on their own.
+func (g *BudgetGroup) Remove(b GroupMember) bool {
+	g.mutex.Lock()
+	i := g.index(b)
+	if i >= 0 {
+		g.members = slices.Delete(g.members, i, i+1)
+	}
+	g.mutex.Unlock()
+
+	if i < 0 {
+		return false
+	}
+	b.leaveGroup(g)
+	return true
+}
+
+// index returns the position of b in the members, -1 if absent
+// Must be called with g.mutex held
+func (g *BudgetGroup) index(b GroupMember) int {
+	return slices.IndexFunc(g.members, func(e groupEntry) bool { return e.member == b })
+}
+
+// Size returns the combined number of items of the members
+func (g *BudgetGroup) Size() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	total := 0
+	for _, e := range g.members {
+		total += e.member.Size()
+	}
+	return total
+}
+
+// enforce evicts from members until the group is within its limit
+// A call made while another goroutine, or the eviction callbacks of this
+// one, is already enforcing leaves the work to that enforcer.
+func (g *BudgetGroup) enforce() {
+	g.pending.Store(true)
+	for g.enforcing.CompareAndSwap(false, true) {
+		for g.pending.Swap(false) {
+			g.enforceOnce()
+		}
+		g.enforcing.Store(false)
+		if !g.pending.Load() {
+			return
+		}
+	}
+}
+
+// enforceOnce evicts until the members, as registered when it starts, are
+// within the limit
+// The member list is copied so that no group lock is held while members
+// evict and run their callbacks.
+func (g *BudgetGroup) enforceOnce() {
+	g.mutex.Lock()
+	members := slices.Clone(g.members)
+	limit := g.limit
+	g.mutex.Unlock()
+
+	sizes := make([]int, len(members))
+	total := 0
+	for i, e := range members {
+		sizes[i] = e.member.Size()
+		total += sizes[i]
+	}
+
+	for total > limit {
+		victim := -1
+		for i, e := range members {
+			if sizes[i] == 0 {
+				continue
+			}
+			if victim < 0 || e.priority < members[victim].priority ||
+				(e.priority == members[victim].priority && sizes[i] > sizes[victim]) {
+				victim = i
+			}
+		}
+		if victim < 0 {
+			return
+		}
+		if !members[victim].member.evictForGroup() {
+			// Frozen, closed or unable to evict, leave it alone this round
+			sizes[victim] = 0
+			continue
+		}
+		sizes[victim]--
+		total--
+	}
+}
+
+// joinGroup makes b report inserts to g, leaving its previous group
+func (b *Bucket[T]) joinGroup(g *BudgetGroup) {
+	b.lock()
+	old := b.group
+	b.group = g
+	b.mutex.Unlock()
+
+	if old != nil && old != g {
+		old.Remove(b)
+	}
+}
+
+// leaveGroup stops b from reporting inserts to g, unless it already moved on
+// to another group
+func (b *Bucket[T]) leaveGroup(g *BudgetGroup) {
+	b.lock()
+	if b.group == g {
+		b.group = nil
+	}
+	b.mutex.Unlock()
+}
+
+// evictForGroup evicts one item on behalf of the group
+func (b *Bucket[T]) evictForGroup() bool {
+	b.lock()
+	defer b.unlock()
+
+	if b.writable() != nil {
+		return false
+	}
+	item := b.updater.Evict()
+	if item == nil {
+		return false
+	}
+	b.forgetLocked(item, ReasonEvicted)
+	return true
+}
@@ -0,0 +1,93 @@
+package heatwave
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBudgetGroupEvictsLowestPriority(t *testing.T) {
+	g := NewBudgetGroup(5)
+	low := NewBucket[int]()
+	defer low.Close()
+	high := NewBucket[int]()
+	defer high.Close()
+	g.AddWithPriority(low, 0)
+	g.AddWithPriority(high, 1)
+
+	for i := 0; i < 3; i++ {
+		_ = high.Nail(strconv.Itoa(i), i)
+	}
+	_ = low.Nail("a", 1)
+	_ = low.Nail("b", 2)
+	_ = high.Nail("3", 3)
+
+	if g.Size() != 5 {
+		t.Fatalf("group Size = %d, want 5", g.Size())
+	}
+	// The larger high priority bucket is spared, low loses its oldest item
+	if high.Size() != 4 || low.Size() != 1 || exists(low, "a") {
+		t.Fatalf("high holds %d and low %d items, want 4 and 1 with a evicted", high.Size(), low.Size())
+	}
+}
+
+func TestBudgetGroupEvictsLargestAmongEquals(t *testing.T) {
+	g := NewBudgetGroup(4)
+	small := NewBucket[int]()
+	defer small.Close()
+	large := NewBucket[int]()
+	defer large.Close()
+	g.Add(small)
+	g.Add(large)
+
+	_ = small.Nail("a", 1)
+	for i := 0; i < 4; i++ {
+		_ = large.Nail(strconv.Itoa(i), i)
+	}
+	if small.Size() != 1 || large.Size() != 3 {
+		t.Fatalf("small holds %d and large %d items, want 1 and 3", small.Size(), large.Size())
+	}
+
+	if !g.Remove(small) || g.Remove(small) {
+		t.Fatal("Remove didn't report membership")
+	}
+	_ = small.Nail("b", 2)
+	if small.Size() != 2 {
+		t.Fatalf("small holds %d items after leaving the group, want 2", small.Size())
+	}
+}
+
+func TestBudgetGroupCallbackWritesToMember(t *testing.T) {
+	g := NewBudgetGroup(4)
+	archive := NewBucket[int]()
+	defer archive.Close()
+	// Evicted items move to the archive, which triggers the group again from
+	// inside an eviction callback
+	hot := NewBucket[int](WithOnEvict(func(key string, value int, reason RemovalReason) {
+		if reason == ReasonEvicted {
+			_ = archive.Nail(key, value)
+		}
+	}))
+	defer hot.Close()
+	g.AddWithPriority(archive, 0)
+	g.AddWithPriority(hot, 1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			_ = hot.Nail(strconv.Itoa(i), i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("group eviction deadlocked on a callback writing to a member")
+	}
+	if g.Size() > 4 {
+		t.Fatalf("group Size = %d, want at most 4", g.Size())
+	}
+	if hot.Size() != 4 {
+		t.Fatalf("hot holds %d items, want 4", hot.Size())
+	}
+}
@@ -2,7 +2,9 @@ package heatwave
 
 import (
 	"errors"
+	"math/rand/v2"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,7 +15,29 @@ const (
 )
 
 var (
-	ErrBucketClosed = errors.New("bucket is closed")
+	ErrBucketClosed      = errors.New("bucket is closed")
+	ErrKeyTooLong        = errors.New("key exceeds maximum length")
+	ErrNoLoader          = errors.New("bucket has no loader")
+	ErrCacheFull         = errors.New("bucket is full")
+	ErrValueTooLarge     = errors.New("value exceeds maximum size")
+	ErrVersionMismatch   = errors.New("version mismatch")
+	ErrBroadcasterClosed = errors.New("broadcaster is closed")
+	ErrNotFound          = errors.New("key not found")
+	ErrUnordered         = errors.New("updater has no defined order")
+	ErrNoAppendLog       = errors.New("bucket has no append log")
+	ErrCircuitOpen       = errors.New("loader circuit breaker is open")
+	ErrInvalidCursor     = errors.New("invalid or expired cursor")
+	ErrNilUpdater        = errors.New("updater is nil")
+	ErrBucketFrozen      = errors.New("bucket is frozen")
+	ErrBadSnapshot       = errors.New("invalid snapshot")
+	ErrSnapshotEncrypted = errors.New("snapshot is encrypted")
+	ErrSnapshotDecrypt   = errors.New("snapshot decryption failed")
+	ErrInvariant         = errors.New("bucket invariant violated")
+	ErrDeadlinePassed    = errors.New("deadline is not in the future")
+	ErrUpdaterShared     = errors.New("updater belongs to another bucket")
+	ErrShardShared       = errors.New("state shared between shards")
+	ErrCorruptLog        = errors.New("append log is corrupt")
+	ErrInvalidConfig     = errors.New("invalid bucket config")
 )
 
 // CacheItem represents an item in the cache with generic value type
@@ -22,183 +46,799 @@ type CacheItem[T any] struct {
 	key       string
 	value     T
 	expiredAt *time.Time // nil means never expire
+	createdAt time.Time  // When the key was inserted, kept across updates
+	updatedAt time.Time  // When the value was last written
+
+	sourceTime time.Time         // External version recorded by NailIfNewer, zero if unset
+	version    uint64            // Starts at 1 on insert and increments on every update
+	seq        uint64            // Insertion sequence number, orders KeysPage
+	priority   int               // Eviction priority set by NailWithPriority, lower goes first
+	size       int64             // Value size measured by the bucket's sizer, zero without one
+	heapIndex  int               // Position in the expiry heap plus one, zero when not scheduled
+	spill      *spillFile        // On-disk location of a spilled value, nil when held in memory
+	meta       map[string]string // Metadata set by NailWithMeta, nil if unset
+}
+
+// expired reports whether the item has expired at now
+func (i *CacheItem[T]) expired(now time.Time) bool {
+	return i.expiredAt != nil && now.After(*i.expiredAt)
 }
 
 type NewBucketOption[T any] func(b *Bucket[T])
 
 type Bucket[T any] struct {
-	name     string         // Name of the bucket
-	maxSize  int            // Maximum number of items in cache
-	outdated *time.Duration // TTL for cache items
+	id             uint64         // Identity used to bind the updater
+	name           string         // Name of the bucket
+	maxSize        int            // Maximum number of items in cache
+	softMaxSize    int            // Size above which inserts evict extra items, zero disables
+	maxKeyLen      int            // Maximum key length in bytes, zero means unlimited
+	maxValueBytes  int64          // Maximum value size in bytes, zero means unlimited
+	valueSizer     func(T) int64  // Measures value sizes in bytes
+	maxBytes       int64          // Budget for the total value size, zero means unlimited
+	totalBytes     int64          // Total value size of the held items
+	trimInterval   time.Duration  // Interval of the background byte trimming, zero disables
+	spillDir       string         // Directory of spilled values, empty disables spilling
+	spillThreshold int64          // Size above which values are spilled to disk
+	spilled        spillStats     // Count and on-disk size of spilled values
+	stagedSpill    *spillFile     // File written for the value being stored, see lockStaged
+	outdated       *time.Duration // TTL for cache items
+
+	updateKeepsExpiry bool              // Whether updates keep the existing deadline
+	valueEqual        func(a, b T) bool // Detects writes of an unchanged value, nil disables
+	equalResetsTTL    bool              // Whether a write of an unchanged value restarts the TTL
+	maxLifetime       time.Duration     // Maximum time since insertion an item may live, zero disables
 
 	cleanupInterval time.Duration            // Interval for background cleanup
 	cache           map[string]*CacheItem[T] // Hash map for O(1) access
+	initialCapacity int                      // Size hint for the map, also after Clear
+	peakSize        int                      // Most items held since the last Clear or Compact
 	updater         Updater[T]               // Update strategy interface
+	newUpdater      func() Updater[T]        // Builds another updater of the built-in strategy, nil means LRU
 	mutex           sync.RWMutex             // Read-write mutex for thread safety
 	stopCleanup     chan struct{}            // Channel to stop cleanup goroutine
-	closed          bool                     // Flag to track if bucket is closed
-	closeMutex      sync.Mutex               // Mutex to protect close operation
+	cleanupDisabled bool                     // Whether the cleanup goroutine is never started
+	cleanupRunning  atomic.Bool              // Whether the cleanup goroutine is alive
+	goroutines      atomic.Int64             // Background goroutines currently running
+	cleanupPaused   bool                     // Whether cleanup ticks are skipped
+	cleanupJitter   bool                     // Whether the first sweep is delayed by a random fraction
+	clock           Clock                    // Source of the current time, nil means time.Now
+	rng             *rand.Rand               // Seeded source for random eviction, nil uses the global one
+	coarse          *coarseClock             // Cached time for hot path expiry checks, nil when off
+	group           *BudgetGroup             // Group sharing an item budget with this bucket, nil if none
+	groupGrew       bool                     // Whether an insert may have pushed the group over its limit
+	expiries        *expiryHeap[T]           // Items with a deadline, earliest first
+	expiryTiers     []ExpiryTier             // Cleanup cadence per TTL, nil uses the defaults
+	cleanupWake     chan struct{}            // Wakes the cleanup goroutine for an earlier deadline
+	wakeAt          time.Time                // When the cleanup goroutine plans to wake up
+	pauseMutex      sync.Mutex               // Mutex protecting cleanupPaused
+	closed          atomic.Bool              // Flag to track if bucket is closed, set under mutex
+	frozen          atomic.Bool              // Whether writes are rejected with ErrBucketFrozen
+	closeMutex      sync.Mutex               // Serializes Close calls
+	reclaims        sync.WaitGroup           // Background reclaimers of cleared items
+	counters        counters                 // Hit, miss, eviction and expiration counters
+	latencyMetrics  bool                     // Whether Nail and Bring are timed
+	latency         *latencyHistograms       // Latency histograms, nil when tracking is off
+	contention      *contention              // Lock wait sampling, nil when profiling is off
+	overflowPolicy  OverflowPolicy           // What inserts do when the bucket is full
+	strictCapacity  bool                     // Fail Nail instead of overflowing when nothing can be evicted
+	keys            keyIndex                 // Keys in insertion order for KeysPage
+	debug           bool                     // DebugMode as it was when the bucket was created
+
+	traceHook         TraceHook            // Tracing hook, nil when disabled
+	onEvict           EvictCallback[T]     // Callback for removed items, nil when disabled
+	onExpire          ExpireCallback[T]    // Callback for expired items, nil when disabled
+	expireInterceptor ExpireInterceptor[T] // Last chance for expired items, nil when disabled
+	intercepting      []*CacheItem[T]      // Expired items taken off the heap for the interceptor
+	pending           []removal[T]         // Removals awaiting dispatch after unlock
+	logger            LogFunc              // Structured logger, nil when disabled
+	evictionTrace     *evictionTrace       // Recent evictions and expirations, nil when disabled
+	closeEvicted      bool                 // Whether removed io.Closer values are closed
+	expiryFeed        expiryFeed           // Subscribers of ExpirationChannel
+	expiryWatched     atomic.Bool          // Whether ExpirationChannel has subscribers
+	expiryMutex       sync.Mutex           // Mutex protecting expiryFeed
+
+	broadcaster   Broadcaster // Invalidation broadcaster, nil when disabled
+	origin        string      // ID identifying this bucket's own events
+	invalidations []string    // Keys awaiting publication after unlock
+
+	copyIn  func(T) T // Copier applied to values on the way in, nil when disabled
+	copyOut func(T) T // Copier applied to values on the way out, nil when disabled
+
+	codec     Codec[T]   // Codec used for persistence, nil means JSONCodec
+	aof       *appendLog // Append log, nil when disabled
+	aofPath   string     // Path of the append log, empty disables it
+	aofPolicy SyncPolicy // Sync policy of the append log
+
+	sealKeys          [][]byte // Snapshot encryption key followed by older keys, nil disables encryption
+	snapshotChunkSize int      // Target size of snapshot chunks, zero uses the default
+
+	loader      Loader[T]               // Loader used by Load
+	source      Source[T]               // Read-through source, used when loader is nil
+	autoFill    bool                    // Whether Bring fills misses through the loader
+	errorTTL    time.Duration           // How long loader failures are cached, zero disables
+	breaker     *circuitBreaker         // Loader circuit breaker, nil when disabled
+	retry       *RetryPolicy            // Loader retries, nil when disabled
+	inflight    map[string]*loadCall[T] // In-flight loads keyed by id
+	loadErrors  map[string]*errorEntry  // Cached loader failures keyed by id
+	flightMutex sync.Mutex              // Mutex protecting inflight and loadErrors
+	readRepair  ReadRepair[T]           // Checks Bring hits in the background, nil disables
+	repairing   map[string]struct{}     // Keys with a read repair running
+	repairMutex sync.Mutex              // Mutex protecting repairing
+
+	asyncQueue     chan asyncWrite[T] // Queue of NailAsync writes, created on first use
+	asyncQueueSize int                // Buffer size of asyncQueue
+	asyncPolicy    AsyncFullPolicy    // Behavior when asyncQueue is full
+	asyncDone      chan struct{}      // Closed when the async writer exits
+	asyncClosed    bool               // Whether asyncQueue stopped accepting writes
+	asyncOnce      sync.Once          // Starts the async writer once
+	asyncMutex     sync.RWMutex       // Mutex protecting the async queue state
 }
 
+// NewBucket creates a bucket configured by opts
+// It panics where NewBucketE returns an error.
 func NewBucket[T any](opts ...NewBucketOption[T]) *Bucket[T] {
+	b, err := NewBucketE(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// NewBucketE creates a bucket configured by opts, failing with
+// ErrUpdaterShared when the updater implements BindableUpdater and belongs
+// to another bucket
+// Nothing is started when it fails.
+func NewBucketE[T any](opts ...NewBucketOption[T]) (*Bucket[T], error) {
+	b := configureBucket(opts)
+	if err := b.start(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// configureBucket returns a bucket with opts applied that hasn't been
+// started yet
+func configureBucket[T any](opts []NewBucketOption[T]) *Bucket[T] {
 	od := defaultOutdated
 	b := &Bucket[T]{
+		id:              bucketIDs.Add(1),
 		maxSize:         defaultMaxSize,
 		outdated:        &od,
 		cache:           make(map[string]*CacheItem[T]),
 		updater:         newLRUUpdater[T](),
 		cleanupInterval: defaultCleanupInterval,
+		cleanupJitter:   true,
 		stopCleanup:     make(chan struct{}, 1), // Buffered channel to prevent blocking
-		closed:          false,
+		expiries:        &expiryHeap[T]{},
+		cleanupWake:     make(chan struct{}, 1),
+		inflight:        make(map[string]*loadCall[T]),
+		loadErrors:      make(map[string]*errorEntry),
+		asyncQueueSize:  defaultAsyncQueueSize,
+		debug:           DebugMode,
 	}
 
 	for _, opt := range opts {
 		opt(b)
 	}
+	return b
+}
+
+// start binds the updater and starts everything the options asked for
+// Nothing is started when it fails.
+func (b *Bucket[T]) start() error {
+	if err := b.bindUpdater(b.updater); err != nil {
+		return err
+	}
+
+	if b.broadcaster != nil {
+		b.origin = newOrigin()
+		b.broadcaster.Subscribe(b.receive)
+	}
+
+	if b.initialCapacity > 0 {
+		b.cache = b.newCacheMap()
+	}
+	b.seedUpdater(b.updater)
+	b.clockUpdater(b.updater)
+
+	if b.spillDir != "" {
+		b.openSpill()
+	}
+
+	if b.coarse != nil {
+		b.startCoarseClock()
+	}
+
+	if b.aofPath != "" {
+		b.openLog()
+	}
 
 	// Start background cleanup goroutine
-	go b.startCleanup()
+	if !b.cleanupDisabled {
+		b.cleanupRunning.Store(true)
+		b.spawn(roleCleanup, b.cleanupLoop)
+	}
+	if b.trimInterval > 0 && b.maxBytes > 0 {
+		b.spawn(roleTrim, b.trimLoop)
+	}
+	return nil
+}
 
-	return b
+// NailOption adjusts a single write
+type NailOption func(o *nailOptions)
+
+// nailOptions holds the per-call settings of a write
+type nailOptions struct {
+	keepExpiry bool
+}
+
+// KeepExpiry makes an update of an existing key keep its current deadline
+// instead of restarting the TTL. New keys still get the regular TTL.
+func KeepExpiry() NailOption {
+	return func(o *nailOptions) {
+		o.keepExpiry = true
+	}
 }
 
 // Nail stores data in memory (like nailing it to memory)
-func (b *Bucket[T]) Nail(id string, data T) error {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
+func (b *Bucket[T]) Nail(id string, data T, opts ...NailOption) error {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	b.lockStaged(id, data)
+	defer b.unlock()
 
 	// Check if bucket is closed
-	if b.isClosed() {
-		return ErrBucketClosed
+	if err := b.writable(); err != nil {
+		return err
 	}
 
-	var expiredAt *time.Time
-	if b.outdated != nil {
-		t := time.Now().Add(*b.outdated)
-		expiredAt = &t
+	data, err := b.admit(id, data)
+	if err != nil {
+		return err
 	}
-	// If b.outdated is nil, expiredAt remains nil (never expire)
 
+	_, err = b.setLocked(id, data, b.writeExpiryLocked(id, b.outdated, opts))
+	return err
+}
+
+// NailWithTTL stores data with a TTL that overrides the bucket default
+// A non-positive ttl falls back to the bucket default.
+func (b *Bucket[T]) NailWithTTL(id string, data T, ttl time.Duration, opts ...NailOption) error {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	b.lockStaged(id, data)
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return err
+	}
+
+	data, err := b.admit(id, data)
+	if err != nil {
+		return err
+	}
+
+	expire := b.outdated
+	if ttl > 0 {
+		expire = &ttl
+	}
+	_, err = b.setLocked(id, data, b.writeExpiryLocked(id, expire, opts))
+	return err
+}
+
+// NailUntil stores data until the absolute deadline instead of for a TTL,
+// e.g. to invalidate it at midnight regardless of when it was written
+// A deadline that isn't after the bucket clock's current time is rejected
+// with ErrDeadlinePassed. WithMaxLifetime still caps the deadline.
+func (b *Bucket[T]) NailUntil(id string, data T, deadline time.Time) error {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	b.lockStaged(id, data)
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return err
+	}
+	if !deadline.After(b.now()) {
+		return ErrDeadlinePassed
+	}
+
+	data, err := b.admit(id, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.setLocked(id, data, &deadline)
+	return err
+}
+
+// NailReportingSize is Nail that also returns the number of items right
+// before and after the write, observed under the same lock
+// An update leaves the size unchanged, an insert into a full bucket may
+// evict items and leave it unchanged or smaller.
+func (b *Bucket[T]) NailReportingSize(id string, data T) (before, after int, err error) {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	b.lockStaged(id, data)
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return 0, 0, err
+	}
+
+	before = b.updater.Size()
+	data, err = b.admit(id, data)
+	if err != nil {
+		return before, before, err
+	}
+	_, err = b.setLocked(id, data, b.expiryFor(b.outdated))
+	return before, b.updater.Size(), err
+}
+
+// writeExpiryLocked returns the expiry for a write of id with the given TTL,
+// keeping the current deadline of a live item when KeepExpiry is set
+// Must be called with b.mutex held
+func (b *Bucket[T]) writeExpiryLocked(id string, ttl *time.Duration, opts []NailOption) *time.Time {
+	var o nailOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.keepExpiry {
+		if item, exists := b.cache[id]; exists && !item.expired(b.now()) {
+			return item.expiredAt
+		}
+	}
+	return b.expiryFor(ttl)
+}
+
+// admit validates a write and returns the value to store
+func (b *Bucket[T]) admit(id string, data T) (T, error) {
+	if err := b.checkKey(id); err != nil {
+		return data, err
+	}
+	if b.maxValueBytes > 0 && b.valueSizer != nil && b.valueSizer(data) > b.maxValueBytes {
+		return data, ErrValueTooLarge
+	}
+	if b.copyIn != nil {
+		data = b.copyIn(data)
+	}
+	return data, nil
+}
+
+// checkKey validates a key against the configured limits
+func (b *Bucket[T]) checkKey(id string) error {
+	if b.maxKeyLen > 0 && len(id) > b.maxKeyLen {
+		return ErrKeyTooLong
+	}
+	return nil
+}
+
+// expiryFor returns the expiry time for an item stored now with the given TTL
+// A nil or non-positive TTL means the item never expires
+func (b *Bucket[T]) expiryFor(ttl *time.Duration) *time.Time {
+	if ttl == nil || *ttl <= 0 {
+		return nil
+	}
+	t := b.now().Add(*ttl)
+	return &t
+}
+
+// setLocked inserts or updates an item, evicting when the bucket is full
+// Must be called with b.mutex held
+func (b *Bucket[T]) setLocked(id string, data T, expiredAt *time.Time) (*CacheItem[T], error) {
+	return b.storeLocked(id, data, expiredAt, 0)
+}
+
+// storeLocked is setLocked with the eviction priority of a newly inserted
+// item, an existing item keeps its own
+// Must be called with b.mutex held
+func (b *Bucket[T]) storeLocked(id string, data T, expiredAt *time.Time, priority int) (*CacheItem[T], error) {
 	// If key already exists, update it
 	if existingItem, exists := b.cache[id]; exists {
-		existingItem.value = data
-		existingItem.expiredAt = expiredAt
-		b.updater.Access(existingItem)
-		return nil
+		if !existingItem.expired(b.expiryNow()) {
+			if b.unchangedLocked(existingItem, data, expiredAt) {
+				return existingItem, nil
+			}
+			b.updateLocked(existingItem, data, expiredAt)
+			return existingItem, nil
+		}
+		// An expired item is replaced by a fresh one
+		b.removeLocked(existingItem, ReasonExpired)
+	}
+
+	if err := b.makeRoomLocked(); err != nil {
+		return nil, err
 	}
+	// Values headed for disk don't need room in the byte budget
+	if b.maxBytes > 0 && b.stagedSpill == nil {
+		b.trimBytesLocked(b.valueSizer(data))
+	}
+	return b.insertLocked(id, data, expiredAt, priority), nil
+}
 
+// updateLocked overwrites an existing item and marks it as accessed
+// Must be called with b.mutex held
+func (b *Bucket[T]) updateLocked(item *CacheItem[T], data T, expiredAt *time.Time) {
+	if b.updateKeepsExpiry {
+		expiredAt = item.expiredAt
+	}
+	b.recordReplacedLocked(item.key, item.value, data)
+	b.accountLocked(item, data)
+	b.storeValueLocked(item, data)
+	item.expiredAt = b.capLifetime(item.createdAt, expiredAt)
+	item.updatedAt = b.now()
+	item.sourceTime = time.Time{}
+	item.version++
+	b.scheduleLocked(item)
+	b.updater.Access(item)
+	b.publishLocked(item.key)
+	b.logSetLocked(item)
+}
+
+// unchangedLocked reports whether data equals the value of item under
+// WithValueEquality, in which case the write is skipped and at most the
+// deadline is moved
+// Must be called with b.mutex held
+func (b *Bucket[T]) unchangedLocked(item *CacheItem[T], data T, expiredAt *time.Time) bool {
+	if b.valueEqual == nil || !b.valueEqual(b.valueOf(item), data) {
+		return false
+	}
+	if b.equalResetsTTL && !b.updateKeepsExpiry {
+		item.expiredAt = b.capLifetime(item.createdAt, expiredAt)
+		b.scheduleLocked(item)
+		b.logExpiryLocked(item)
+	}
+	return true
+}
+
+// makeRoomLocked evicts items so that one more item can be inserted
+// Must be called with b.mutex held
+func (b *Bucket[T]) makeRoomLocked() error {
+	if b.overflowPolicy == OverflowReject {
+		if b.rejectOverflowLocked() {
+			return ErrCacheFull
+		}
+		return nil
+	}
 	// If cache is full, remove least recently used item
+	evictions := 0
 	if b.updater.Size() >= b.maxSize {
+		evictions = 1
+	}
+	// Above the soft limit every insert evicts two items to converge back down
+	if b.softMaxSize > 0 && b.updater.Size() > b.softMaxSize {
+		evictions = 2
+	}
+	for ; evictions > 0; evictions-- {
 		evictedItem := b.updater.Evict()
-		if evictedItem != nil {
-			delete(b.cache, evictedItem.key)
+		if evictedItem == nil {
+			if b.strictCapacity && b.updater.Size() >= b.maxSize {
+				// The updater reports full but has nothing to evict
+				return ErrCacheFull
+			}
+			break
 		}
+		b.forgetLocked(evictedItem, ReasonEvicted)
 	}
+	return nil
+}
 
+// insertLocked adds a new item without checking capacity
+// Must be called with b.mutex held
+func (b *Bucket[T]) insertLocked(id string, data T, expiredAt *time.Time, priority int) *CacheItem[T] {
 	// Create new cache item
+	now := b.now()
 	newItem := &CacheItem[T]{
 		key:       id,
 		value:     data,
-		expiredAt: expiredAt,
+		expiredAt: b.capLifetime(now, expiredAt),
+		createdAt: now,
+		updatedAt: now,
+		version:   1,
+		priority:  priority,
 	}
 
+	b.accountLocked(newItem, data)
+	b.storeValueLocked(newItem, data)
 	b.cache[id] = newItem
+	b.indexKeyLocked(newItem)
+	b.peakSize = max(b.peakSize, len(b.cache))
 	b.updater.Add(newItem)
+	b.groupGrew = b.group != nil
+	b.scheduleLocked(newItem)
+	b.publishLocked(id)
+	b.logSetLocked(newItem)
+	return newItem
+}
 
-	return nil
+// capLifetime limits expiredAt to the maximum lifetime of an item created at
+// createdAt
+func (b *Bucket[T]) capLifetime(createdAt time.Time, expiredAt *time.Time) *time.Time {
+	if b.maxLifetime <= 0 {
+		return expiredAt
+	}
+	deadline := createdAt.Add(b.maxLifetime)
+	if expiredAt == nil || expiredAt.After(deadline) {
+		return &deadline
+	}
+	return expiredAt
 }
 
 // Bring retrieves data from the bucket
 func (b *Bucket[T]) Bring(id string) (T, bool) {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
+	return b.get(id, b.autoFill)
+}
+
+// get is Bring with timing and tracing, filling misses through the loader
+// when fill is set
+func (b *Bucket[T]) get(id string, fill bool) (T, bool) {
+	if b.timed() {
+		defer b.observeBring(time.Now())
+	}
+
+	if b.traceHook != nil {
+		end := b.traceBringStart(id)
+		value, ok := b.lookup(id, fill)
+		end(ok)
+		return value, ok
+	}
+	return b.lookup(id, fill)
+}
+
+// lookup brings id, filling a miss through the loader when fill is set
+func (b *Bucket[T]) lookup(id string, fill bool) (T, bool) {
+	value, ok := b.bring(id)
+	if !ok && fill {
+		return b.fill(id)
+	}
+	return value, ok
+}
+
+// bring looks up id under the write lock, removing it if it has expired
+func (b *Bucket[T]) bring(id string) (T, bool) {
+	item := b.lockAccess(id)
+	if item == nil {
+		b.unlock()
+		var zero T
+		return zero, false
+	}
+	key, version := item.key, item.version
+	value, ok := b.readUnlock(item)
+	if ok && b.readRepair != nil {
+		b.startRepair(key, version, value)
+	}
+	return value, ok
+}
 
-	var zero T
+// lockAccess takes the write lock and returns the live item for id like
+// accessLocked
+// An expired item is first handed to the expire interceptor with the lock
+// released, so a read of a key the interceptor keeps hits. The caller must
+// release the lock with b.unlock.
+func (b *Bucket[T]) lockAccess(id string) *CacheItem[T] {
+	b.lock()
+	if b.expireInterceptor != nil {
+		if item, exists := b.cache[id]; exists && item.expired(b.expiryNow()) {
+			candidate := b.candidateLocked(item)
+			b.unlock()
+			b.interceptExpired([]expireCandidate[T]{candidate})
+			b.lock()
+		}
+	}
+	return b.accessLocked(id)
+}
 
+// accessLocked returns the live item for id and marks it as accessed,
+// counting the hit or miss
+// Expired items are removed without consulting the expire interceptor and
+// nil is returned, use lockAccess for reads. Must be called with b.mutex
+// held
+func (b *Bucket[T]) accessLocked(id string) *CacheItem[T] {
 	// Check if bucket is closed
 	if b.isClosed() {
-		return zero, false
+		return nil
 	}
 
 	item, exists := b.cache[id]
 	if !exists {
-		return zero, false
+		b.counters.misses.Add(1)
+		return nil
 	}
 
-	// Check if expired
-	if item.expiredAt != nil && time.Now().After(*item.expiredAt) {
-		b.updater.Remove(item)
-		delete(b.cache, id)
-		return zero, false
+	// Check if expired, a frozen bucket keeps the item until it thaws
+	if item.expired(b.expiryNow()) {
+		if !b.frozen.Load() {
+			b.removeLocked(item, ReasonExpired)
+		}
+		b.counters.misses.Add(1)
+		return nil
 	}
 
 	// Mark as accessed
 	b.updater.Access(item)
+	b.counters.hits.Add(1)
 
-	return item.value, true
+	return item
+}
+
+// BringRef retrieves a pointer to the stored value without copying it
+// The pointer aliases the cached item and must be treated as read-only. The
+// copier set with WithValueCopier is bypassed, and a later write of the same
+// key overwrites the value in place, so don't hold on to the pointer across
+// writes of id.
+func (b *Bucket[T]) BringRef(id string) (*T, bool) {
+	item := b.lockAccess(id)
+	if item == nil {
+		b.unlock()
+		return nil, false
+	}
+	if item.spill != nil {
+		value, ok := b.readUnlock(item)
+		if !ok {
+			return nil, false
+		}
+		return &value, true
+	}
+	b.unlock()
+	return &item.value, true
 }
 
-// startCleanup starts the background goroutine for cleaning up expired items
-func (b *Bucket[T]) startCleanup() {
-	ticker := time.NewTicker(b.cleanupInterval)
-	defer ticker.Stop()
+// Unnail removes id from the bucket and reports whether it was present
+// Expired items that haven't been cleaned up yet are removed but reported as
+// absent.
+func (b *Bucket[T]) Unnail(id string) (bool, error) {
+	b.lock()
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return false, err
+	}
+
+	item, exists := b.cache[id]
+	if !exists {
+		return false, nil
+	}
+	if item.expired(b.now()) {
+		b.removeLocked(item, ReasonExpired)
+		return false, nil
+	}
+	b.removeLocked(item, ReasonDeleted)
+	return true, nil
+}
+
+// cleanupLoop runs the background cleanup of expired items until the bucket
+// closes
+// The goroutine sleeps until the next deadline in the expiry heap, rounded up
+// by the cadence of its TTL tier, and wakes at least every cleanupInterval
+// for housekeeping of loader errors. With jitter the first
+// sweep is delayed by a random fraction of the interval, so buckets created
+// together don't sweep in lockstep.
+func (b *Bucket[T]) cleanupLoop() {
+	defer b.cleanupRunning.Store(false)
+
+	nextSweep := time.Now().Add(b.cleanupInterval + b.cleanupDelay())
+	timer := time.NewTimer(b.nextWake(time.Until(nextSweep)))
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			if b.isClosed() {
-				return
-			}
-			b.cleanupExpired()
+		case <-timer.C:
+		case <-b.cleanupWake:
 		case <-b.stopCleanup:
 			return
 		}
+		if b.isClosed() {
+			return
+		}
+
+		sweep := !time.Now().Before(nextSweep)
+		if sweep {
+			nextSweep = time.Now().Add(b.cleanupInterval)
+		}
+		if !b.cleanupIsPaused() && !b.frozen.Load() {
+			b.runCleanup(sweep)
+		}
+		resetTimer(timer, b.nextWake(time.Until(nextSweep)))
 	}
 }
 
-// cleanupExpired removes expired cache items
-func (b *Bucket[T]) cleanupExpired() {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
+// cleanupDelay returns the random delay of the first sweep, zero without
+// jitter
+func (b *Bucket[T]) cleanupDelay() time.Duration {
+	if !b.cleanupJitter || b.cleanupInterval <= 0 {
+		return 0
+	}
+	return rand.N(b.cleanupInterval)
+}
 
-	// Double-check if closed after acquiring lock
-	if b.closed {
-		return
+// runCleanup runs one cleanup pass, keeping the goroutine alive if a custom
+// updater panics
+func (b *Bucket[T]) runCleanup(sweep bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.log(LogError, "updater panic recovered during cleanup", "panic", r)
+		}
+	}()
+
+	if removed := b.cleanupExpired(sweep); removed > 0 {
+		b.log(LogDebug, "expired items cleaned up", "count", removed)
 	}
+}
 
-	now := time.Now()
-	expiredKeys := make([]string, 0)
+// cleanupExpired removes expired cache items and returns how many it removed,
+// also dropping stale loader errors when sweep is set
+func (b *Bucket[T]) cleanupExpired(sweep bool) int {
+	if b.latency != nil {
+		defer b.latency.cleanup.observe(time.Now())
+	}
 
-	// Collect expired keys
-	for key, item := range b.cache {
-		if item.expiredAt != nil && now.After(*item.expiredAt) {
-			expiredKeys = append(expiredKeys, key)
-		}
+	removed, candidates := b.sweepExpired(sweep)
+	if len(candidates) > 0 {
+		removed += b.interceptExpired(candidates)
 	}
+	return removed
+}
 
-	// Delete expired items
-	for _, key := range expiredKeys {
-		if item, exists := b.cache[key]; exists {
-			b.updater.Remove(item)
-			delete(b.cache, key)
-		}
+// sweepExpired removes the items that are due and returns how many it removed
+// Due items are found under the read lock by visiting only the front of the
+// expiry heap, and the write lock is taken only when there is something to
+// remove, so readers aren't blocked while a mostly live bucket is checked.
+// With an expire interceptor the expired items are returned as candidates
+// instead of being removed.
+func (b *Bucket[T]) sweepExpired(sweep bool) (int, []expireCandidate[T]) {
+	now := b.now()
+	b.rlock()
+	if b.closed.Load() {
+		b.mutex.RUnlock()
+		return 0, nil
+	}
+	due := b.dueLocked(now)
+	b.mutex.RUnlock()
+	if len(due) == 0 && !sweep {
+		return 0, nil
 	}
+
+	b.lockCleanup()
+	defer b.unlock()
+
+	// Double-check if closed after acquiring lock
+	if b.closed.Load() {
+		return 0, nil
+	}
+
+	removed, candidates := b.expireDueLocked(due, now)
+	if sweep {
+		b.cleanupLoadErrors(now)
+	}
+	return removed, candidates
 }
 
 // Close closes the bucket and stops the cleanup goroutine
-// It's safe to call Close multiple times
+// It's safe to call Close multiple times and concurrently with other
+// operations. The bucket is marked closed under the write lock: operations
+// that already hold the lock, such as a Range taking its snapshot, finish
+// against the contents before Close, and later ones observe the closed
+// bucket and return ErrBucketClosed or an empty result.
 func (b *Bucket[T]) Close() error {
+	// Apply pending async writes while the bucket still accepts them
+	b.drainAsync()
+
 	b.closeMutex.Lock()
 	defer b.closeMutex.Unlock()
 
 	// Check if already closed
-	if b.closed {
+	if b.closed.Load() {
 		return nil // Already closed, no error
 	}
 
-	// Mark as closed
-	b.closed = true
-
 	// Stop the cleanup goroutine
 	select {
 	case b.stopCleanup <- struct{}{}:
@@ -209,21 +849,112 @@ func (b *Bucket[T]) Close() error {
 
 	// Close the channel
 	close(b.stopCleanup)
+	if b.coarse != nil {
+		close(b.coarse.stop)
+	}
 
-	// Clear all data from the bucket
+	// Mark as closed and clear all data from the bucket. The map is emptied
+	// in place rather than swapped, the closed flag gates every access
 	b.mutex.Lock()
-	b.cache = make(map[string]*CacheItem[T])
+	b.closed.Store(true)
+	var discarded []snapshotEntry[T]
+	if b.closeEvicted {
+		for key, item := range b.cache {
+			discarded = append(discarded, snapshotEntry[T]{key: key, value: item.value})
+		}
+	}
+	b.dropAllSpillsLocked()
+	clear(b.cache)
 	b.updater.Clear()
+	b.totalBytes = 0
+	b.resetExpiriesLocked()
+	b.unbindUpdater(b.updater)
+	group := b.group
 	b.mutex.Unlock()
 
+	if group != nil {
+		group.Remove(b)
+	}
+	for _, e := range discarded {
+		b.closeValue(e.key, e.value)
+	}
+	b.closeExpirationChannels()
+	// Let reclaimers of earlier Clears finish their callbacks
+	b.reclaims.Wait()
+
+	// The log keeps the contents for the next process, so it is closed
+	// without recording the clear above
+	if b.aof != nil {
+		if err := b.aof.close(); err != nil {
+			b.log(LogError, "append log close failed", "path", b.aofPath, "err", err)
+		}
+	}
+
+	b.log(LogInfo, "bucket closed")
 	return nil
 }
 
-// isClosed checks if the bucket is closed (must be called with appropriate locking)
+// isClosed checks if the bucket is closed
+// The flag only changes under the write lock, so it stays stable for
+// callers holding b.mutex
 func (b *Bucket[T]) isClosed() bool {
-	b.closeMutex.Lock()
-	defer b.closeMutex.Unlock()
-	return b.closed
+	return b.closed.Load()
+}
+
+// CleanupRunning reports whether the background cleanup goroutine is alive
+func (b *Bucket[T]) CleanupRunning() bool {
+	return b.cleanupRunning.Load()
+}
+
+// PauseCleanup stops the background cleanup from removing expired items
+// until ResumeCleanup is called, e.g. to avoid lock contention during a bulk
+// import. Expired items are still removed lazily when accessed.
+func (b *Bucket[T]) PauseCleanup() {
+	b.pauseMutex.Lock()
+	defer b.pauseMutex.Unlock()
+	b.cleanupPaused = true
+}
+
+// ResumeCleanup resumes background cleanup paused by PauseCleanup
+// The next sweep runs at the next regular tick.
+func (b *Bucket[T]) ResumeCleanup() {
+	b.pauseMutex.Lock()
+	defer b.pauseMutex.Unlock()
+	b.cleanupPaused = false
+}
+
+// cleanupIsPaused reports whether background cleanup is paused
+func (b *Bucket[T]) cleanupIsPaused() bool {
+	b.pauseMutex.Lock()
+	defer b.pauseMutex.Unlock()
+	return b.cleanupPaused
+}
+
+// Freeze makes the bucket read-only until Unfreeze
+// Writes, deletions and Clear fail with ErrBucketFrozen, or do nothing when
+// they can't return an error, while reads keep working. Background cleanup
+// is paused and reads don't remove expired items, so the contents stay
+// unchanged, e.g. while taking a consistent snapshot. Invalidations from
+// other replicas are still applied so the bucket never serves data known to
+// be stale.
+func (b *Bucket[T]) Freeze() {
+	b.frozen.Store(true)
+}
+
+// Unfreeze makes a frozen bucket writable again
+func (b *Bucket[T]) Unfreeze() {
+	b.frozen.Store(false)
+}
+
+// writable returns the error for a write to a closed or frozen bucket
+func (b *Bucket[T]) writable() error {
+	if b.isClosed() {
+		return ErrBucketClosed
+	}
+	if b.frozen.Load() {
+		return ErrBucketFrozen
+	}
+	return nil
 }
 
 // IsClosed returns whether the bucket is closed (public method)
@@ -232,8 +963,9 @@ func (b *Bucket[T]) IsClosed() bool {
 }
 
 // Size returns the current cache size
+// It includes expired items that haven't been cleaned up yet, see LiveSize
 func (b *Bucket[T]) Size() int {
-	b.mutex.RLock()
+	b.rlock()
 	defer b.mutex.RUnlock()
 
 	if b.isClosed() {
@@ -243,17 +975,69 @@ func (b *Bucket[T]) Size() int {
 	return b.updater.Size()
 }
 
+// Utilization returns the fill ratio Size/maxSize, between 0 and 1 unless
+// the bucket has overflowed
+// It returns 0 when maxSize is not positive or the bucket is closed.
+func (b *Bucket[T]) Utilization() float64 {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.maxSize <= 0 || b.isClosed() {
+		return 0
+	}
+	return float64(b.updater.Size()) / float64(b.maxSize)
+}
+
 // Clear removes all cache items
+// It holds the lock only to swap in an empty map. Removal callbacks for the
+// cleared items run on a background goroutine after Clear returns, Close
+// waits for them.
 func (b *Bucket[T]) Clear() {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
+	b.lock()
+	defer b.unlock()
 
-	if b.isClosed() {
+	if b.writable() != nil {
 		return
 	}
 
-	b.cache = make(map[string]*CacheItem[T])
+	b.clearLocked()
+}
+
+// clearLocked removes all cache items, must be called with b.mutex held
+// The old map is swapped out rather than emptied, so the lock is held for
+// constant time; its items are handed to a background reclaimer that
+// reports them to observers and deletes their spill files.
+func (b *Bucket[T]) clearLocked() {
+	old := b.cache
+	spilled := b.spilled.entries > 0
+	b.cache = b.newCacheMap()
+	b.keys.entries = nil
+	b.peakSize = 0
 	b.updater.Clear()
+	b.totalBytes = 0
+	b.spilled = spillStats{}
+	b.resetExpiriesLocked()
+	b.logClearLocked()
+
+	if len(old) > 0 && (spilled || b.observed()) {
+		b.reclaims.Add(1)
+		b.spawn(roleReclaim, func() { b.reclaim(old) })
+	}
+}
+
+// reclaim disposes of the items of a cleared map
+func (b *Bucket[T]) reclaim(old map[string]*CacheItem[T]) {
+	defer b.reclaims.Done()
+
+	observed := b.observed()
+	for key, item := range old {
+		if observed {
+			b.dispatch([]removal[T]{{key: key, value: b.valueOf(item), reason: ReasonCleared}})
+		}
+		if item.spill != nil {
+			b.removeSpill(item.spill)
+		}
+	}
 }
 
 func WithBucketName[T any](name string) NewBucketOption[T] {
@@ -262,8 +1046,15 @@ func WithBucketName[T any](name string) NewBucketOption[T] {
 	}
 }
 
+// WithBucketExpire sets the default TTL for items
+// A zero or negative expire means items never expire by time, as with
+// WithBucketNeverExpire; they are still evicted for capacity.
 func WithBucketExpire[T any](expire time.Duration) NewBucketOption[T] {
 	return func(b *Bucket[T]) {
+		if expire <= 0 {
+			b.outdated = nil
+			return
+		}
 		b.outdated = &expire
 	}
 }
@@ -281,22 +1072,144 @@ func WithMaxSize[T any](maxSize int) NewBucketOption[T] {
 	}
 }
 
+// WithCleanupDisabled turns off the background cleanup goroutine
+// Expired items are then only removed lazily when they are accessed.
+func WithCleanupDisabled[T any]() NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.cleanupDisabled = true
+	}
+}
+
+// WithUpdateKeepsExpiry makes every update of an existing key keep its
+// current deadline, as if KeepExpiry was passed to each write
+func WithUpdateKeepsExpiry[T any]() NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.updateKeepsExpiry = true
+	}
+}
+
+// WithMaxLifetime bounds how long a key can stay in the bucket after it was
+// first inserted, no matter how often it is rewritten or which TTL it gets
+func WithMaxLifetime[T any](d time.Duration) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.maxLifetime = d
+	}
+}
+
+// WithMaxKeyLength rejects keys longer than n bytes with ErrKeyTooLong
+// Zero means unlimited
+func WithMaxKeyLength[T any](n int) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.maxKeyLen = n
+	}
+}
+
+// WithMaxValueBytes rejects values whose size measured by sizer exceeds limit
+// Nail returns ErrValueTooLarge instead of caching them. Zero means unlimited.
+func WithMaxValueBytes[T any](limit int64, sizer func(T) int64) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.maxValueBytes = limit
+		b.valueSizer = sizer
+	}
+}
+
+// WithSoftMaxSize sets a soft size limit below maxSize
+// While the bucket holds more than soft items, every insert evicts two items
+// instead of one, so the size converges back to soft during bursts.
+func WithSoftMaxSize[T any](soft int) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.softMaxSize = soft
+	}
+}
+
+// WithStrictCapacity makes Nail return ErrCacheFull when the bucket is full
+// and the updater's Evict returns nil, instead of growing past maxSize
+func WithStrictCapacity[T any]() NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.strictCapacity = true
+	}
+}
+
+// WithCleanupInterval sets how often the cleanup goroutine runs its
+// housekeeping pass
+// A zero or negative interval disables the goroutine, as WithCleanupDisabled
+// does, instead of spinning it.
 func WithCleanupInterval[T any](interval time.Duration) NewBucketOption[T] {
 	return func(b *Bucket[T]) {
+		if interval <= 0 {
+			b.cleanupDisabled = true
+			return
+		}
 		b.cleanupInterval = interval
 	}
 }
 
+// WithCleanupJitter sets whether the first cleanup sweep is delayed by a
+// random fraction of the cleanup interval, on by default
+// Jitter spreads the sweeps of buckets created together over the interval
+// instead of waking them all at once. Expiry deadlines are not delayed.
+func WithCleanupJitter[T any](enabled bool) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.cleanupJitter = enabled
+	}
+}
+
+// WithValueEquality skips writes that store a value equal to the current one
+// under eq
+// Such a write leaves the item as it is: the value, version, deadline and
+// eviction order are kept, and it isn't published, logged or reported to
+// WithCloseEvictedValues. WithEqualWriteTTLReset makes it restart the TTL.
+// Spilled values are read back from disk for the comparison.
+func WithValueEquality[T any](eq func(a, b T) bool) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.valueEqual = eq
+	}
+}
+
+// WithEqualWriteTTLReset makes writes skipped by WithValueEquality still
+// restart the TTL of the item, as Touch does
+func WithEqualWriteTTLReset[T any]() NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.equalResetsTTL = true
+	}
+}
+
 // WithUpdater sets a custom update strategy
+// A nil updater is ignored and the bucket keeps its LRU default.
 func WithUpdater[T any](updater Updater[T]) NewBucketOption[T] {
 	return func(b *Bucket[T]) {
+		if updater == nil {
+			return
+		}
 		b.updater = updater
+		b.newUpdater = nil
 	}
 }
 
 // WithFIFOUpdater sets FIFO update strategy
 func WithFIFOUpdater[T any]() NewBucketOption[T] {
 	return func(b *Bucket[T]) {
-		b.updater = newFIFO[T]()
+		b.newUpdater = func() Updater[T] { return newFIFO[T]() }
+		b.updater = b.newUpdater()
+	}
+}
+
+// WithSampledLRUUpdater sets an approximate LRU strategy for large caches
+// Eviction samples sampleSize random items and evicts the least recently
+// used one among them instead of maintaining an exact recency list.
+func WithSampledLRUUpdater[T any](sampleSize int) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.newUpdater = func() Updater[T] { return newSampledLRU[T](sampleSize) }
+		b.updater = b.newUpdater()
+	}
+}
+
+// WithDecayingLFUUpdater sets an LFU strategy whose access counts halve every
+// halfLife, so items that were popular long ago eventually become evictable
+// A non-positive halfLife disables decay.
+func WithDecayingLFUUpdater[T any](halfLife time.Duration) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.newUpdater = func() Updater[T] { return newDecayingLFU[T](halfLife) }
+		b.updater = b.newUpdater()
 	}
 }
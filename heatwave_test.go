@@ -0,0 +1,435 @@
+package heatwave
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxKeyLength(t *testing.T) {
+	b := NewBucket[int](WithMaxKeyLength[int](4))
+	defer b.Close()
+
+	if err := b.Nail("abcd", 1); err != nil {
+		t.Fatalf("Nail within the limit: %v", err)
+	}
+	if err := b.Nail("abcde", 2); !errors.Is(err, ErrKeyTooLong) {
+		t.Fatalf("Nail over the limit = %v, want ErrKeyTooLong", err)
+	}
+	if _, ok := b.Bring("abcde"); ok {
+		t.Fatal("over-length key was stored")
+	}
+	if v, ok := b.Bring("abcd"); !ok || v != 1 {
+		t.Fatalf("Bring(abcd) = %v, %v, want 1, true", v, ok)
+	}
+}
+
+func TestMaxKeyLengthAppliesToReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bucket.aof")
+
+	w := NewBucket[int](WithAppendLog[int](path, SyncAlways))
+	if err := w.Nail("ok", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Nail(strings.Repeat("k", 16), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewBucket[int](WithMaxKeyLength[int](8))
+	defer r.Close()
+	applied, err := r.ReplayLog(path)
+	if err != nil {
+		t.Fatalf("ReplayLog: %v", err)
+	}
+	if applied != 1 {
+		t.Fatalf("applied = %d, want 1", applied)
+	}
+	if r.Size() != 1 {
+		t.Fatalf("Size = %d, want 1", r.Size())
+	}
+}
+
+// stuckUpdater never finds anything to evict
+type stuckUpdater[T any] struct {
+	BaseUpdater[T]
+}
+
+func (u *stuckUpdater[T]) Evict() *CacheItem[T] { return nil }
+
+func TestLenientCapacityGrowsPastMaxSize(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2), WithUpdater[int](&stuckUpdater[int]{}))
+	defer b.Close()
+
+	for i, key := range []string{"a", "b", "c"} {
+		if err := b.Nail(key, i); err != nil {
+			t.Fatalf("Nail(%s) = %v", key, err)
+		}
+	}
+	if b.Size() != 3 {
+		t.Fatalf("Size = %d, want 3", b.Size())
+	}
+}
+
+func TestStrictCapacityRejectsInserts(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2), WithUpdater[int](&stuckUpdater[int]{}), WithStrictCapacity[int]())
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	if err := b.Nail("c", 3); !errors.Is(err, ErrCacheFull) {
+		t.Fatalf("Nail into a full bucket = %v, want ErrCacheFull", err)
+	}
+	if b.Size() != 2 {
+		t.Fatalf("Size = %d, want 2", b.Size())
+	}
+	// Updates need no room
+	if err := b.Nail("a", 10); err != nil {
+		t.Fatalf("update in a full bucket = %v", err)
+	}
+	if v, _ := b.Bring("a"); v != 10 {
+		t.Fatalf("Bring(a) = %d, want 10", v)
+	}
+}
+
+// exists reports whether key holds a live item, without touching access order
+func exists[T any](b *Bucket[T], key string) bool {
+	return b.ExistsMany([]string{key})[key]
+}
+
+func TestSoftMaxSizeConvergesDown(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](100), WithSoftMaxSize[int](10))
+	defer b.Close()
+
+	for i := 0; i < 50; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+		if n := b.Size(); n > 11 {
+			t.Fatalf("Size = %d after %d inserts, want at most one over the soft limit", n, i+1)
+		}
+	}
+	// Once over the soft limit every insert evicts two items, the newest
+	// items survive
+	if !exists(b, "49") || exists(b, "0") {
+		t.Fatal("eviction above the soft limit didn't drop the oldest items")
+	}
+	if ev := b.Stats().Evictions; ev < 39 {
+		t.Fatalf("Evictions = %d, want at least 39", ev)
+	}
+}
+
+func TestMaxValueBytes(t *testing.T) {
+	b := NewBucket[string](WithMaxValueBytes[string](4, func(s string) int64 { return int64(len(s)) }))
+	defer b.Close()
+
+	if err := b.Nail("small", "abcd"); err != nil {
+		t.Fatalf("Nail within the limit: %v", err)
+	}
+	if err := b.Nail("big", "abcde"); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("Nail over the limit = %v, want ErrValueTooLarge", err)
+	}
+	if exists(b, "big") {
+		t.Fatal("oversized value was stored")
+	}
+	// A rejected update leaves the old value in place
+	if err := b.Nail("small", "abcdef"); !errors.Is(err, ErrValueTooLarge) {
+		t.Fatalf("oversized update = %v, want ErrValueTooLarge", err)
+	}
+	if v, ok := b.Bring("small"); !ok || v != "abcd" {
+		t.Fatalf("Bring(small) = %q, %v, want abcd, true", v, ok)
+	}
+
+	unlimited := NewBucket[string](WithMaxValueBytes[string](0, func(s string) int64 { return int64(len(s)) }))
+	defer unlimited.Close()
+	if err := unlimited.Nail("big", strings.Repeat("x", 1<<16)); err != nil {
+		t.Fatalf("Nail with a zero limit = %v", err)
+	}
+}
+
+func TestBringRef(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[[4]int](WithClock[[4]int](clock), WithCleanupDisabled[[4]int](), WithMaxSize[[4]int](2))
+	defer b.Close()
+
+	_ = b.Nail("a", [4]int{1, 2, 3, 4})
+	_ = b.NailWithTTL("b", [4]int{5}, time.Second)
+
+	p, ok := b.BringRef("a")
+	if !ok || *p != [4]int{1, 2, 3, 4} {
+		t.Fatalf("BringRef(a) = %v, %v", p, ok)
+	}
+	// The pointer aliases the stored value instead of a copy
+	if q, _ := b.BringRef("a"); q != p {
+		t.Fatal("BringRef returned a copy")
+	}
+	if s := b.Stats(); s.Hits != 2 {
+		t.Fatalf("Hits = %d, want 2", s.Hits)
+	}
+
+	clock.Advance(2 * time.Second)
+	if _, ok := b.BringRef("b"); ok {
+		t.Fatal("BringRef returned an expired item")
+	}
+
+	// BringRef counts as an access, so the other key is evicted first
+	_ = b.Nail("c", [4]int{6})
+	_, _ = b.BringRef("c")
+	_, _ = b.BringRef("a")
+	_ = b.Nail("d", [4]int{7})
+	if !exists(b, "a") || exists(b, "c") {
+		t.Fatal("BringRef didn't mark the item as recently used")
+	}
+}
+
+// largeValue is a value expensive to copy
+type largeValue struct {
+	data [4096]byte
+}
+
+// BenchmarkBringLarge compares Bring, which copies the value, with BringRef
+func BenchmarkBringLarge(b *testing.B) {
+	bucket := NewBucket[largeValue]()
+	defer bucket.Close()
+	_ = bucket.Nail("k", largeValue{})
+
+	var sink byte
+	b.Run("Bring", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v, _ := bucket.Bring("k")
+			sink += v.data[i%len(v.data)]
+		}
+	})
+	b.Run("BringRef", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v, _ := bucket.BringRef("k")
+			sink += v.data[i%len(v.data)]
+		}
+	})
+	_ = sink
+}
+
+func TestNailReportingSize(t *testing.T) {
+	b := NewBucket[string](
+		WithMaxSize[string](2),
+		WithMaxValueBytes[string](8, func(s string) int64 { return int64(len(s)) }),
+	)
+
+	for _, tc := range []struct {
+		key, value    string
+		before, after int
+	}{
+		{"a", "1", 0, 1},
+		{"b", "2", 1, 2},
+		{"a", "3", 2, 2}, // Update
+		{"c", "4", 2, 2}, // Insert evicting b
+	} {
+		before, after, err := b.NailReportingSize(tc.key, tc.value)
+		if err != nil || before != tc.before || after != tc.after {
+			t.Fatalf("NailReportingSize(%s) = %d, %d, %v, want %d, %d, nil",
+				tc.key, before, after, err, tc.before, tc.after)
+		}
+	}
+	if exists(b, "b") {
+		t.Fatal("b wasn't evicted")
+	}
+
+	before, after, err := b.NailReportingSize("d", "too large value")
+	if !errors.Is(err, ErrValueTooLarge) || before != 2 || after != 2 {
+		t.Fatalf("rejected NailReportingSize = %d, %d, %v, want 2, 2, ErrValueTooLarge", before, after, err)
+	}
+
+	_ = b.Close()
+	if _, _, err := b.NailReportingSize("e", "5"); !errors.Is(err, ErrBucketClosed) {
+		t.Fatalf("NailReportingSize on a closed bucket = %v, want ErrBucketClosed", err)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.NailWithTTL("b", 2, time.Second)
+	b.Freeze()
+	clock.Advance(2 * time.Second)
+
+	if err := b.Nail("c", 3); !errors.Is(err, ErrBucketFrozen) {
+		t.Fatalf("Nail while frozen = %v, want ErrBucketFrozen", err)
+	}
+	if _, err := b.Unnail("a"); !errors.Is(err, ErrBucketFrozen) {
+		t.Fatalf("Unnail while frozen = %v, want ErrBucketFrozen", err)
+	}
+	b.Clear()
+	if v, ok := b.Bring("a"); !ok || v != 1 {
+		t.Fatalf("Bring(a) while frozen = %d, %v, want 1, true", v, ok)
+	}
+	// Reads don't remove the expired item while frozen
+	if _, ok := b.Bring("b"); ok {
+		t.Fatal("Bring returned an expired item")
+	}
+	if b.Size() != 2 {
+		t.Fatalf("Size while frozen = %d, want 2", b.Size())
+	}
+
+	b.Unfreeze()
+	if err := b.Nail("c", 3); err != nil {
+		t.Fatalf("Nail after Unfreeze: %v", err)
+	}
+	if ok, err := b.Unnail("a"); !ok || err != nil {
+		t.Fatalf("Unnail after Unfreeze = %v, %v, want true, nil", ok, err)
+	}
+	if v, ok := b.Bring("c"); !ok || v != 3 {
+		t.Fatalf("Bring(c) = %d, %v, want 3, true", v, ok)
+	}
+}
+
+func TestCloseDuringReads(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		b := NewBucket[int]()
+		for i := 0; i < 200; i++ {
+			_ = b.Nail(strconv.Itoa(i), i)
+		}
+
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				<-start
+				for i := 0; i < 200; i++ {
+					key := strconv.Itoa(i)
+					if w%2 == 0 {
+						if v, ok := b.Bring(key); ok && v != i {
+							t.Errorf("Bring(%s) = %d", key, v)
+							return
+						}
+						continue
+					}
+					b.Range(func(key string, value int) bool {
+						if key != strconv.Itoa(value) {
+							t.Errorf("Range yielded %s = %d", key, value)
+						}
+						return true
+					})
+				}
+			}(w)
+		}
+		close(start)
+		_ = b.Close()
+		wg.Wait()
+
+		if err := b.Nail("a", 1); !errors.Is(err, ErrBucketClosed) {
+			t.Fatalf("Nail after Close = %v, want ErrBucketClosed", err)
+		}
+		if _, ok := b.Bring("0"); ok {
+			t.Fatal("Bring after Close found an item")
+		}
+		b.Range(func(key string, value int) bool {
+			t.Fatalf("Range after Close yielded %s", key)
+			return false
+		})
+	}
+}
+
+func TestValueEqualitySkipsEqualWrites(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	br := NewMemoryBroadcaster()
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithBucketExpire[int](time.Minute),
+		WithBroadcaster[int](br),
+		WithValueEquality[int](func(a, b int) bool { return a == b }),
+	)
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	before, _ := b.ItemInfo("a")
+	clock.Advance(30 * time.Second)
+
+	if err := b.Nail("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(br.Events()); n != 1 {
+		t.Fatalf("%d invalidations after an equal write, want only the insert's", n)
+	}
+	if info, _ := b.ItemInfo("a"); info != before {
+		t.Fatalf("equal write changed the item: %+v, want %+v", info, before)
+	}
+
+	if err := b.Nail("a", 2); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(br.Events()); n != 2 {
+		t.Fatalf("%d invalidations after a changed write, want 2", n)
+	}
+	info, _ := b.ItemInfo("a")
+	if info.Version != 2 || !info.ExpiresAt.After(before.ExpiresAt) {
+		t.Fatalf("changed write left %+v, want version 2 and a new deadline", info)
+	}
+}
+
+func TestEqualWriteTTLReset(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithBucketExpire[int](time.Minute),
+		WithValueEquality[int](func(a, b int) bool { return a == b }),
+		WithEqualWriteTTLReset[int](),
+	)
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	clock.Advance(45 * time.Second)
+	_ = b.Nail("a", 1)
+	clock.Advance(45 * time.Second)
+
+	info, ok := b.ItemInfo("a")
+	if !ok {
+		t.Fatal("equal write didn't restart the TTL")
+	}
+	if info.Version != 1 {
+		t.Fatalf("Version = %d, want 1", info.Version)
+	}
+}
+
+func TestNailUntil(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithBucketExpire[int](time.Minute))
+	defer b.Close()
+
+	midnight := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	if err := b.NailUntil("report", 1, midnight); err != nil {
+		t.Fatalf("NailUntil: %v", err)
+	}
+	_ = b.NailUntil("lazy", 2, midnight)
+	if err := b.NailUntil("late", 3, clock.Now()); !errors.Is(err, ErrDeadlinePassed) {
+		t.Fatalf("NailUntil(now) = %v, want ErrDeadlinePassed", err)
+	}
+
+	// The bucket TTL of a minute doesn't apply
+	clock.Set(midnight)
+	if _, ok := b.Bring("report"); !ok {
+		t.Fatal("item expired before its deadline")
+	}
+	clock.Advance(time.Nanosecond)
+	if _, ok := b.Bring("lazy"); ok {
+		t.Fatal("item outlived its deadline")
+	}
+	if n := b.CleanupNow(); n != 1 {
+		t.Fatalf("CleanupNow = %d, want 1", n)
+	}
+	if b.Size() != 0 {
+		t.Fatalf("Size = %d, want 0", b.Size())
+	}
+}
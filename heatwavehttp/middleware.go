@@ -0,0 +1,304 @@
+// Package heatwavehttp provides HTTP response caching built on heatwave buckets
+package heatwavehttp
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AeaZer/heatwave"
+)
+
+const (
+	// CacheHeader reports whether a response was served from the cache
+	CacheHeader = "X-Heatwave-Cache"
+
+	defaultMaxBodySize = 1 << 20
+)
+
+// CachedResponse is a captured HTTP response
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Option configures the middleware
+type Option func(c *config)
+
+type config struct {
+	maxBodySize int
+	ttl         func(r *http.Request) time.Duration
+	allowCookie bool
+}
+
+// WithMaxBodySize sets the largest response body that is cached
+// Larger responses are passed through without being stored. Zero or a
+// negative n lifts the limit; the default is 1 MiB.
+func WithMaxBodySize(n int) Option {
+	return func(c *config) {
+		c.maxBodySize = n
+	}
+}
+
+// WithTTL overrides the bucket TTL per request, e.g. per route
+// Returning zero uses the bucket default.
+func WithTTL(ttl func(r *http.Request) time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// WithSetCookie allows caching responses that carry Set-Cookie headers
+// This is off by default because it can leak one user's session to others.
+func WithSetCookie() Option {
+	return func(c *config) {
+		c.allowCookie = true
+	}
+}
+
+// Middleware returns a middleware that caches successful GET responses in b
+// HEAD requests are served from cached GET responses. Requests with
+// Cache-Control: no-store or an Authorization header and responses with
+// Cache-Control: no-store or private are never cached. A response with a
+// Vary header is stored per value of the headers it names, and Vary: * is
+// not cached. Hits are served without calling the next handler and every
+// response carries CacheHeader set to HIT or MISS. If keyFn is nil the
+// request URI is used as key.
+func Middleware(b *heatwave.Bucket[CachedResponse], keyFn func(*http.Request) string, opts ...Option) func(http.Handler) http.Handler {
+	cfg := &config{maxBodySize: defaultMaxBodySize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if keyFn == nil {
+		keyFn = func(r *http.Request) string {
+			return r.URL.RequestURI()
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := keyFn(r)
+			bypass := hasDirective(r.Header, "no-store") || r.Header.Get("Authorization") != ""
+			if !bypass {
+				if resp, ok := lookup(b, key, r); ok {
+					serve(w, r, resp)
+					return
+				}
+			}
+
+			w.Header().Set(CacheHeader, "MISS")
+			if r.Method == http.MethodHead || bypass {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w, limit: cfg.maxBodySize}
+			next.ServeHTTP(rec, r)
+			rec.finish()
+			if !rec.cacheable(cfg.allowCookie) {
+				return
+			}
+
+			resp := CachedResponse{
+				Status: rec.status,
+				Header: rec.header,
+				Body:   rec.body.Bytes(),
+			}
+			store := func(key string, resp CachedResponse) {
+				if cfg.ttl != nil {
+					_ = b.NailWithTTL(key, resp, cfg.ttl(r))
+				} else {
+					_ = b.Nail(key, resp)
+				}
+			}
+			// The plain key only records which headers the response varies
+			// on, the variant key holds the response for this request's
+			// values
+			if vary := resp.Header.Values("Vary"); len(vary) > 0 {
+				store(variantKey(key, vary, r), resp)
+				store(key, CachedResponse{Header: http.Header{"Vary": vary}})
+				return
+			}
+			store(key, resp)
+		})
+	}
+}
+
+// lookup finds the cached response for r, following Vary to the variant
+// matching its headers
+func lookup(b *heatwave.Bucket[CachedResponse], key string, r *http.Request) (CachedResponse, bool) {
+	resp, ok := b.Bring(key)
+	if !ok {
+		return resp, false
+	}
+	if vary := resp.Header.Values("Vary"); len(vary) > 0 {
+		return b.Bring(variantKey(key, vary, r))
+	}
+	return resp, true
+}
+
+// variantKey extends key with the values r has for the headers named in
+// vary
+func variantKey(key string, vary []string, r *http.Request) string {
+	var sb strings.Builder
+	sb.WriteString(key)
+	for _, v := range vary {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			sb.WriteString("\x00")
+			sb.WriteString(name)
+			sb.WriteString("=")
+			sb.WriteString(strings.Join(r.Header.Values(name), ","))
+		}
+	}
+	return sb.String()
+}
+
+// serve writes a cached response
+func serve(w http.ResponseWriter, r *http.Request, resp CachedResponse) {
+	h := w.Header()
+	for k, v := range resp.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set(CacheHeader, "HIT")
+	w.WriteHeader(resp.Status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(resp.Body)
+	}
+}
+
+// hasDirective reports whether a header set carries one of the
+// Cache-Control directives in names
+// Directives with arguments, like private="Set-Cookie", match by name.
+func hasDirective(h http.Header, names ...string) bool {
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			directive, _, _ = strings.Cut(strings.TrimSpace(directive), "=")
+			for _, name := range names {
+				if strings.EqualFold(directive, name) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// varyAll reports whether a header set carries Vary: *
+func varyAll(h http.Header) bool {
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if strings.TrimSpace(name) == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recorder passes a response through while capturing it
+type recorder struct {
+	http.ResponseWriter
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	limit    int // Largest body captured, non-positive for no limit
+	tooLarge bool
+	hijacked bool // Whether the connection was taken over
+}
+
+// WriteHeader captures the status and a copy of the headers
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+		r.header = r.ResponseWriter.Header().Clone()
+		r.header.Del(CacheHeader)
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write captures the body until the size limit is exceeded
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.tooLarge {
+		if r.limit > 0 && r.body.Len()+len(p) > r.limit {
+			r.tooLarge = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// finish records the implicit 200 of a handler that wrote nothing
+func (r *recorder) finish() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+		r.header = r.ResponseWriter.Header().Clone()
+		r.header.Del(CacheHeader)
+	}
+}
+
+// cacheable reports whether the captured response may be stored
+func (r *recorder) cacheable(allowCookie bool) bool {
+	if r.status != http.StatusOK || r.tooLarge || r.hijacked {
+		return false
+	}
+	if hasDirective(r.header, "no-store", "private") || varyAll(r.header) {
+		return false
+	}
+	if !allowCookie && len(r.header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	return true
+}
+
+// Flush sends buffered data to the client if the underlying writer supports
+// it
+func (r *recorder) Flush() {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, the response is then
+// not cached
+func (r *recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	r.hijacked = true
+	return h.Hijack()
+}
+
+// Push initiates an HTTP/2 server push if the underlying writer supports it
+func (r *recorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,181 @@
+package heatwavehttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AeaZer/heatwave"
+)
+
+// counting returns a handler that writes body and counts its calls
+func counting(calls *atomic.Int32, header http.Header, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		fmt.Fprintf(w, "%s %d", body, n)
+	})
+}
+
+func get(h http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func newBucket(t *testing.T) *heatwave.Bucket[CachedResponse] {
+	t.Helper()
+	b := heatwave.NewBucket[CachedResponse]()
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+func TestMiddlewareCachesGet(t *testing.T) {
+	var calls atomic.Int32
+	h := Middleware(newBucket(t), nil)(counting(&calls, nil, "hello"))
+
+	first := get(h, "/a", nil)
+	second := get(h, "/a", nil)
+	if first.Header().Get(CacheHeader) != "MISS" || second.Header().Get(CacheHeader) != "HIT" {
+		t.Fatalf("cache headers = %q, %q, want MISS, HIT", first.Header().Get(CacheHeader), second.Header().Get(CacheHeader))
+	}
+	if second.Body.String() != "hello 1" || calls.Load() != 1 {
+		t.Fatalf("second body = %q after %d calls, want the cached response", second.Body.String(), calls.Load())
+	}
+}
+
+func TestMiddlewareSkipsAuthorizedRequests(t *testing.T) {
+	var calls atomic.Int32
+	h := Middleware(newBucket(t), nil)(counting(&calls, nil, "secret"))
+
+	auth := http.Header{"Authorization": {"Bearer x"}}
+	get(h, "/me", auth)
+	if rec := get(h, "/me", auth); rec.Header().Get(CacheHeader) != "MISS" {
+		t.Fatal("authorized request was served from the cache")
+	}
+	if rec := get(h, "/me", nil); rec.Header().Get(CacheHeader) != "MISS" {
+		t.Fatal("authorized response was stored for anonymous requests")
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("handler called %d times, want 3", calls.Load())
+	}
+}
+
+func TestMiddlewareSkipsPrivateResponses(t *testing.T) {
+	for _, cc := range []string{"private", "no-store", `private="Set-Cookie"`, "max-age=60, Private"} {
+		var calls atomic.Int32
+		h := Middleware(newBucket(t), nil)(counting(&calls, http.Header{"Cache-Control": {cc}}, "x"))
+		get(h, "/", nil)
+		if rec := get(h, "/", nil); rec.Header().Get(CacheHeader) != "MISS" {
+			t.Fatalf("Cache-Control: %s was cached", cc)
+		}
+	}
+}
+
+func TestMiddlewareHonoursVary(t *testing.T) {
+	var calls atomic.Int32
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprint(w, r.Header.Get("Accept-Language"))
+	})
+	b := newBucket(t)
+	h := Middleware(b, nil)(inner)
+
+	en := http.Header{"Accept-Language": {"en"}}
+	de := http.Header{"Accept-Language": {"de"}}
+	get(h, "/", en)
+	if rec := get(h, "/", de); rec.Header().Get(CacheHeader) != "MISS" || rec.Body.String() != "de" {
+		t.Fatalf("de request got %q (%s)", rec.Body.String(), rec.Header().Get(CacheHeader))
+	}
+	if rec := get(h, "/", en); rec.Header().Get(CacheHeader) != "HIT" || rec.Body.String() != "en" {
+		t.Fatalf("en request got %q (%s)", rec.Body.String(), rec.Header().Get(CacheHeader))
+	}
+	if rec := get(h, "/", de); rec.Header().Get(CacheHeader) != "HIT" || rec.Body.String() != "de" {
+		t.Fatalf("de request got %q (%s)", rec.Body.String(), rec.Header().Get(CacheHeader))
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("handler called %d times, want 2", calls.Load())
+	}
+	// The plain key holds the Vary list, not another copy of a body
+	base, ok := b.Bring("/")
+	if !ok || len(base.Body) != 0 || base.Header.Get("Vary") != "Accept-Language" {
+		t.Fatalf("base entry = %+v, %v, want only the Vary list", base, ok)
+	}
+}
+
+func TestMiddlewareCachesEmptyResponse(t *testing.T) {
+	var calls atomic.Int32
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})
+	h := Middleware(newBucket(t), nil)(inner)
+
+	get(h, "/", nil)
+	rec := get(h, "/", nil)
+	if rec.Header().Get(CacheHeader) != "HIT" || rec.Code != http.StatusOK || calls.Load() != 1 {
+		t.Fatalf("second response = %d (%s) after %d calls, want a cached 200",
+			rec.Code, rec.Header().Get(CacheHeader), calls.Load())
+	}
+}
+
+func TestMiddlewareSkipsVaryStar(t *testing.T) {
+	var calls atomic.Int32
+	h := Middleware(newBucket(t), nil)(counting(&calls, http.Header{"Vary": {"*"}}, "x"))
+	get(h, "/", nil)
+	if rec := get(h, "/", nil); rec.Header().Get(CacheHeader) != "MISS" {
+		t.Fatal("Vary: * was cached")
+	}
+}
+
+func TestMiddlewareMaxBodySize(t *testing.T) {
+	body := strings.Repeat("x", 64)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	})
+
+	limited := Middleware(newBucket(t), nil, WithMaxBodySize(16))(handler)
+	get(limited, "/", nil)
+	if rec := get(limited, "/", nil); rec.Header().Get(CacheHeader) != "MISS" {
+		t.Fatal("body over the limit was cached")
+	}
+
+	unlimited := Middleware(newBucket(t), nil, WithMaxBodySize(0))(handler)
+	get(unlimited, "/", nil)
+	if rec := get(unlimited, "/", nil); rec.Header().Get(CacheHeader) != "HIT" || rec.Body.String() != body {
+		t.Fatal("WithMaxBodySize(0) didn't lift the limit")
+	}
+}
+
+func TestMiddlewareForwardsFlusher(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Error("ResponseWriter doesn't implement http.Flusher")
+			return
+		}
+		fmt.Fprint(w, "chunk")
+		f.Flush()
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("ResponseController.Flush = %v", err)
+		}
+	})
+	h := Middleware(newBucket(t), nil)(handler)
+
+	rec := get(h, "/", nil)
+	if !rec.Flushed {
+		t.Fatal("Flush didn't reach the underlying writer")
+	}
+	if rec := get(h, "/", nil); rec.Header().Get(CacheHeader) != "HIT" {
+		t.Fatal("flushed response wasn't cached")
+	}
+}
@@ -0,0 +1,139 @@
+// Package heatwavetest provides heatwave.Cache implementations for tests
+package heatwavetest
+
+import (
+	"sync"
+
+	"github.com/AeaZer/heatwave"
+)
+
+// NopCache is a cache that holds nothing
+// Writes succeed and are dropped, every Bring misses. The zero value is
+// ready to use.
+type NopCache[T any] struct{}
+
+// Nail drops data
+func (NopCache[T]) Nail(string, T, ...heatwave.NailOption) error { return nil }
+
+// Bring always misses
+func (NopCache[T]) Bring(string) (T, bool) {
+	var zero T
+	return zero, false
+}
+
+// Unnail reports that nothing was removed
+func (NopCache[T]) Unnail(string) (bool, error) { return false, nil }
+
+// Size always returns zero
+func (NopCache[T]) Size() int { return 0 }
+
+// Clear does nothing
+func (NopCache[T]) Clear() {}
+
+// Close does nothing
+func (NopCache[T]) Close() error { return nil }
+
+// Method names a Cache method recorded by RecordingCache
+type Method string
+
+// Recorded methods
+const (
+	MethodNail   Method = "Nail"
+	MethodBring  Method = "Bring"
+	MethodUnnail Method = "Unnail"
+	MethodSize   Method = "Size"
+	MethodClear  Method = "Clear"
+	MethodClose  Method = "Close"
+)
+
+// Op is one recorded call
+type Op[T any] struct {
+	Method Method // Method called
+	Key    string // Key passed, empty for Size, Clear and Close
+	Value  T      // Value written by Nail or returned by Bring
+	Found  bool   // Result of Bring and Unnail
+	Size   int    // Result of Size
+	Err    error  // Error returned
+}
+
+// RecordingCache forwards calls to another cache and records them in order
+// It is safe for concurrent use; calls made concurrently are recorded in
+// the order they return.
+type RecordingCache[T any] struct {
+	inner heatwave.Cache[T]
+	mutex sync.Mutex
+	ops   []Op[T]
+}
+
+// NewRecordingCache creates a recording cache in front of inner, usually a
+// real bucket
+func NewRecordingCache[T any](inner heatwave.Cache[T]) *RecordingCache[T] {
+	return &RecordingCache[T]{inner: inner}
+}
+
+// record appends op
+func (c *RecordingCache[T]) record(op Op[T]) {
+	c.mutex.Lock()
+	c.ops = append(c.ops, op)
+	c.mutex.Unlock()
+}
+
+// Nail forwards to the inner cache
+func (c *RecordingCache[T]) Nail(id string, data T, opts ...heatwave.NailOption) error {
+	err := c.inner.Nail(id, data, opts...)
+	c.record(Op[T]{Method: MethodNail, Key: id, Value: data, Err: err})
+	return err
+}
+
+// Bring forwards to the inner cache
+func (c *RecordingCache[T]) Bring(id string) (T, bool) {
+	data, ok := c.inner.Bring(id)
+	c.record(Op[T]{Method: MethodBring, Key: id, Value: data, Found: ok})
+	return data, ok
+}
+
+// Unnail forwards to the inner cache
+func (c *RecordingCache[T]) Unnail(id string) (bool, error) {
+	ok, err := c.inner.Unnail(id)
+	c.record(Op[T]{Method: MethodUnnail, Key: id, Found: ok, Err: err})
+	return ok, err
+}
+
+// Size forwards to the inner cache
+func (c *RecordingCache[T]) Size() int {
+	size := c.inner.Size()
+	c.record(Op[T]{Method: MethodSize, Size: size})
+	return size
+}
+
+// Clear forwards to the inner cache
+func (c *RecordingCache[T]) Clear() {
+	c.inner.Clear()
+	c.record(Op[T]{Method: MethodClear})
+}
+
+// Close forwards to the inner cache
+func (c *RecordingCache[T]) Close() error {
+	err := c.inner.Close()
+	c.record(Op[T]{Method: MethodClose, Err: err})
+	return err
+}
+
+// Ops returns a copy of the calls recorded so far
+func (c *RecordingCache[T]) Ops() []Op[T] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Op[T](nil), c.ops...)
+}
+
+// Reset forgets the recorded calls
+func (c *RecordingCache[T]) Reset() {
+	c.mutex.Lock()
+	c.ops = nil
+	c.mutex.Unlock()
+}
+
+var (
+	_ heatwave.Cache[any] = NopCache[any]{}
+	_ heatwave.Cache[any] = (*RecordingCache[any])(nil)
+)
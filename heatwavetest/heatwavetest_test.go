@@ -0,0 +1,59 @@
+package heatwavetest
+
+import (
+	"testing"
+
+	"github.com/AeaZer/heatwave"
+)
+
+func TestNopCacheMisses(t *testing.T) {
+	var c NopCache[int]
+	if err := c.Nail("a", 1); err != nil {
+		t.Fatalf("Nail = %v, want nil", err)
+	}
+	if _, ok := c.Bring("a"); ok {
+		t.Fatal("Bring hit a NopCache")
+	}
+	if ok, err := c.Unnail("a"); ok || err != nil {
+		t.Fatalf("Unnail = %v, %v, want false, nil", ok, err)
+	}
+	if c.Size() != 0 {
+		t.Fatalf("Size = %d, want 0", c.Size())
+	}
+}
+
+func TestRecordingCacheRecordsCalls(t *testing.T) {
+	c := NewRecordingCache[int](heatwave.NewBucket[int]())
+
+	_ = c.Nail("a", 1)
+	c.Bring("a")
+	c.Bring("missing")
+	_, _ = c.Unnail("a")
+	c.Size()
+	c.Clear()
+	_ = c.Close()
+
+	want := []Op[int]{
+		{Method: MethodNail, Key: "a", Value: 1},
+		{Method: MethodBring, Key: "a", Value: 1, Found: true},
+		{Method: MethodBring, Key: "missing"},
+		{Method: MethodUnnail, Key: "a", Found: true},
+		{Method: MethodSize},
+		{Method: MethodClear},
+		{Method: MethodClose},
+	}
+	got := c.Ops()
+	if len(got) != len(want) {
+		t.Fatalf("recorded %d calls, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("call %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	c.Reset()
+	if ops := c.Ops(); len(ops) != 0 {
+		t.Fatalf("Ops after Reset = %+v, want none", ops)
+	}
+}
@@ -0,0 +1,260 @@
+package heatwave
+
+import (
+	"slices"
+	"time"
+)
+
+// EvictionCandidate returns the item the updater would evict next
+// It is a thin wrapper over OrderedUpdater.Peek and may return an expired
+// item that hasn't been cleaned up yet. It reports false when the bucket is
+// empty, closed, or its updater doesn't implement OrderedUpdater.
+func (b *Bucket[T]) EvictionCandidate() (key string, value T, ok bool) {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	ordered, isOrdered := b.orderedUpdater()
+	if !isOrdered || b.isClosed() {
+		return "", value, false
+	}
+	item := ordered.Peek()
+	if item == nil {
+		return "", value, false
+	}
+	return item.key, b.readValue(item), true
+}
+
+// Oldest returns the live item closest to eviction
+// For LRU this is the least recently used item, for FIFO the earliest
+// inserted one. Access order is not changed.
+func (b *Bucket[T]) Oldest() (key string, value T, ok bool) {
+	return b.firstLive(true)
+}
+
+// Newest returns the live item furthest from eviction
+// For LRU this is the most recently written or read item. For FIFO it is the
+// most recently inserted item; reads and updates of existing keys don't make
+// an item newer under FIFO. Access order is not changed.
+func (b *Bucket[T]) Newest() (key string, value T, ok bool) {
+	return b.firstLive(false)
+}
+
+// firstLive returns the first unexpired item in ascending or descending
+// eviction order
+func (b *Bucket[T]) firstLive(ascending bool) (key string, value T, ok bool) {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	ordered, isOrdered := b.orderedUpdater()
+	if !isOrdered || b.isClosed() {
+		return "", value, false
+	}
+
+	now := b.now()
+	var found *CacheItem[T]
+	visit := func(item *CacheItem[T]) bool {
+		if item.expired(now) {
+			return true
+		}
+		found = item
+		return false
+	}
+	if ascending {
+		ordered.Ascend(visit)
+	} else {
+		ordered.Descend(visit)
+	}
+	if found == nil {
+		return "", value, false
+	}
+	return found.key, b.readValue(found), true
+}
+
+// RangeByAccessOrder calls fn for each live item from the most to the least
+// recently used one, stopping early when fn returns false
+// The order is the updater's Descend order, so for FIFO it is insertion order
+// from newest to oldest. Updaters that don't implement OrderedUpdater return
+// ErrUnordered. fn runs under the read lock and must not call back into the
+// bucket; access order is not changed.
+func (b *Bucket[T]) RangeByAccessOrder(fn func(key string, value T) bool) error {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return ErrBucketClosed
+	}
+	ordered, isOrdered := b.orderedUpdater()
+	if !isOrdered {
+		return ErrUnordered
+	}
+
+	now := b.now()
+	ordered.Descend(func(item *CacheItem[T]) bool {
+		if item.expired(now) {
+			return true
+		}
+		return fn(item.key, b.readValue(item))
+	})
+	return nil
+}
+
+// MostRecent returns up to n keys of live items from the most recently used
+// one down, without changing access order
+// The order is the updater's Descend order, so FIFO buckets return the most
+// recently inserted keys. Updaters that don't implement OrderedUpdater have
+// no recency order and return nil, as does a closed bucket.
+func (b *Bucket[T]) MostRecent(n int) []string {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	ordered, isOrdered := b.orderedUpdater()
+	if n <= 0 || !isOrdered || b.isClosed() {
+		return nil
+	}
+
+	now := b.now()
+	keys := make([]string, 0, min(n, len(b.cache)))
+	ordered.Descend(func(item *CacheItem[T]) bool {
+		if !item.expired(now) {
+			keys = append(keys, item.key)
+		}
+		return len(keys) < n
+	})
+	return keys
+}
+
+// ExpiringWithin returns the keys of live items that expire within d, soonest
+// first
+// Only the front of the expiry heap is visited, so the cost depends on the
+// number of matches rather than the size of the bucket. Items that never
+// expire are not returned, nor are expired ones awaiting cleanup.
+func (b *Bucket[T]) ExpiringWithin(d time.Duration) []string {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if d <= 0 || b.isClosed() {
+		return nil
+	}
+
+	now := b.now()
+	var due []*CacheItem[T]
+	b.scanExpiriesLocked(now.Add(d), func(item *CacheItem[T]) {
+		if item.expiredAt.After(now) {
+			due = append(due, item)
+		}
+	})
+
+	slices.SortFunc(due, func(x, y *CacheItem[T]) int {
+		return x.expiredAt.Compare(*y.expiredAt)
+	})
+	keys := make([]string, len(due))
+	for i, item := range due {
+		keys[i] = item.key
+	}
+	return keys
+}
+
+// ItemInfo describes the metadata of a cached item
+type ItemInfo struct {
+	Key       string
+	CreatedAt time.Time // When the key was first inserted
+	UpdatedAt time.Time // When the value was last written
+	ExpiresAt time.Time // Zero when the item never expires
+	Version   uint64
+}
+
+// ItemInfo returns the metadata of the live item id without marking it as
+// accessed
+// Touch and lazy expiry don't change UpdatedAt, only writes of a value do.
+func (b *Bucket[T]) ItemInfo(id string) (ItemInfo, bool) {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return ItemInfo{}, false
+	}
+	item, exists := b.cache[id]
+	if !exists || item.expired(b.now()) {
+		return ItemInfo{}, false
+	}
+	info := ItemInfo{
+		Key:       item.key,
+		CreatedAt: item.createdAt,
+		UpdatedAt: item.updatedAt,
+		Version:   item.version,
+	}
+	if item.expiredAt != nil {
+		info.ExpiresAt = *item.expiredAt
+	}
+	return info, true
+}
+
+// ExistsMany reports for each of ids whether it holds a live item
+// All keys are checked under a single read lock and access order is not
+// changed.
+func (b *Bucket[T]) ExistsMany(ids []string) map[string]bool {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	out := make(map[string]bool, len(ids))
+	closed := b.isClosed()
+	now := b.now()
+	for _, id := range ids {
+		item, exists := b.cache[id]
+		out[id] = !closed && exists && !item.expired(now)
+	}
+	return out
+}
+
+// Range calls fn for each live item until fn returns false
+// It iterates over a snapshot taken under the read lock, so fn may modify the
+// bucket. The order is unspecified and access order is not changed.
+func (b *Bucket[T]) Range(fn func(key string, value T) bool) {
+	for _, e := range b.snapshot(nil) {
+		if !fn(e.key, e.value) {
+			return
+		}
+	}
+}
+
+// snapshotEntry is a key and value copied out of the bucket
+type snapshotEntry[T any] struct {
+	key   string
+	value T
+}
+
+// snapshot copies the live items accepted by keep, or all live items when
+// keep is nil
+func (b *Bucket[T]) snapshot(keep func(item *CacheItem[T]) bool) []snapshotEntry[T] {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return nil
+	}
+
+	now := b.now()
+	out := make([]snapshotEntry[T], 0, len(b.cache))
+	for key, item := range b.cache {
+		if item.expired(now) || (keep != nil && !keep(item)) {
+			continue
+		}
+		out = append(out, snapshotEntry[T]{key: key, value: b.readValue(item)})
+	}
+	return out
+}
+
+// orderedUpdater returns the updater if it implements OrderedUpdater
+func (b *Bucket[T]) orderedUpdater() (OrderedUpdater[T], bool) {
+	ordered, ok := b.updater.(OrderedUpdater[T])
+	return ordered, ok
+}
+
+// readValue returns the value of item as handed out to callers
+func (b *Bucket[T]) readValue(item *CacheItem[T]) T {
+	value := b.valueOf(item)
+	if b.copyOut != nil {
+		return b.copyOut(value)
+	}
+	return value
+}
@@ -0,0 +1,279 @@
+package heatwave
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOldestNewestLRU(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	for i, key := range []string{"a", "b", "c"} {
+		_ = b.Nail(key, i)
+	}
+	b.Bring("a") // LRU order is now b, c, a
+
+	if key, _, ok := b.Oldest(); !ok || key != "b" {
+		t.Fatalf("Oldest = %q, %v, want b", key, ok)
+	}
+	if key, _, ok := b.Newest(); !ok || key != "a" {
+		t.Fatalf("Newest = %q, %v, want a", key, ok)
+	}
+	if key, _, ok := b.EvictionCandidate(); !ok || key != "b" {
+		t.Fatalf("EvictionCandidate = %q, %v, want b", key, ok)
+	}
+
+	// Inspecting must not have promoted b
+	if key, _, _ := b.Oldest(); key != "b" {
+		t.Fatalf("Oldest after inspection = %q, want b", key)
+	}
+}
+
+func TestOldestNewestFIFO(t *testing.T) {
+	b := NewBucket[int](WithFIFOUpdater[int]())
+	defer b.Close()
+
+	for i, key := range []string{"a", "b", "c"} {
+		_ = b.Nail(key, i)
+	}
+	// Neither reads nor updates make an item newer under FIFO
+	b.Bring("a")
+	_ = b.Nail("a", 10)
+
+	if key, _, ok := b.Oldest(); !ok || key != "a" {
+		t.Fatalf("Oldest = %q, %v, want a", key, ok)
+	}
+	if key, v, ok := b.Newest(); !ok || key != "c" || v != 2 {
+		t.Fatalf("Newest = %q, %d, %v, want c, 2", key, v, ok)
+	}
+}
+
+func TestOldestEmptyAndUnordered(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+	if _, _, ok := b.Oldest(); ok {
+		t.Fatal("Oldest reported an item in an empty bucket")
+	}
+
+	s := NewBucket[int](WithSampledLRUUpdater[int](3))
+	defer s.Close()
+	_ = s.Nail("a", 1)
+	if _, _, ok := s.Newest(); ok {
+		t.Fatal("Newest reported an order for an unordered updater")
+	}
+}
+
+func TestMostRecent(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		_ = b.Nail(key, i)
+	}
+	_ = b.NailWithTTL("expired", 0, time.Second)
+	clock.Advance(2 * time.Second)
+	_, _ = b.Bring("b")
+	_, _ = b.Bring("a")
+
+	if got := strings.Join(b.MostRecent(3), ","); got != "a,b,d" {
+		t.Fatalf("MostRecent(3) = %s, want a,b,d", got)
+	}
+	// Asking doesn't promote anything, and expired items are skipped
+	if got := strings.Join(b.MostRecent(10), ","); got != "a,b,d,c" {
+		t.Fatalf("MostRecent(10) = %s, want a,b,d,c", got)
+	}
+	if got := b.MostRecent(0); got != nil {
+		t.Fatalf("MostRecent(0) = %v, want nil", got)
+	}
+
+	s := NewBucket[int](WithSampledLRUUpdater[int](3))
+	defer s.Close()
+	_ = s.Nail("a", 1)
+	if got := s.MostRecent(1); got != nil {
+		t.Fatalf("MostRecent = %v for an unordered updater, want nil", got)
+	}
+}
+
+func TestExpiringWithin(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	_ = b.NailWithTTL("expired", 0, time.Second)
+	clock.Advance(2 * time.Second)
+	_ = b.NailWithTTL("30s", 0, 30*time.Second)
+	_ = b.NailWithTTL("10s", 0, 10*time.Second)
+	_ = b.NailWithTTL("20s", 0, 20*time.Second)
+	_ = b.NailWithTTL("2m", 0, 2*time.Minute)
+	_ = b.Nail("forever", 0)
+
+	if got := strings.Join(b.ExpiringWithin(25*time.Second), ","); got != "10s,20s" {
+		t.Fatalf("ExpiringWithin(25s) = %s, want 10s,20s", got)
+	}
+	clock.Advance(15 * time.Second)
+	if got := strings.Join(b.ExpiringWithin(time.Minute), ","); got != "20s,30s" {
+		t.Fatalf("ExpiringWithin(1m) 15s later = %s, want 20s,30s", got)
+	}
+	if got := b.ExpiringWithin(0); got != nil {
+		t.Fatalf("ExpiringWithin(0) = %v, want nil", got)
+	}
+}
+
+func TestRangeByAccessOrder(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		_ = b.Nail(key, i)
+	}
+	b.Bring("b")
+	b.Bring("a")
+	_ = b.Nail("c", 10)
+
+	var keys []string
+	err := b.RangeByAccessOrder(func(key string, value int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"c", "a", "b", "d"}
+	if len(keys) != len(want) {
+		t.Fatalf("order = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("order = %v, want %v", keys, want)
+		}
+	}
+
+	// Ranging neither promotes items nor continues after fn returns false
+	keys = nil
+	_ = b.RangeByAccessOrder(func(key string, value int) bool {
+		keys = append(keys, key)
+		return len(keys) < 2
+	})
+	if len(keys) != 2 || keys[0] != "c" || keys[1] != "a" {
+		t.Fatalf("stopped range = %v, want [c a]", keys)
+	}
+}
+
+func TestRangeByAccessOrderUnordered(t *testing.T) {
+	b := NewBucket[int](WithSampledLRUUpdater[int](3))
+
+	_ = b.Nail("a", 1)
+	err := b.RangeByAccessOrder(func(key string, value int) bool { return true })
+	if !errors.Is(err, ErrUnordered) {
+		t.Fatalf("RangeByAccessOrder = %v, want ErrUnordered", err)
+	}
+
+	_ = b.Close()
+	if err := b.RangeByAccessOrder(func(string, int) bool { return true }); !errors.Is(err, ErrBucketClosed) {
+		t.Fatalf("RangeByAccessOrder on a closed bucket = %v, want ErrBucketClosed", err)
+	}
+}
+
+func TestItemInfoTimestamps(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := NewManualClock(start)
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithBucketExpire[int](time.Minute))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	info, ok := b.ItemInfo("a")
+	if !ok || !info.CreatedAt.Equal(start) || !info.UpdatedAt.Equal(start) {
+		t.Fatalf("ItemInfo after insert = %+v, %v", info, ok)
+	}
+
+	// Reads and expiry changes leave both timestamps alone
+	clock.Advance(10 * time.Second)
+	b.Bring("a")
+	b.Touch("a")
+	b.BringAndExtend("a", time.Hour)
+	if got, _ := b.ItemInfo("a"); !got.CreatedAt.Equal(start) || !got.UpdatedAt.Equal(start) {
+		t.Fatalf("ItemInfo after reads and touches = %+v", got)
+	}
+
+	// An overwrite only moves UpdatedAt
+	clock.Advance(10 * time.Second)
+	_ = b.Nail("a", 2, KeepExpiry())
+	updated := start.Add(20 * time.Second)
+	if got, _ := b.ItemInfo("a"); !got.CreatedAt.Equal(start) || !got.UpdatedAt.Equal(updated) {
+		t.Fatalf("ItemInfo after an update = %+v, want created %v, updated %v", got, start, updated)
+	}
+
+	// An item removed by lazy expiry is inserted afresh
+	_ = b.NailWithTTL("e", 1, time.Second)
+	clock.Advance(2 * time.Second)
+	b.Bring("e")
+	_ = b.Nail("e", 2)
+	now := clock.Now()
+	if got, _ := b.ItemInfo("e"); !got.CreatedAt.Equal(now) || !got.UpdatedAt.Equal(now) {
+		t.Fatalf("ItemInfo after re-inserting an expired key = %+v, want both %v", got, now)
+	}
+}
+
+func TestItemInfoTimestampsSurviveSnapshot(t *testing.T) {
+	clock := NewManualClock(time.Unix(1000, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	clock.Advance(time.Minute)
+	_ = b.Nail("a", 2)
+	want, _ := b.ItemInfo("a")
+
+	var buf bytes.Buffer
+	if err := b.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	r := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer r.Close()
+	if _, err := r.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := r.ItemInfo("a")
+	if !ok || !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Fatalf("restored ItemInfo = %+v, want timestamps of %+v", got, want)
+	}
+}
+
+func TestExistsMany(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_ = b.NailWithTTL("expired", 3, time.Second)
+	clock.Advance(2 * time.Second)
+
+	got := b.ExistsMany([]string{"a", "b", "expired", "missing"})
+	want := map[string]bool{"a": true, "b": true, "expired": false, "missing": false}
+	if len(got) != len(want) {
+		t.Fatalf("ExistsMany = %v, want %v", got, want)
+	}
+	for key, w := range want {
+		if got[key] != w {
+			t.Fatalf("ExistsMany = %v, want %v", got, want)
+		}
+	}
+
+	// The check doesn't promote a
+	b.ExistsMany([]string{"a"})
+	if key, _, _ := b.Oldest(); key != "a" {
+		t.Fatalf("Oldest = %q after ExistsMany, want a", key)
+	}
+
+	_ = b.Close()
+	if got := b.ExistsMany([]string{"a"}); got["a"] {
+		t.Fatal("ExistsMany reported a key of a closed bucket")
+	}
+}
@@ -0,0 +1,55 @@
+package heatwave
+
+import "time"
+
+// Hooks are the callbacks Instrument runs around cache calls, nil ones are
+// skipped
+// Hooks run on the calling goroutine outside any bucket lock.
+type Hooks[T any] struct {
+	BeforeNail  func(key string, data T)
+	AfterNail   func(key string, elapsed time.Duration, err error)
+	BeforeBring func(key string)
+	AfterBring  func(key string, elapsed time.Duration, found bool)
+}
+
+// instrumented runs hooks around an inner cache
+type instrumented[T any] struct {
+	Cache[T]
+	hooks Hooks[T]
+}
+
+// Instrument wraps c so that hooks observe its Nail and Bring calls
+// Results are passed through untouched. The clock is only read when an
+// After hook is set, so unset hooks cost nothing. Any Cache can be wrapped,
+// including another instrumented one.
+func Instrument[T any](c Cache[T], hooks Hooks[T]) Cache[T] {
+	return &instrumented[T]{Cache: c, hooks: hooks}
+}
+
+// Nail forwards to the inner cache between the nail hooks
+func (c *instrumented[T]) Nail(id string, data T, opts ...NailOption) error {
+	if c.hooks.BeforeNail != nil {
+		c.hooks.BeforeNail(id, data)
+	}
+	if c.hooks.AfterNail == nil {
+		return c.Cache.Nail(id, data, opts...)
+	}
+	start := time.Now()
+	err := c.Cache.Nail(id, data, opts...)
+	c.hooks.AfterNail(id, time.Since(start), err)
+	return err
+}
+
+// Bring forwards to the inner cache between the bring hooks
+func (c *instrumented[T]) Bring(id string) (T, bool) {
+	if c.hooks.BeforeBring != nil {
+		c.hooks.BeforeBring(id)
+	}
+	if c.hooks.AfterBring == nil {
+		return c.Cache.Bring(id)
+	}
+	start := time.Now()
+	data, ok := c.Cache.Bring(id)
+	c.hooks.AfterBring(id, time.Since(start), ok)
+	return data, ok
+}
@@ -0,0 +1,103 @@
+package heatwave
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// stubCache answers every call with fixed results
+type stubCache struct {
+	Cache[int]
+	err   error
+	value int
+	found bool
+	nails int
+}
+
+func (s *stubCache) Nail(id string, data int, opts ...NailOption) error {
+	s.nails++
+	return s.err
+}
+
+func (s *stubCache) Bring(id string) (int, bool) {
+	return s.value, s.found
+}
+
+func TestInstrumentPassesThrough(t *testing.T) {
+	errFull := errors.New("full")
+	inner := &stubCache{err: errFull, value: 7, found: true}
+
+	var calls []string
+	c := Instrument[int](inner, Hooks[int]{
+		BeforeNail: func(key string, data int) { calls = append(calls, "before nail "+key) },
+		AfterNail: func(key string, elapsed time.Duration, err error) {
+			if !errors.Is(err, errFull) {
+				t.Errorf("AfterNail err = %v, want the inner error", err)
+			}
+			calls = append(calls, "after nail "+key)
+		},
+		BeforeBring: func(key string) { calls = append(calls, "before bring "+key) },
+		AfterBring: func(key string, elapsed time.Duration, found bool) {
+			if !found {
+				t.Error("AfterBring found = false, want the inner result")
+			}
+			calls = append(calls, "after bring "+key)
+		},
+	})
+
+	if err := c.Nail("a", 1); !errors.Is(err, errFull) {
+		t.Fatalf("Nail = %v, want the inner error", err)
+	}
+	if v, ok := c.Bring("a"); v != 7 || !ok {
+		t.Fatalf("Bring = %d, %v, want 7, true", v, ok)
+	}
+	want := []string{"before nail a", "after nail a", "before bring a", "after bring a"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("hooks ran as %v, want %v", calls, want)
+	}
+	if inner.nails != 1 {
+		t.Fatalf("inner Nail called %d times, want 1", inner.nails)
+	}
+}
+
+func TestInstrumentBucket(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	var found []bool
+	c := Instrument[int](b, Hooks[int]{
+		AfterBring: func(key string, elapsed time.Duration, ok bool) { found = append(found, ok) },
+	})
+	// Instrumented caches can be wrapped again
+	c = Instrument(c, Hooks[int]{})
+
+	if err := c.Nail("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Bring("a"); v != 1 || !ok {
+		t.Fatalf("Bring(a) = %d, %v", v, ok)
+	}
+	if _, ok := c.Bring("b"); ok {
+		t.Fatal("Bring(b) found a missing key")
+	}
+	if !slices.Equal(found, []bool{true, false}) {
+		t.Fatalf("AfterBring saw %v, want [true false]", found)
+	}
+	if c.Size() != 1 {
+		t.Fatalf("Size = %d through the wrapper, want 1", c.Size())
+	}
+}
+
+func TestInstrumentNilHooksDontAllocate(t *testing.T) {
+	c := Instrument[int](&stubCache{value: 1, found: true}, Hooks[int]{})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = c.Nail("a", 1)
+		_, _ = c.Bring("a")
+	})
+	if allocs != 0 {
+		t.Fatalf("%v allocations per call with nil hooks, want 0", allocs)
+	}
+}
@@ -0,0 +1,115 @@
+package heatwave
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DebugMode makes every bucket check its invariants whenever it releases the
+// write lock and panic with the violations
+// The check walks the whole bucket, so it is meant for tests of custom
+// updaters only. Buckets read it once when they are created, so changing it
+// only affects buckets created afterwards.
+var DebugMode bool
+
+// maxInvariantErrors caps the violations CheckInvariants reports
+const maxInvariantErrors = 20
+
+// InvariantError describes one inconsistency found by CheckInvariants
+type InvariantError struct {
+	Key     string // Offending key, empty for bucket-wide problems
+	Problem string
+}
+
+// Error implements error
+func (e *InvariantError) Error() string {
+	if e.Key == "" {
+		return "heatwave: invariant violated: " + e.Problem
+	}
+	return fmt.Sprintf("heatwave: invariant violated for %q: %s", e.Key, e.Problem)
+}
+
+// Is makes errors.Is(err, ErrInvariant) succeed
+func (e *InvariantError) Is(target error) bool {
+	return target == ErrInvariant
+}
+
+// CheckInvariants verifies that the bucket map, the updater and the expiry
+// schedule agree and returns the violations joined into one error, nil if
+// there are none
+// It checks that the updater holds as many items as the map; for ordered
+// updaters, that their order lists every item exactly once; that every item
+// with a deadline is scheduled; and, while the cleanup goroutine runs on the
+// wall clock, that no item stayed expired for more than a cleanup interval.
+// At most 20 violations are reported. It holds the read lock for a full walk
+// of the bucket.
+func (b *Bucket[T]) CheckInvariants() error {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return nil
+	}
+	return b.checkInvariantsLocked()
+}
+
+// checkInvariantsLocked implements CheckInvariants
+// Must be called with b.mutex held
+func (b *Bucket[T]) checkInvariantsLocked() error {
+	var errs []error
+	report := func(key, format string, args ...any) bool {
+		if len(errs) == maxInvariantErrors {
+			return false
+		}
+		errs = append(errs, &InvariantError{Key: key, Problem: fmt.Sprintf(format, args...)})
+		return true
+	}
+
+	if size := b.updater.Size(); size != len(b.cache) {
+		report("", "updater holds %d items, map holds %d", size, len(b.cache))
+	}
+
+	if ordered, ok := b.orderedUpdater(); ok {
+		seen := make(map[*CacheItem[T]]bool, len(b.cache))
+		ordered.Ascend(func(item *CacheItem[T]) bool {
+			switch {
+			case seen[item]:
+				return report(item.key, "listed twice in the eviction order")
+			case b.cache[item.key] != item:
+				seen[item] = true
+				return report(item.key, "in the eviction order but not in the map")
+			}
+			seen[item] = true
+			return true
+		})
+		for key, item := range b.cache {
+			if !seen[item] && !report(key, "in the map but not in the eviction order") {
+				break
+			}
+		}
+	}
+
+	now := b.now()
+	staleChecked := b.clock == nil && b.cleanupRunning.Load() && !b.cleanupIsPaused() && !b.frozen.Load()
+	for key, item := range b.cache {
+		// Expired items may be off the heap while an interceptor decides
+		if item.expiredAt != nil && item.heapIndex == 0 && !item.expired(now) &&
+			!report(key, "has a deadline but isn't scheduled") {
+			break
+		}
+		if staleChecked && item.expiredAt != nil && now.Sub(*item.expiredAt) > b.cleanupInterval &&
+			!report(key, "expired %s ago but wasn't cleaned up", now.Sub(*item.expiredAt)) {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// debugCheckLocked runs the DebugMode check, must be called with b.mutex
+// held
+func (b *Bucket[T]) debugCheckLocked() error {
+	if !b.debug || b.isClosed() {
+		return nil
+	}
+	return b.checkInvariantsLocked()
+}
@@ -0,0 +1,116 @@
+package heatwave
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lossyUpdater forgets to track one key, the bug CheckInvariants is for
+type lossyUpdater[T any] struct {
+	*lru[T]
+	lose string
+}
+
+func (u *lossyUpdater[T]) Add(item *CacheItem[T]) {
+	if item.key != u.lose {
+		u.lru.Add(item)
+	}
+}
+
+func TestCheckInvariantsReportsOrphans(t *testing.T) {
+	b := NewBucket[int](WithUpdater[int](&lossyUpdater[int]{lru: newLRUUpdater[int](), lose: "b"}))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	if err := b.CheckInvariants(); err != nil {
+		t.Fatalf("CheckInvariants = %v for a consistent bucket", err)
+	}
+	_ = b.Nail("b", 2)
+
+	err := b.CheckInvariants()
+	if !errors.Is(err, ErrInvariant) {
+		t.Fatalf("CheckInvariants = %v, want ErrInvariant", err)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "updater holds 1 items, map holds 2") || !strings.Contains(msg, `"b": in the map but not in the eviction order`) {
+		t.Fatalf("CheckInvariants = %q, want the size mismatch and the orphaned key", msg)
+	}
+}
+
+func TestDebugModePanics(t *testing.T) {
+	DebugMode = true
+	defer func() { DebugMode = false }()
+
+	b := NewBucket[int](WithUpdater[int](&lossyUpdater[int]{lru: newLRUUpdater[int](), lose: "b"}))
+	defer b.Close()
+	_ = b.Nail("a", 1)
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrInvariant) {
+			t.Fatalf("Nail panicked with %v, want an ErrInvariant error", err)
+		}
+	}()
+	_ = b.Nail("b", 2)
+	t.Fatal("DebugMode didn't catch the orphaned key")
+}
+
+func TestInvariantsStress(t *testing.T) {
+	DebugMode = true
+	defer func() { DebugMode = false }()
+
+	strategies := map[string][]NewBucketOption[int]{
+		"LRU":         nil,
+		"FIFO":        {WithFIFOUpdater[int]()},
+		"SampledLRU":  {WithSampledLRUUpdater[int](3)},
+		"DecayingLFU": {WithDecayingLFUUpdater[int](time.Minute)},
+		"Scored": {WithScoredEviction[int](func(item ItemView[int]) float64 {
+			return float64(item.Value)
+		}, 3)},
+	}
+	for name, opts := range strategies {
+		t.Run(name, func(t *testing.T) {
+			clock := NewManualClock(time.Unix(0, 0))
+			opts := append([]NewBucketOption[int]{
+				WithClock[int](clock),
+				WithCleanupDisabled[int](),
+				WithMaxSize[int](50),
+				WithDeterministic[int](1),
+			}, opts...)
+			b := NewBucket[int](opts...)
+			defer b.Close()
+
+			rng := rand.New(rand.NewPCG(1, 2))
+			for i := 0; i < 20_000; i++ {
+				key := strconv.Itoa(rng.IntN(120))
+				switch op := rng.IntN(100); {
+				case op < 35:
+					_ = b.Nail(key, rng.IntN(1000))
+				case op < 50:
+					_ = b.NailWithTTL(key, rng.IntN(1000), time.Duration(1+rng.IntN(10))*time.Second)
+				case op < 55:
+					_ = b.NailWithPriority(key, rng.IntN(1000), rng.IntN(3))
+				case op < 75:
+					_, _ = b.Bring(key)
+				case op < 82:
+					_, _ = b.Unnail(key)
+				case op < 88:
+					b.Touch(key)
+				case op < 95:
+					clock.Advance(time.Duration(rng.IntN(2000)) * time.Millisecond)
+				case op < 99:
+					b.CleanupNow()
+				default:
+					b.Clear()
+				}
+			}
+			if err := b.CheckInvariants(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+//go:build go1.23
+
+package heatwave
+
+import (
+	"iter"
+	"time"
+)
+
+// All returns an iterator over the live items
+// Like Range it iterates over a snapshot taken under the read lock, so the
+// loop body may modify the bucket.
+func (b *Bucket[T]) All() iter.Seq2[string, T] {
+	return b.seq(nil)
+}
+
+// Keys returns an iterator over the keys of the live items
+// Only the keys are copied, values are never read.
+func (b *Bucket[T]) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, key := range b.snapshotKeys() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// ExpiringBefore returns an iterator over the live items that expire before t
+func (b *Bucket[T]) ExpiringBefore(t time.Time) iter.Seq2[string, T] {
+	return b.seq(func(item *CacheItem[T]) bool {
+		return item.expiredAt != nil && item.expiredAt.Before(t)
+	})
+}
+
+// seq returns an iterator over a snapshot of the items accepted by keep
+// The snapshot is taken when iteration starts, not when seq is called.
+func (b *Bucket[T]) seq(keep func(item *CacheItem[T]) bool) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		for _, e := range b.snapshot(keep) {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
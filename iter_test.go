@@ -0,0 +1,118 @@
+//go:build go1.23
+
+package heatwave
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAll(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	for i := 0; i < 3; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+	_ = b.NailWithTTL("expired", 9, time.Second)
+	clock.Advance(2 * time.Second)
+
+	got := make(map[string]int)
+	for key, value := range b.All() {
+		got[key] = value
+	}
+	if len(got) != 3 || got["0"] != 0 || got["1"] != 1 || got["2"] != 2 {
+		t.Fatalf("All = %v, want the three live items", got)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	reads := 0
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](),
+		WithValueCopier(func(v int) int { reads++; return v }, CopyOnRead))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_ = b.NailWithTTL("expired", 3, time.Second)
+	clock.Advance(2 * time.Second)
+
+	keys := slices.Sorted(b.Keys())
+	if !slices.Equal(keys, []string{"a", "b"}) {
+		t.Fatalf("Keys = %v, want [a b]", keys)
+	}
+	if reads != 0 {
+		t.Fatalf("Keys read %d values, want none", reads)
+	}
+}
+
+func TestExpiringBefore(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	_ = b.NailWithTTL("10s", 1, 10*time.Second)
+	_ = b.NailWithTTL("1m", 2, time.Minute)
+	_ = b.NailWithTTL("1h", 3, time.Hour)
+	_ = b.NailWithTTL("expired", 4, time.Second)
+	clock.Advance(2 * time.Second)
+
+	var keys []string
+	for key := range b.ExpiringBefore(clock.Now().Add(5 * time.Minute)) {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"10s", "1m"}) {
+		t.Fatalf("ExpiringBefore = %v, want [10s 1m]", keys)
+	}
+}
+
+func TestIteratorsBreakAndModify(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+	for i := 0; i < 10; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+
+	// Breaking out stops the iteration
+	n := 0
+	for range b.All() {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	if n != 3 {
+		t.Fatalf("All yielded %d items after break, want 3", n)
+	}
+	n = 0
+	for range b.Keys() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Fatalf("Keys yielded %d keys after break, want 1", n)
+	}
+
+	// The loop body may write to the bucket without deadlocking, and sees
+	// the snapshot taken when the loop started
+	seen := 0
+	for key, value := range b.All() {
+		seen++
+		_, _ = b.Unnail(key)
+		_ = b.Nail("new"+key, value)
+	}
+	for key := range b.Keys() {
+		_ = b.Nail(key, -1)
+	}
+	if seen != 10 || b.Size() != 10 {
+		t.Fatalf("visited %d items and left %d, want 10 and 10", seen, b.Size())
+	}
+	if v, _ := b.Bring("new0"); v != -1 {
+		t.Fatalf("new0 = %d, want -1", v)
+	}
+}
@@ -0,0 +1,157 @@
+package heatwave
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// histogramBuckets is the number of power-of-two nanosecond buckets
+const histogramBuckets = 64
+
+// LatencySummary describes the latency distribution of one operation
+// Percentiles are upper bounds of power-of-two buckets, so they are accurate
+// to within a factor of two.
+type LatencySummary struct {
+	Count uint64
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// LatencyStats holds latency summaries per operation
+type LatencyStats struct {
+	Nail    LatencySummary
+	Bring   LatencySummary
+	Cleanup LatencySummary
+}
+
+// histogram is a lock-free log-scale latency histogram
+// Bucket i counts durations whose nanosecond value has bit length i.
+type histogram struct {
+	buckets [histogramBuckets]atomic.Uint64
+}
+
+// record adds a duration to the histogram without allocating
+func (h *histogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.buckets[min(bits.Len64(uint64(d)), histogramBuckets-1)].Add(1)
+}
+
+// observe records the time elapsed since start
+func (h *histogram) observe(start time.Time) {
+	h.record(time.Since(start))
+}
+
+// reset zeroes all buckets
+func (h *histogram) reset() {
+	for i := range h.buckets {
+		h.buckets[i].Store(0)
+	}
+}
+
+// summary computes the count and percentiles of the histogram
+func (h *histogram) summary() LatencySummary {
+	var counts [histogramBuckets]uint64
+	var total uint64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+		total += counts[i]
+	}
+	return LatencySummary{
+		Count: total,
+		P50:   percentile(&counts, total, 0.50),
+		P95:   percentile(&counts, total, 0.95),
+		P99:   percentile(&counts, total, 0.99),
+	}
+}
+
+// percentile returns the upper bound of the bucket holding the p-th quantile
+func percentile(counts *[histogramBuckets]uint64, total uint64, p float64) time.Duration {
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(p * float64(total))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range counts {
+		seen += c
+		if seen >= rank {
+			if i == 0 {
+				return 0
+			}
+			return time.Duration(uint64(1)<<i - 1)
+		}
+	}
+	return time.Duration(1<<(histogramBuckets-1) - 1)
+}
+
+// latencyHistograms groups the histograms of a bucket
+type latencyHistograms struct {
+	nail    histogram
+	bring   histogram
+	cleanup histogram
+}
+
+// reset zeroes every histogram
+func (l *latencyHistograms) reset() {
+	l.nail.reset()
+	l.bring.reset()
+	l.cleanup.reset()
+}
+
+// LatencyStats returns latency percentiles for Nail, Bring and cleanup
+// It returns zero values unless WithLatencyTracking is enabled.
+func (b *Bucket[T]) LatencyStats() LatencyStats {
+	if b.latency == nil {
+		return LatencyStats{}
+	}
+	return LatencyStats{
+		Nail:    b.latency.nail.summary(),
+		Bring:   b.latency.bring.summary(),
+		Cleanup: b.latency.cleanup.summary(),
+	}
+}
+
+// timed reports whether operations need to be timed
+func (b *Bucket[T]) timed() bool {
+	return b.latencyMetrics || b.latency != nil
+}
+
+// observeNail records a write that started at start
+func (b *Bucket[T]) observeNail(start time.Time) {
+	d := time.Since(start)
+	if b.latencyMetrics {
+		b.counters.addNail(d)
+	}
+	if b.latency != nil {
+		b.latency.nail.record(d)
+	}
+}
+
+// observeBring records a Bring call that started at start
+func (b *Bucket[T]) observeBring(start time.Time) {
+	d := time.Since(start)
+	if b.latencyMetrics {
+		b.counters.addBring(d)
+	}
+	if b.latency != nil {
+		b.latency.bring.record(d)
+	}
+}
+
+// WithLatencyTracking records Nail, Bring and cleanup durations in histograms
+// Every Nail variant and Txn commit is recorded as a Nail. Recording uses
+// atomics only and doesn't allocate. See LatencyStats.
+func WithLatencyTracking[T any](enabled bool) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.latency = nil
+		if enabled {
+			b.latency = &latencyHistograms{}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogramPercentiles(t *testing.T) {
+	var h histogram
+	for i := 0; i < 90; i++ {
+		h.record(100 * time.Nanosecond)
+	}
+	for i := 0; i < 10; i++ {
+		h.record(time.Millisecond)
+	}
+
+	s := h.summary()
+	if s.Count != 100 {
+		t.Fatalf("Count = %d, want 100", s.Count)
+	}
+	// Percentiles are power-of-two upper bounds, accurate within a factor of two
+	if s.P50 < 100*time.Nanosecond || s.P50 >= 200*time.Nanosecond {
+		t.Fatalf("P50 = %v, want within [100ns, 200ns)", s.P50)
+	}
+	if s.P95 < time.Millisecond || s.P95 >= 2*time.Millisecond {
+		t.Fatalf("P95 = %v, want within [1ms, 2ms)", s.P95)
+	}
+	if s.P99 != s.P95 {
+		t.Fatalf("P99 = %v, want %v", s.P99, s.P95)
+	}
+}
+
+func TestLatencyTracking(t *testing.T) {
+	b := NewBucket[int](WithLatencyTracking[int](true))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.NailWithTTL("b", 2, time.Minute)
+	_ = b.NailUntil("c", 3, time.Now().Add(time.Minute))
+	_ = b.Txn(func(tx *Tx[int]) error {
+		return tx.Set("d", 4)
+	})
+	b.Bring("a")
+	b.CleanupNow()
+
+	s := b.LatencyStats()
+	if s.Nail.Count != 4 {
+		t.Fatalf("Nail count = %d, want 4", s.Nail.Count)
+	}
+	if s.Bring.Count != 1 {
+		t.Fatalf("Bring count = %d, want 1", s.Bring.Count)
+	}
+	if s.Cleanup.Count != 1 {
+		t.Fatalf("Cleanup count = %d, want 1", s.Cleanup.Count)
+	}
+
+	b.ResetStats()
+	if s := b.LatencyStats(); s.Nail.Count != 0 || s.Bring.Count != 0 || s.Cleanup.Count != 0 {
+		t.Fatalf("LatencyStats after ResetStats = %+v", s)
+	}
+}
+
+func TestLatencyTrackingDisabled(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	if s := b.LatencyStats(); s != (LatencyStats{}) {
+		t.Fatalf("LatencyStats = %+v, want zero", s)
+	}
+}
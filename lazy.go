@@ -0,0 +1,144 @@
+package heatwave
+
+import "sync"
+
+// LazyBucket stores producers and materializes their values on first read
+// A value is produced at most once per SetLazy, even with concurrent Gets,
+// and never when its key isn't read. Once produced it is stored in the
+// underlying bucket and is subject to its TTL and eviction like any other
+// value. A producer error is returned to the caller and the producer stays
+// pending, so the next Get tries again. A value the bucket refuses to store
+// is still returned and kept with its producer, which then doesn't run again.
+type LazyBucket[T any] struct {
+	bucket *Bucket[T]
+	mutex  sync.Mutex
+	thunks map[string]*thunk[T]
+}
+
+// thunk is a pending producer
+type thunk[T any] struct {
+	producer func() (T, error)
+	opts     []NailOption
+	mutex    sync.Mutex // Held while the producer runs
+	done     bool
+	value    T
+}
+
+// NewLazyBucket creates a lazy bucket whose values are stored in a bucket
+// built from opts
+func NewLazyBucket[T any](opts ...NewBucketOption[T]) *LazyBucket[T] {
+	return &LazyBucket[T]{
+		bucket: NewBucket[T](opts...),
+		thunks: make(map[string]*thunk[T]),
+	}
+}
+
+// SetLazy stores producer for id, replacing any value or producer stored
+// before
+// opts are applied when the value is stored, so a TTL counts from the first
+// Get rather than from SetLazy.
+func (lb *LazyBucket[T]) SetLazy(id string, producer func() (T, error), opts ...NailOption) error {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	if _, err := lb.bucket.Unnail(id); err != nil {
+		return err
+	}
+	lb.thunks[id] = &thunk[T]{producer: producer, opts: opts}
+	return nil
+}
+
+// Set stores data for id right away, dropping a pending producer
+func (lb *LazyBucket[T]) Set(id string, data T, opts ...NailOption) error {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	delete(lb.thunks, id)
+	return lb.bucket.Nail(id, data, opts...)
+}
+
+// Get returns the value for id, running its producer if it hasn't run yet
+// ErrNotFound is returned when id holds neither a value nor a producer.
+func (lb *LazyBucket[T]) Get(id string) (T, error) {
+	lb.mutex.Lock()
+	t := lb.thunks[id]
+	lb.mutex.Unlock()
+
+	if t == nil {
+		data, ok := lb.bucket.Bring(id)
+		if !ok {
+			return data, ErrNotFound
+		}
+		return data, nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.done {
+		// Produced by a concurrent Get while this one waited
+		return t.value, nil
+	}
+	value, err := t.producer()
+	if err != nil {
+		return value, err
+	}
+	t.value, t.done = value, true
+
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	if lb.thunks[id] != t {
+		// Replaced while producing, the newer write wins
+		return value, nil
+	}
+	if err := lb.bucket.Nail(id, value, t.opts...); err != nil {
+		// The value is good even if the bucket refused it, so keep serving
+		// it from the thunk
+		lb.bucket.log(LogWarn, "keeping lazy value out of the bucket", "key", id, "err", err)
+		return value, nil
+	}
+	delete(lb.thunks, id)
+	return value, nil
+}
+
+// Unnail removes the value or pending producer for id and reports whether
+// there was one
+func (lb *LazyBucket[T]) Unnail(id string) (bool, error) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	_, pending := lb.thunks[id]
+	delete(lb.thunks, id)
+	removed, err := lb.bucket.Unnail(id)
+	return pending || removed, err
+}
+
+// Pending returns the number of values held outside the bucket: producers
+// that haven't run yet and values the bucket refused to store
+func (lb *LazyBucket[T]) Pending() int {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	return len(lb.thunks)
+}
+
+// Size returns the number of materialized values plus pending producers
+func (lb *LazyBucket[T]) Size() int {
+	return lb.bucket.Size() + lb.Pending()
+}
+
+// Clear removes all values and pending producers
+func (lb *LazyBucket[T]) Clear() {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	clear(lb.thunks)
+	lb.bucket.Clear()
+}
+
+// Close drops the pending producers and closes the underlying bucket
+func (lb *LazyBucket[T]) Close() error {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	clear(lb.thunks)
+	return lb.bucket.Close()
+}
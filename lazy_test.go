@@ -0,0 +1,97 @@
+package heatwave
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazyProducerRunsOnce(t *testing.T) {
+	lb := NewLazyBucket[int]()
+	defer lb.Close()
+
+	var calls, unread atomic.Int32
+	_ = lb.SetLazy("a", func() (int, error) {
+		calls.Add(1)
+		return 42, nil
+	})
+	_ = lb.SetLazy("unread", func() (int, error) {
+		unread.Add(1)
+		return 0, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := lb.Get("a"); err != nil || v != 42 {
+				t.Errorf("Get = %d, %v, want 42, nil", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, err := lb.Get("a"); err != nil || v != 42 {
+		t.Fatalf("Get after materializing = %d, %v, want 42, nil", v, err)
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("producer ran %d times, want once", n)
+	}
+	if n := unread.Load(); n != 0 {
+		t.Fatalf("producer of an unread key ran %d times", n)
+	}
+	if n := lb.Pending(); n != 1 {
+		t.Fatalf("Pending = %d, want only the unread producer", n)
+	}
+	if _, err := lb.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLazyProducerErrorRetries(t *testing.T) {
+	lb := NewLazyBucket[int]()
+	defer lb.Close()
+
+	calls := 0
+	_ = lb.SetLazy("a", func() (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("down")
+		}
+		return 7, nil
+	})
+	if _, err := lb.Get("a"); err == nil {
+		t.Fatal("Get = nil error for a failing producer")
+	}
+	if v, err := lb.Get("a"); err != nil || v != 7 {
+		t.Fatalf("second Get = %d, %v, want 7, nil", v, err)
+	}
+}
+
+func TestLazyValueKeptWhenBucketRefusesIt(t *testing.T) {
+	lb := NewLazyBucket[int](WithMaxSize[int](1), WithOverflowPolicy[int](OverflowReject))
+	defer lb.Close()
+
+	_ = lb.Set("full", 0)
+	calls := 0
+	_ = lb.SetLazy("a", func() (int, error) {
+		calls++
+		return 42, nil
+	})
+
+	// The bucket is full, yet the produced value is returned and served again
+	// without rerunning the producer
+	for i := 0; i < 2; i++ {
+		if v, err := lb.Get("a"); err != nil || v != 42 {
+			t.Fatalf("Get = %d, %v, want 42, nil", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("producer ran %d times, want once", calls)
+	}
+	if n := lb.Pending(); n != 1 {
+		t.Fatalf("Pending = %d, want the refused value kept", n)
+	}
+}
@@ -0,0 +1,131 @@
+package heatwave
+
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+// lfuEntry tracks the decaying frequency score of an item
+// The score is stored together with the time (in half-lives) it was last
+// updated. Because every score decays at the same rate, comparing
+// log2(score) + stamp orders items by their current decayed score without
+// ever having to touch idle entries.
+type lfuEntry[T any] struct {
+	item     *CacheItem[T]
+	score    float64 // Frequency score as of stamp
+	stamp    float64 // Half-lives elapsed since the updater was created
+	priority float64 // log2(score) + stamp, smallest is evicted first
+	index    int     // Position in the heap
+}
+
+// lfuHeap is a min-heap of entries ordered by priority
+type lfuHeap[T any] []*lfuEntry[T]
+
+func (h lfuHeap[T]) Len() int           { return len(h) }
+func (h lfuHeap[T]) Less(i, j int) bool { return h[i].priority < h[j].priority }
+func (h lfuHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[T]) Push(x any) {
+	e := x.(*lfuEntry[T])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+// decayingLFU implements LFU whose frequency counters halve every halfLife
+// A once-popular item that stops being accessed eventually becomes evictable.
+type decayingLFU[T any] struct {
+	heap     lfuHeap[T]
+	entries  map[*CacheItem[T]]*lfuEntry[T]
+	halfLife time.Duration // Zero disables decay
+	epoch    time.Time
+	clock    func() time.Time // Source of the current time
+}
+
+// newDecayingLFU creates a new decaying LFU updater
+func newDecayingLFU[T any](halfLife time.Duration) *decayingLFU[T] {
+	return &decayingLFU[T]{
+		heap:     make(lfuHeap[T], 0),
+		entries:  make(map[*CacheItem[T]]*lfuEntry[T]),
+		halfLife: halfLife,
+		epoch:    time.Now(),
+		clock:    time.Now,
+	}
+}
+
+// useClock makes the decay follow now and restarts the epoch from it
+func (d *decayingLFU[T]) useClock(now func() time.Time) {
+	d.clock = now
+	d.epoch = now()
+}
+
+// now returns the current time in half-lives since the epoch
+func (d *decayingLFU[T]) now() float64 {
+	if d.halfLife <= 0 {
+		return 0
+	}
+	return float64(d.clock().Sub(d.epoch)) / float64(d.halfLife)
+}
+
+// Add adds a new item with a score of one access
+func (d *decayingLFU[T]) Add(item *CacheItem[T]) {
+	stamp := d.now()
+	e := &lfuEntry[T]{item: item, score: 1, stamp: stamp, priority: stamp}
+	d.entries[item] = e
+	heap.Push(&d.heap, e)
+}
+
+// Access decays the item's score to now and counts one more access
+func (d *decayingLFU[T]) Access(item *CacheItem[T]) {
+	e, exists := d.entries[item]
+	if !exists {
+		return
+	}
+	stamp := d.now()
+	e.score = e.score*math.Exp2(e.stamp-stamp) + 1
+	e.stamp = stamp
+	e.priority = math.Log2(e.score) + stamp
+	heap.Fix(&d.heap, e.index)
+}
+
+// Remove removes an item from the updater
+func (d *decayingLFU[T]) Remove(item *CacheItem[T]) {
+	if e, exists := d.entries[item]; exists {
+		heap.Remove(&d.heap, e.index)
+		delete(d.entries, item)
+	}
+}
+
+// Evict returns the item with the lowest decayed frequency
+func (d *decayingLFU[T]) Evict() *CacheItem[T] {
+	if len(d.heap) == 0 {
+		return nil
+	}
+	e := heap.Pop(&d.heap).(*lfuEntry[T])
+	delete(d.entries, e.item)
+	return e.item
+}
+
+// Size returns the current size
+func (d *decayingLFU[T]) Size() int {
+	return len(d.heap)
+}
+
+// Clear removes all items from the updater
+func (d *decayingLFU[T]) Clear() {
+	d.heap = make(lfuHeap[T], 0)
+	d.entries = make(map[*CacheItem[T]]*lfuEntry[T])
+}
@@ -0,0 +1,47 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+// hotThenCold makes "old" hot, lets time pass, makes "new" moderately hot and
+// then inserts a third key into the full bucket
+func hotThenCold(t *testing.T, halfLife time.Duration) *Bucket[int] {
+	t.Helper()
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithMaxSize[int](2), WithBucketNeverExpire[int](), WithDecayingLFUUpdater[int](halfLife))
+	t.Cleanup(func() { _ = b.Close() })
+
+	_ = b.Nail("old", 1)
+	for i := 0; i < 100; i++ {
+		b.Bring("old")
+	}
+	clock.Advance(20 * time.Second)
+	_ = b.Nail("new", 2)
+	for i := 0; i < 5; i++ {
+		b.Bring("new")
+	}
+	_ = b.Nail("third", 3)
+	return b
+}
+
+func TestDecayingLFUForgetsOnceHotItems(t *testing.T) {
+	b := hotThenCold(t, time.Second)
+	if exists(b, "old") {
+		t.Fatal("once-hot item survived twenty half-lives")
+	}
+	if !exists(b, "new") {
+		t.Fatal("newly-hot item was evicted")
+	}
+}
+
+func TestDecayingLFUWithoutDecayKeepsHotItems(t *testing.T) {
+	b := hotThenCold(t, 0)
+	if !exists(b, "old") {
+		t.Fatal("hot item was evicted without decay")
+	}
+	if exists(b, "new") {
+		t.Fatal("less frequent item survived without decay")
+	}
+}
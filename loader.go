@@ -0,0 +1,263 @@
+package heatwave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Loader loads the value for a key that is missing from the bucket
+type Loader[T any] func(id string) (T, error)
+
+// loadCall tracks a single in-flight load shared by concurrent callers
+type loadCall[T any] struct {
+	done    chan struct{}
+	value   T
+	err     error
+	waiters int                // Callers waiting for the result, guarded by flightMutex
+	ctx     context.Context    // Done once every waiter has given up
+	cancel  context.CancelFunc // Cancels ctx
+	copyOut func(T) T          // Copier of the bucket for values on the way out
+}
+
+// result returns the outcome of the load, cloning the value for each caller
+// since the bucket stores the same value
+func (c *loadCall[T]) result() (T, error) {
+	if c.err == nil && c.copyOut != nil {
+		return c.copyOut(c.value), nil
+	}
+	return c.value, c.err
+}
+
+// copyResult clones a value that was just stored, so the caller can't
+// mutate the cached copy
+func (b *Bucket[T]) copyResult(value T) T {
+	if b.copyOut != nil {
+		return b.copyOut(value)
+	}
+	return value
+}
+
+// errorEntry caches a loader failure for a short period
+type errorEntry struct {
+	err       error
+	expiredAt time.Time
+}
+
+// GetOrLoad returns the cached value for id, or loads it with loader on a miss
+// Concurrent callers for the same key share one loader invocation. A
+// successful result is stored in the bucket before it is returned.
+func (b *Bucket[T]) GetOrLoad(id string, loader func() (T, error)) (T, error) {
+	if value, ok := b.get(id, false); ok {
+		return value, nil
+	}
+	return b.load(id, loader)
+}
+
+// Load is GetOrLoad using the loader configured with WithLoader
+// Without a loader the Source configured with WithSource is used.
+func (b *Bucket[T]) Load(id string) (T, error) {
+	loader := b.resolveLoader()
+	if loader == nil {
+		var zero T
+		return zero, ErrNoLoader
+	}
+	return b.GetOrLoad(id, func() (T, error) {
+		return loader(id)
+	})
+}
+
+// Refresh always runs loader for id and stores its result, overwriting any
+// cached value and restarting its TTL
+// Unlike GetOrLoad it never serves the cached value and doesn't join an
+// in-flight load. A loader error leaves the cached value in place. The
+// value is returned together with the error when it can't be stored.
+func (b *Bucket[T]) Refresh(id string, loader func() (T, error)) (T, error) {
+	var value T
+	var err error
+	if b.traceHook != nil {
+		end := b.traceLoadStart(id)
+		value, err = b.callLoader(context.Background(), loader)
+		end(err)
+	} else {
+		value, err = b.callLoader(context.Background(), loader)
+	}
+	if err != nil {
+		return value, err
+	}
+	if err := b.Nail(id, value); err != nil {
+		return value, err
+	}
+	return b.copyResult(value), nil
+}
+
+// BringContext returns the value for id, loading it with the configured
+// loader on a miss
+// Cache hits never look at ctx. On a miss the load runs detached from the
+// caller: if ctx is done first BringContext returns ctx.Err(), while the load
+// keeps running for other waiters and still populates the bucket.
+func (b *Bucket[T]) BringContext(ctx context.Context, id string) (T, error) {
+	if value, ok := b.get(id, false); ok {
+		return value, nil
+	}
+
+	var zero T
+	loader := b.resolveLoader()
+	if loader == nil {
+		return zero, ErrNoLoader
+	}
+	call, leader, err := b.acquireLoad(id)
+	if err != nil {
+		return zero, err
+	}
+	if leader {
+		b.spawn(roleLoad, func() {
+			b.runLoad(id, call, func() (T, error) {
+				return loader(id)
+			})
+		})
+	}
+
+	select {
+	case <-call.done:
+		return call.result()
+	case <-ctx.Done():
+		b.abandonLoad(call)
+		return zero, ctx.Err()
+	}
+}
+
+// load runs loader for id unless a load for it is already in flight
+func (b *Bucket[T]) load(id string, loader func() (T, error)) (T, error) {
+	call, leader, err := b.acquireLoad(id)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if leader {
+		if r := b.runLoad(id, call, loader); r != nil {
+			panic(r)
+		}
+	} else {
+		<-call.done
+	}
+	return call.result()
+}
+
+// acquireLoad returns the in-flight load for id, registering a new one when
+// none exists; leader reports whether the caller must run the loader
+// A cached loader failure is returned as err.
+func (b *Bucket[T]) acquireLoad(id string) (call *loadCall[T], leader bool, err error) {
+	b.flightMutex.Lock()
+	defer b.flightMutex.Unlock()
+
+	if entry, ok := b.loadErrors[id]; ok {
+		if b.now().Before(entry.expiredAt) {
+			return nil, false, entry.err
+		}
+		delete(b.loadErrors, id)
+	}
+	if call, ok := b.inflight[id]; ok {
+		call.waiters++
+		return call, false, nil
+	}
+	call = &loadCall[T]{done: make(chan struct{}), waiters: 1, copyOut: b.copyOut}
+	call.ctx, call.cancel = context.WithCancel(context.Background())
+	b.inflight[id] = call
+	return call, true, nil
+}
+
+// abandonLoad is called by a BringContext waiter that stops waiting for call
+// Once nobody waits any more the load stops retrying; the attempt in progress
+// still finishes and populates the bucket.
+func (b *Bucket[T]) abandonLoad(call *loadCall[T]) {
+	b.flightMutex.Lock()
+	defer b.flightMutex.Unlock()
+
+	call.waiters--
+	if call.waiters == 0 {
+		call.cancel()
+	}
+}
+
+// runLoad invokes loader and publishes its result to every waiter
+// A loader panic is turned into an error for the waiters and returned so the
+// caller can decide whether to re-panic.
+func (b *Bucket[T]) runLoad(id string, call *loadCall[T], loader func() (T, error)) (recovered any) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = fmt.Errorf("heatwave: loader panicked: %v", r)
+			b.finishLoad(id, call)
+			recovered = r
+		}
+	}()
+
+	if b.traceHook != nil {
+		end := b.traceLoadStart(id)
+		call.value, call.err = b.callLoader(call.ctx, loader)
+		end(call.err)
+	} else {
+		call.value, call.err = b.callLoader(call.ctx, loader)
+	}
+	if call.err == nil {
+		// The value is still returned when the bucket has been closed meanwhile
+		_ = b.Nail(id, call.value)
+	}
+	b.finishLoad(id, call)
+	return nil
+}
+
+// finishLoad removes the in-flight marker, caches failures and wakes waiters
+func (b *Bucket[T]) finishLoad(id string, call *loadCall[T]) {
+	b.flightMutex.Lock()
+	delete(b.inflight, id)
+	if call.err != nil && b.errorTTL > 0 && !errors.Is(call.err, ErrNotFound) && !errors.Is(call.err, ErrCircuitOpen) {
+		b.loadErrors[id] = &errorEntry{
+			err:       call.err,
+			expiredAt: b.now().Add(b.errorTTL),
+		}
+	}
+	b.flightMutex.Unlock()
+	call.cancel()
+	close(call.done)
+}
+
+// resolveLoader returns the configured loader, falling back to the source
+func (b *Bucket[T]) resolveLoader() Loader[T] {
+	if b.loader != nil {
+		return b.loader
+	}
+	if b.source != nil {
+		return b.sourceLoader
+	}
+	return nil
+}
+
+// cleanupLoadErrors drops cached loader failures whose TTL has passed
+func (b *Bucket[T]) cleanupLoadErrors(now time.Time) {
+	b.flightMutex.Lock()
+	defer b.flightMutex.Unlock()
+
+	for id, entry := range b.loadErrors {
+		if !now.Before(entry.expiredAt) {
+			delete(b.loadErrors, id)
+		}
+	}
+}
+
+// WithLoader sets the loader used by Load
+func WithLoader[T any](loader Loader[T]) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.loader = loader
+	}
+}
+
+// WithErrorCaching caches loader failures for ttl
+// Calls for the same key within ttl return the cached error without invoking
+// the loader again, which avoids retry storms while a backend is down.
+func WithErrorCaching[T any](ttl time.Duration) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.errorTTL = ttl
+	}
+}
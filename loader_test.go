@@ -0,0 +1,237 @@
+package heatwave
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestErrorCachingCallsFailingLoaderOnce(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[string](WithClock[string](clock), WithErrorCaching[string](time.Second))
+	defer b.Close()
+
+	boom := errors.New("boom")
+	var calls atomic.Int32
+	loader := func() (string, error) {
+		calls.Add(1)
+		return "", boom
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := b.GetOrLoad("k", loader); !errors.Is(err, boom) {
+			t.Fatalf("GetOrLoad = %v, want boom", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader called %d times within the error TTL, want 1", n)
+	}
+
+	clock.Advance(2 * time.Second)
+	if _, err := b.GetOrLoad("k", loader); !errors.Is(err, boom) {
+		t.Fatalf("GetOrLoad = %v, want boom", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("loader called %d times after the error TTL, want 2", n)
+	}
+}
+
+func TestGetOrLoadSharesConcurrentLoads(t *testing.T) {
+	b := NewBucket[string]()
+	defer b.Close()
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	loader := func() (string, error) {
+		calls.Add(1)
+		<-release
+		return "v", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := b.GetOrLoad("k", loader); err != nil || v != "v" {
+				t.Errorf("GetOrLoad = %q, %v", v, err)
+			}
+		}()
+	}
+	// Let every caller join the in-flight load before releasing it
+	waitFor(t, "callers to join the load", func() bool { return b.inflightWaiters("k") == 8 })
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader called %d times, want 1", n)
+	}
+	if v, ok := b.Bring("k"); !ok || v != "v" {
+		t.Fatalf("Bring = %q, %v, want v, true", v, ok)
+	}
+}
+
+// inflightCount returns the number of loads in flight
+func (b *Bucket[T]) inflightCount() int {
+	b.flightMutex.Lock()
+	defer b.flightMutex.Unlock()
+	return len(b.inflight)
+}
+
+// inflightWaiters returns the number of callers waiting for the load of id
+func (b *Bucket[T]) inflightWaiters(id string) int {
+	b.flightMutex.Lock()
+	defer b.flightMutex.Unlock()
+	if call, ok := b.inflight[id]; ok {
+		return call.waiters
+	}
+	return 0
+}
+
+func TestBringContextCancelDetachesLoad(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	b := NewBucket[string](WithLoader(func(id string) (string, error) {
+		calls.Add(1)
+		<-release
+		return "v", nil
+	}))
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := b.BringContext(ctx, "k")
+		canceled <- err
+	}()
+	waitFor(t, "the first caller to wait", func() bool { return b.inflightWaiters("k") == 1 })
+
+	waited := make(chan string, 1)
+	go func() {
+		v, err := b.BringContext(context.Background(), "k")
+		if err != nil {
+			t.Errorf("BringContext of the patient caller = %v", err)
+		}
+		waited <- v
+	}()
+	waitFor(t, "the second caller to join", func() bool { return b.inflightWaiters("k") == 2 })
+
+	// The canceled caller returns at once while the load carries on
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("BringContext after cancel = %v, want context.Canceled", err)
+	}
+	close(release)
+	if v := <-waited; v != "v" {
+		t.Fatalf("patient caller got %q, want v", v)
+	}
+	if v, ok := b.Bring("k"); !ok || v != "v" || calls.Load() != 1 {
+		t.Fatalf("Bring = %q, %v after %d loads, want v from one load", v, ok, calls.Load())
+	}
+}
+
+func TestBringContext(t *testing.T) {
+	b := NewBucket[string]()
+	defer b.Close()
+	if _, err := b.BringContext(context.Background(), "k"); !errors.Is(err, ErrNoLoader) {
+		t.Fatalf("BringContext without a loader = %v, want ErrNoLoader", err)
+	}
+
+	// A hit doesn't look at the context
+	_ = b.Nail("k", "v")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if v, err := b.BringContext(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("BringContext hit with a canceled context = %q, %v, want v, nil", v, err)
+	}
+
+	// The last waiter giving up still lets the running attempt populate the
+	// bucket
+	release := make(chan struct{})
+	l := NewBucket[string](WithLoader(func(id string) (string, error) {
+		<-release
+		return "loaded", nil
+	}))
+	defer l.Close()
+	ctx, cancel = context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.BringContext(ctx, "k")
+		done <- err
+	}()
+	waitFor(t, "the load to start", func() bool { return l.inflightWaiters("k") == 1 })
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("BringContext after cancel = %v, want context.Canceled", err)
+	}
+	close(release)
+	waitFor(t, "the detached load to populate the bucket", func() bool { return exists(l, "k") })
+}
+
+func TestRefresh(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithBucketExpire[int](time.Minute))
+	defer b.Close()
+
+	calls := 0
+	loader := func() (int, error) {
+		calls++
+		return calls * 10, nil
+	}
+	_ = b.Nail("k", 1)
+	clock.Advance(50 * time.Second)
+
+	for want := 10; want <= 20; want += 10 {
+		v, err := b.Refresh("k", loader)
+		if err != nil || v != want {
+			t.Fatalf("Refresh = %d, %v, want %d, nil", v, err, want)
+		}
+		if got, _ := b.Bring("k"); got != want {
+			t.Fatalf("Bring after Refresh = %d, want %d", got, want)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("loader called %d times, want 2", calls)
+	}
+
+	// The refresh restarted the TTL
+	clock.Advance(50 * time.Second)
+	if !exists(b, "k") {
+		t.Fatal("Refresh didn't restart the TTL")
+	}
+
+	// A failed refresh keeps the cached value
+	failed := errors.New("backend down")
+	if _, err := b.Refresh("k", func() (int, error) { return 0, failed }); !errors.Is(err, failed) {
+		t.Fatalf("Refresh = %v, want the loader error", err)
+	}
+	if got, _ := b.Bring("k"); got != 20 {
+		t.Fatalf("Bring after a failed Refresh = %d, want 20", got)
+	}
+}
+
+func TestRefreshDoesNotJoinInflightLoad(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = b.GetOrLoad("k", func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+
+	if v, err := b.Refresh("k", func() (int, error) { return 2, nil }); err != nil || v != 2 {
+		t.Fatalf("Refresh during a load = %d, %v, want 2, nil", v, err)
+	}
+	close(release)
+	<-done
+}
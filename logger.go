@@ -0,0 +1,31 @@
+package heatwave
+
+// Log levels passed to LogFunc
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+// LogFunc receives structured log events as a message plus key/value pairs
+type LogFunc func(level, msg string, kv ...any)
+
+// log emits an event through the configured logger, tagged with the bucket name
+func (b *Bucket[T]) log(level, msg string, kv ...any) {
+	if b.logger == nil {
+		return
+	}
+	kv = append([]any{"bucket", b.name}, kv...)
+	b.guard(func() { b.logger(level, msg, kv...) })
+}
+
+// WithLogger sets a structured logger for notable events
+// It is called for evictions, completed expiration sweeps, recovered updater
+// panics, loader circuit breaker state changes and Close. Without it logging
+// costs nothing.
+func WithLogger[T any](log LogFunc) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.logger = log
+	}
+}
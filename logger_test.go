@@ -0,0 +1,93 @@
+package heatwave
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// logEntry is a captured logger call
+type logEntry struct {
+	level, msg string
+	kv         map[string]any
+}
+
+// captureLogger collects logger calls
+type captureLogger struct {
+	mutex   sync.Mutex
+	entries []logEntry
+}
+
+func (c *captureLogger) log(level, msg string, kv ...any) {
+	entry := logEntry{level: level, msg: msg, kv: make(map[string]any)}
+	for i := 0; i+1 < len(kv); i += 2 {
+		entry.kv[kv[i].(string)] = kv[i+1]
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = append(c.entries, entry)
+}
+
+// find returns the first entry with msg
+func (c *captureLogger) find(msg string) (logEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, e := range c.entries {
+		if e.msg == msg {
+			return e, true
+		}
+	}
+	return logEntry{}, false
+}
+
+// sum adds up the integer value of key over the entries with msg
+func (c *captureLogger) sum(msg, key string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	total := 0
+	for _, e := range c.entries {
+		if n, ok := e.kv[key].(int); ok && e.msg == msg {
+			total += n
+		}
+	}
+	return total
+}
+
+func TestLoggerReportsEviction(t *testing.T) {
+	logs := &captureLogger{}
+	b := NewBucket[int](WithBucketName[int]("users"), WithMaxSize[int](1), WithLogger[int](logs.log))
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2) // evicts a
+
+	e, ok := logs.find("item evicted")
+	if !ok {
+		t.Fatal("no eviction was logged")
+	}
+	if e.kv["key"] != "a" || e.kv["reason"] != "evicted" || e.kv["bucket"] != "users" {
+		t.Fatalf("eviction logged with %v, want key a, reason evicted, bucket users", e.kv)
+	}
+
+	_ = b.Close()
+	if _, ok := logs.find("bucket closed"); !ok {
+		t.Fatal("Close wasn't logged")
+	}
+}
+
+func TestLoggerReportsCleanup(t *testing.T) {
+	logs := &captureLogger{}
+	b := NewBucket[int](WithCleanupInterval[int](5*time.Millisecond), WithLogger[int](logs.log))
+	defer b.Close()
+
+	_ = b.NailWithTTL("a", 1, time.Millisecond)
+	_ = b.NailWithTTL("b", 2, time.Millisecond)
+
+	// The two items may expire in separate sweeps
+	deadline := time.Now().Add(time.Second)
+	for logs.sum("expired items cleaned up", "count") < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the cleanup goroutine didn't log its sweeps")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,137 @@
+package heatwave
+
+import "time"
+
+// lookupChunkSize bounds how many keys BringDetailed looks up per lock
+// acquisition
+const lookupChunkSize = 256
+
+// LookupStatus is the outcome of looking up one key
+type LookupStatus int
+
+const (
+	// LookupHit means the key holds a live item
+	LookupHit LookupStatus = iota
+	// LookupMiss means the key is not in the bucket
+	LookupMiss
+	// LookupExpired means the key held an expired item, which was removed
+	LookupExpired
+	// LookupClosed means the bucket is closed
+	LookupClosed
+)
+
+// String returns the name of the status
+func (s LookupStatus) String() string {
+	switch s {
+	case LookupHit:
+		return "hit"
+	case LookupMiss:
+		return "miss"
+	case LookupExpired:
+		return "expired"
+	case LookupClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// LookupResult is the result of looking up one key with BringDetailed
+type LookupResult[T any] struct {
+	Value     T // Zero unless Status is LookupHit
+	Status    LookupStatus
+	ExpiresAt time.Time // Expiry of a hit, zero when it never expires
+}
+
+// BringDetailed looks up ids like Bring but tells expired keys apart from
+// keys that were never present
+// Expiry is judged against a single point in time taken at the start, so the
+// statuses are consistent across the batch. The lock is released every 256
+// keys to bound the time writers wait. Hits are marked as accessed and
+// expired items are removed; an expired item the expire interceptor keeps is
+// reported as a hit. The trace hook sees every key as a Bring, while the
+// latency metrics count the whole call as one Bring.
+func (b *Bucket[T]) BringDetailed(ids []string) map[string]LookupResult[T] {
+	if b.timed() {
+		defer b.observeBring(time.Now())
+	}
+
+	out := make(map[string]LookupResult[T], len(ids))
+	now := b.now()
+	for start := 0; start < len(ids); start += lookupChunkSize {
+		end := min(start+lookupChunkSize, len(ids))
+		b.bringDetailedChunk(ids[start:end], now, out)
+	}
+	return out
+}
+
+// bringDetailedChunk looks up a chunk of keys under a single lock
+// acquisition, plus one more when expired items went to the interceptor
+func (b *Bucket[T]) bringDetailedChunk(ids []string, now time.Time, out map[string]LookupResult[T]) {
+	if b.traceHook != nil {
+		ends := make([]func(hit bool), len(ids))
+		for i, id := range ids {
+			ends[i] = b.traceBringStart(id)
+		}
+		defer func() {
+			for i, id := range ids {
+				ends[i](out[id].Status == LookupHit)
+			}
+		}()
+	}
+
+	b.lock()
+	closed := b.isClosed()
+	intercept := b.expireInterceptor != nil && !b.frozen.Load()
+	var candidates []expireCandidate[T]
+	for _, id := range ids {
+		if closed {
+			out[id] = LookupResult[T]{Status: LookupClosed}
+			continue
+		}
+		item, exists := b.cache[id]
+		switch {
+		case !exists:
+			b.counters.misses.Add(1)
+			out[id] = LookupResult[T]{Status: LookupMiss}
+		case item.expired(now) && intercept:
+			candidates = append(candidates, b.candidateLocked(item))
+		case item.expired(now):
+			if !b.frozen.Load() {
+				b.removeLocked(item, ReasonExpired)
+			}
+			b.counters.misses.Add(1)
+			out[id] = LookupResult[T]{Status: LookupExpired}
+		default:
+			out[id] = b.hitLocked(item)
+		}
+	}
+	b.unlock()
+	if len(candidates) == 0 {
+		return
+	}
+
+	b.interceptExpired(candidates)
+	b.lock()
+	defer b.unlock()
+	for _, c := range candidates {
+		if item, exists := b.cache[c.key]; exists && !b.isClosed() && !item.expired(now) {
+			out[c.key] = b.hitLocked(item)
+			continue
+		}
+		b.counters.misses.Add(1)
+		out[c.key] = LookupResult[T]{Status: LookupExpired}
+	}
+}
+
+// hitLocked marks item as accessed and returns it as a hit
+// Must be called with b.mutex held
+func (b *Bucket[T]) hitLocked(item *CacheItem[T]) LookupResult[T] {
+	b.updater.Access(item)
+	b.counters.hits.Add(1)
+	result := LookupResult[T]{Value: b.readValue(item), Status: LookupHit}
+	if item.expiredAt != nil {
+		result.ExpiresAt = *item.expiredAt
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package heatwave
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBringDetailed(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	hook := &recordingHook{}
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithBucketNeverExpire[int](),
+		WithTraceHook[int](hook),
+		WithLatencyMetrics[int](),
+	)
+
+	_ = b.Nail("hit", 1)
+	_ = b.NailWithTTL("expired", 2, time.Second)
+	clock.Advance(2 * time.Second)
+
+	got := b.BringDetailed([]string{"hit", "missing", "expired"})
+	if r := got["hit"]; r.Status != LookupHit || r.Value != 1 || !r.ExpiresAt.IsZero() {
+		t.Fatalf("hit = %+v", r)
+	}
+	if r := got["missing"]; r.Status != LookupMiss {
+		t.Fatalf("missing = %+v", r)
+	}
+	if r := got["expired"]; r.Status != LookupExpired || r.Value != 0 {
+		t.Fatalf("expired = %+v", r)
+	}
+	if exists(b, "expired") {
+		t.Fatal("the expired item wasn't removed")
+	}
+	if s := b.Stats(); s.BringCount != 1 {
+		t.Fatalf("BringCount = %d, want the call counted once", s.BringCount)
+	}
+	for _, want := range []string{"bring hit hit=true", "bring missing hit=false", "bring expired hit=false"} {
+		if !slices.Contains(hook.events, want) {
+			t.Fatalf("trace events %v lack %q", hook.events, want)
+		}
+	}
+
+	_ = b.Close()
+	if r := b.BringDetailed([]string{"hit"})["hit"]; r.Status != LookupClosed {
+		t.Fatalf("after Close = %+v, want LookupClosed", r)
+	}
+}
+
+func TestBringDetailedExpireInterceptor(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), keepInterceptor[int]("kept"))
+	defer b.Close()
+
+	_ = b.NailWithTTL("kept", 1, time.Second)
+	_ = b.NailWithTTL("dropped", 2, time.Second)
+	clock.Advance(2 * time.Second)
+
+	got := b.BringDetailed([]string{"kept", "dropped"})
+	if r := got["kept"]; r.Status != LookupHit || r.Value != 1 || !r.ExpiresAt.Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("kept = %+v, want a hit expiring a minute from now", r)
+	}
+	if r := got["dropped"]; r.Status != LookupExpired {
+		t.Fatalf("dropped = %+v, want LookupExpired", r)
+	}
+	if exists(b, "dropped") {
+		t.Fatal("dropped is still held")
+	}
+}
+
+func TestBringDetailedChunks(t *testing.T) {
+	const n = 2*lookupChunkSize + 1
+	b := NewBucket[int]()
+	defer b.Close()
+
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+		if i%2 == 0 {
+			_ = b.Nail(ids[i], i)
+		}
+	}
+	got := b.BringDetailed(ids)
+	for i, id := range ids {
+		want := LookupMiss
+		if i%2 == 0 {
+			want = LookupHit
+		}
+		if got[id].Status != want {
+			t.Fatalf("%s = %v, want %v", id, got[id].Status, want)
+		}
+	}
+}
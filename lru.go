@@ -56,6 +56,32 @@ func (l *lru[T]) Evict() *CacheItem[T] {
 	return l.removeTail()
 }
 
+// Peek returns the least recently used item without removing it
+func (l *lru[T]) Peek() *CacheItem[T] {
+	if l.size == 0 {
+		return nil
+	}
+	return l.tail.prev.item
+}
+
+// Ascend walks items from least to most recently used
+func (l *lru[T]) Ascend(fn func(item *CacheItem[T]) bool) {
+	for node := l.tail.prev; node != l.head; node = node.prev {
+		if !fn(node.item) {
+			return
+		}
+	}
+}
+
+// Descend walks items from most to least recently used
+func (l *lru[T]) Descend(fn func(item *CacheItem[T]) bool) {
+	for node := l.head.next; node != l.tail; node = node.next {
+		if !fn(node.item) {
+			return
+		}
+	}
+}
+
 // Size returns the current size
 func (l *lru[T]) Size() int {
 	return l.size
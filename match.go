@@ -0,0 +1,138 @@
+package heatwave
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// matchChunkSize bounds how many matched keys are removed per lock
+// acquisition
+const matchChunkSize = 256
+
+// MatchSyntax selects how key patterns are interpreted
+type MatchSyntax int
+
+const (
+	// MatchGlob matches with path.Match, e.g. `page:*:42`
+	MatchGlob MatchSyntax = iota
+	// MatchRegexp matches with regexp.MatchString semantics, unanchored
+	MatchRegexp
+)
+
+// compileMatcher validates pattern and returns a key predicate for it
+func compileMatcher(pattern string, syntax MatchSyntax) (func(key string) bool, error) {
+	switch syntax {
+	case MatchGlob:
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+		return func(key string) bool {
+			ok, _ := path.Match(pattern, key)
+			return ok
+		}, nil
+	case MatchRegexp:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	default:
+		return nil, fmt.Errorf("heatwave: unknown match syntax %d", syntax)
+	}
+}
+
+// DeleteMatch removes every live item whose key matches pattern and returns
+// how many were removed
+// pattern uses path.Match syntax, so `user:*:session` matches any middle
+// segment without a slash. A malformed pattern returns path.ErrBadPattern
+// before anything is removed.
+func (b *Bucket[T]) DeleteMatch(pattern string) (int, error) {
+	return b.UnnailMatch(pattern, MatchGlob)
+}
+
+// UnnailMatch removes every live item whose key matches pattern and returns
+// how many were removed
+// An invalid pattern returns an error before anything is removed. Keys are
+// matched against a snapshot outside the lock and removed in chunks, so
+// writers are not blocked for the whole scan; removals are reported to
+// observers and replicas like Unnail.
+func (b *Bucket[T]) UnnailMatch(pattern string, syntax MatchSyntax) (int, error) {
+	keys, err := b.KeysMatch(pattern, syntax)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for start := 0; start < len(keys); start += matchChunkSize {
+		end := min(start+matchChunkSize, len(keys))
+		n, err := b.unnailChunk(keys[start:end])
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// unnailChunk removes a chunk of keys under a single lock acquisition
+func (b *Bucket[T]) unnailChunk(keys []string) (int, error) {
+	b.lock()
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return 0, err
+	}
+
+	now := b.now()
+	removed := 0
+	for _, key := range keys {
+		item, exists := b.cache[key]
+		if !exists {
+			continue
+		}
+		if item.expired(now) {
+			b.removeLocked(item, ReasonExpired)
+			continue
+		}
+		b.removeLocked(item, ReasonDeleted)
+		removed++
+	}
+	return removed, nil
+}
+
+// KeysMatch returns the keys of the live items matching pattern without
+// removing anything, as a dry run of UnnailMatch
+func (b *Bucket[T]) KeysMatch(pattern string, syntax MatchSyntax) ([]string, error) {
+	match, err := compileMatcher(pattern, syntax)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, key := range b.snapshotKeys() {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// snapshotKeys copies the keys of the live items under the read lock
+func (b *Bucket[T]) snapshotKeys() []string {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return nil
+	}
+
+	now := b.now()
+	keys := make([]string, 0, len(b.cache))
+	for key, item := range b.cache {
+		if !item.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,75 @@
+package heatwave
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestDeleteMatch(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	for _, key := range []string{
+		"user:1:session", "user:2:session", "user:1:profile", "admin:1:session", "user:1:session:old",
+	} {
+		_ = b.Nail(key, 0)
+	}
+
+	n, err := b.DeleteMatch("user:*:session")
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteMatch = %d, %v, want 2, nil", n, err)
+	}
+	left := b.ToMap()
+	keys := make([]string, 0, len(left))
+	for key := range left {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	want := []string{"admin:1:session", "user:1:profile", "user:1:session:old"}
+	if len(keys) != len(want) {
+		t.Fatalf("remaining keys = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("remaining keys = %v, want %v", keys, want)
+		}
+	}
+}
+
+func TestDeleteMatchInvalidPattern(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	_ = b.Nail("a[", 1)
+	if _, err := b.DeleteMatch("a["); !errors.Is(err, path.ErrBadPattern) {
+		t.Fatalf("DeleteMatch with a bad pattern = %v, want path.ErrBadPattern", err)
+	}
+	if !exists(b, "a[") {
+		t.Fatal("a bad pattern removed keys")
+	}
+	if _, err := b.UnnailMatch("(", MatchRegexp); err == nil {
+		t.Fatal("UnnailMatch with a bad regexp succeeded")
+	}
+}
+
+func TestUnnailMatchRegexpAcrossChunks(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](10_000))
+	defer b.Close()
+
+	const n = 3*matchChunkSize + 10
+	for i := 0; i < n; i++ {
+		_ = b.Nail("page:"+strconv.Itoa(i), i)
+		_ = b.Nail("keep:"+strconv.Itoa(i), i)
+	}
+	keys, _ := b.KeysMatch(`^page:\d+$`, MatchRegexp)
+	if len(keys) != n || b.Size() != 2*n {
+		t.Fatalf("KeysMatch found %d keys and removed something, want %d and nothing removed", len(keys), n)
+	}
+	removed, err := b.UnnailMatch(`^page:\d+$`, MatchRegexp)
+	if err != nil || removed != n || b.Size() != n {
+		t.Fatalf("UnnailMatch = %d, %v leaving %d items, want %d removed", removed, err, b.Size(), n)
+	}
+}
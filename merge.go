@@ -0,0 +1,129 @@
+package heatwave
+
+import "time"
+
+// MergePolicy decides what Restore does with a key the bucket already holds
+type MergePolicy int
+
+const (
+	// MergeOverwriteAll replaces existing items with the restored ones
+	MergeOverwriteAll MergePolicy = iota
+	// MergeSkipExisting keeps existing items and restores only missing keys
+	MergeSkipExisting
+	// MergeKeepNewer keeps whichever value was written last, the existing one
+	// on a tie
+	MergeKeepNewer
+	// MergeKeepLongerTTL keeps whichever item expires later, the existing one
+	// on a tie; items that never expire win
+	MergeKeepLongerTTL
+)
+
+// EntryMeta describes one side of a restore conflict
+type EntryMeta struct {
+	CreatedAt time.Time // When the key was first inserted
+	UpdatedAt time.Time // When the value was last written
+	ExpiresAt time.Time // Zero when the item never expires
+}
+
+// Decision is the outcome of a restore conflict
+type Decision int
+
+const (
+	// DecisionKeep keeps the existing item and drops the restored one
+	DecisionKeep Decision = iota
+	// DecisionReplace replaces the existing item with the restored one
+	DecisionReplace
+)
+
+// MergeResolver decides a restore conflict for key
+// It runs under the bucket lock and must not call back into the bucket.
+type MergeResolver func(key string, existing, incoming EntryMeta) Decision
+
+// RestoreStats counts what a restore did with the items it read
+type RestoreStats struct {
+	Inserted    int // Items restored under keys the bucket didn't hold
+	Overwritten int // Existing items replaced by restored ones
+	Skipped     int // Restored items dropped in favour of existing ones or rejected by the limits
+	Expired     int // Restored items left out because their deadline passed
+	Resolved    int // Conflicts decided by a MergeResolver
+}
+
+// Applied returns how many items were written to the bucket
+func (s RestoreStats) Applied() int {
+	return s.Inserted + s.Overwritten
+}
+
+// add accumulates the counts of other
+func (s *RestoreStats) add(other RestoreStats) {
+	s.Inserted += other.Inserted
+	s.Overwritten += other.Overwritten
+	s.Skipped += other.Skipped
+	s.Resolved += other.Resolved
+	s.Expired += other.Expired
+}
+
+// MergeWith sets how conflicts with existing keys are settled, the default is
+// MergeOverwriteAll
+func MergeWith(policy MergePolicy) RestoreOption {
+	return func(o *restoreOptions) {
+		o.policy = policy
+	}
+}
+
+// ResolveWith settles conflicts with existing keys by calling resolver,
+// taking precedence over MergeWith
+func ResolveWith(resolver MergeResolver) RestoreOption {
+	return func(o *restoreOptions) {
+		o.resolver = resolver
+	}
+}
+
+// entryMeta returns the metadata of item
+func entryMeta[T any](item *CacheItem[T]) EntryMeta {
+	meta := EntryMeta{CreatedAt: item.createdAt, UpdatedAt: item.updatedAt}
+	if item.expiredAt != nil {
+		meta.ExpiresAt = *item.expiredAt
+	}
+	return meta
+}
+
+// recordMeta returns the metadata of a restored record
+func recordMeta(rec logRecord) EntryMeta {
+	meta := EntryMeta{CreatedAt: rec.createdAt, UpdatedAt: rec.updatedAt}
+	if rec.expiredAt != nil {
+		meta.ExpiresAt = *rec.expiredAt
+	}
+	return meta
+}
+
+// decide settles a conflict between an existing item and a restored record
+func (o *restoreOptions) decide(key string, existing, incoming EntryMeta) Decision {
+	if o.resolver != nil {
+		return o.resolver(key, existing, incoming)
+	}
+	switch o.policy {
+	case MergeSkipExisting:
+		return DecisionKeep
+	case MergeKeepNewer:
+		if incoming.UpdatedAt.After(existing.UpdatedAt) {
+			return DecisionReplace
+		}
+		return DecisionKeep
+	case MergeKeepLongerTTL:
+		if outlives(incoming.ExpiresAt, existing.ExpiresAt) {
+			return DecisionReplace
+		}
+		return DecisionKeep
+	default:
+		return DecisionReplace
+	}
+}
+
+// outlives reports whether deadline a is strictly later than b, zero meaning
+// never
+func outlives(a, b time.Time) bool {
+	if b.IsZero() {
+		return false
+	}
+	return a.IsZero() || a.After(b)
+}
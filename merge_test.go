@@ -0,0 +1,157 @@
+package heatwave
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// mergeFixture returns a bucket and a snapshot that both hold "old" and
+// "new": the snapshot has the later write of "old" and the earlier one of
+// "new", and the bucket's "old" outlives the snapshot's. Only the snapshot
+// holds "fresh".
+func mergeFixture(t *testing.T) (*Bucket[int], []byte) {
+	t.Helper()
+	clock := NewManualClock(time.Unix(1000, 0))
+	opts := []NewBucketOption[int]{WithClock[int](clock), WithCleanupDisabled[int](), WithBucketNeverExpire[int]()}
+	dst := NewBucket[int](opts...)
+	t.Cleanup(func() { dst.Close() })
+	src := NewBucket[int](opts...)
+	defer src.Close()
+
+	_ = dst.NailWithTTL("old", 10, time.Hour)
+	clock.Advance(10 * time.Second)
+	_ = src.NailWithTTL("old", 1, 10*time.Minute)
+	_ = src.NailWithTTL("new", 2, 2*time.Hour)
+	_ = src.Nail("fresh", 3)
+	clock.Advance(10 * time.Second)
+	_ = dst.NailWithTTL("new", 20, time.Hour)
+
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return dst, buf.Bytes()
+}
+
+func TestMergePolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []RestoreOption
+		old, new int
+		stats    RestoreStats
+	}{
+		{
+			name:  "overwrite all",
+			opts:  []RestoreOption{MergeWith(MergeOverwriteAll)},
+			old:   1,
+			new:   2,
+			stats: RestoreStats{Inserted: 1, Overwritten: 2},
+		},
+		{
+			name:  "default",
+			old:   1,
+			new:   2,
+			stats: RestoreStats{Inserted: 1, Overwritten: 2},
+		},
+		{
+			name:  "skip existing",
+			opts:  []RestoreOption{MergeWith(MergeSkipExisting)},
+			old:   10,
+			new:   20,
+			stats: RestoreStats{Inserted: 1, Skipped: 2},
+		},
+		{
+			name:  "keep newer",
+			opts:  []RestoreOption{MergeWith(MergeKeepNewer)},
+			old:   1,
+			new:   20,
+			stats: RestoreStats{Inserted: 1, Overwritten: 1, Skipped: 1},
+		},
+		{
+			name:  "keep longer TTL",
+			opts:  []RestoreOption{MergeWith(MergeKeepLongerTTL)},
+			old:   10,
+			new:   2,
+			stats: RestoreStats{Inserted: 1, Overwritten: 1, Skipped: 1},
+		},
+		{
+			name: "resolver over policy",
+			opts: []RestoreOption{
+				MergeWith(MergeOverwriteAll),
+				ResolveWith(func(key string, existing, incoming EntryMeta) Decision {
+					if key == "new" {
+						return DecisionReplace
+					}
+					return DecisionKeep
+				}),
+			},
+			old:   10,
+			new:   2,
+			stats: RestoreStats{Inserted: 1, Overwritten: 1, Skipped: 1, Resolved: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, data := mergeFixture(t)
+			opts := append([]RestoreOption{TTLWith(RestoreTTLAbsolute)}, tt.opts...)
+			stats, err := b.Restore(bytes.NewReader(data), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats != tt.stats {
+				t.Fatalf("Restore = %+v, want %+v", stats, tt.stats)
+			}
+			if stats.Applied() != tt.stats.Inserted+tt.stats.Overwritten {
+				t.Fatalf("Applied = %d", stats.Applied())
+			}
+			for key, want := range map[string]int{"old": tt.old, "new": tt.new, "fresh": 3} {
+				if got, ok := b.Bring(key); !ok || got != want {
+					t.Fatalf("Bring(%s) = %d, %v, want %d", key, got, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveWithSeesBothSides(t *testing.T) {
+	b, data := mergeFixture(t)
+	start := time.Unix(1000, 0)
+
+	seen := make(map[string][2]EntryMeta)
+	_, err := b.Restore(bytes.NewReader(data), TTLWith(RestoreTTLAbsolute),
+		ResolveWith(func(key string, existing, incoming EntryMeta) Decision {
+			seen[key] = [2]EntryMeta{existing, incoming}
+			return DecisionKeep
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 2 {
+		t.Fatalf("resolver called for %d keys, want 2 conflicts", len(seen))
+	}
+	old := seen["old"]
+	if !old[0].UpdatedAt.Equal(start) || !old[1].UpdatedAt.Equal(start.Add(10*time.Second)) {
+		t.Fatalf("old updated at %v and %v", old[0].UpdatedAt, old[1].UpdatedAt)
+	}
+	if want := start.Add(time.Hour); !old[0].ExpiresAt.Equal(want) {
+		t.Fatalf("existing old expires at %v, want %v", old[0].ExpiresAt, want)
+	}
+	if want := start.Add(10*time.Second + 10*time.Minute); !old[1].ExpiresAt.Equal(want) {
+		t.Fatalf("incoming old expires at %v, want %v", old[1].ExpiresAt, want)
+	}
+}
+
+func TestMergeNeverExpiringWinsLongerTTL(t *testing.T) {
+	if !outlives(time.Time{}, time.Unix(1, 0)) {
+		t.Fatal("an item that never expires doesn't outlive a deadline")
+	}
+	if outlives(time.Unix(1, 0), time.Time{}) || outlives(time.Time{}, time.Time{}) {
+		t.Fatal("a deadline outlives an item that never expires")
+	}
+	if outlives(time.Unix(1, 0), time.Unix(1, 0)) {
+		t.Fatal("a tie replaces the existing item")
+	}
+}
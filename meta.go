@@ -0,0 +1,57 @@
+package heatwave
+
+import (
+	"maps"
+	"time"
+)
+
+// NailWithMeta stores data together with metadata such as its source, etag
+// or content type, so it doesn't have to be wrapped in a struct
+// meta is copied and replaces the metadata held for id; a nil or empty map
+// clears it. A later Nail of id keeps the metadata, while eviction, expiry
+// and Unnail drop it with the item. Metadata is held in memory only and is
+// not part of snapshots.
+func (b *Bucket[T]) NailWithMeta(id string, data T, meta map[string]string, opts ...NailOption) error {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	b.lockStaged(id, data)
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return err
+	}
+
+	data, err := b.admit(id, data)
+	if err != nil {
+		return err
+	}
+
+	item, err := b.setLocked(id, data, b.writeExpiryLocked(id, b.outdated, opts))
+	if err != nil {
+		return err
+	}
+	item.meta = nil
+	if len(meta) > 0 {
+		item.meta = maps.Clone(meta)
+	}
+	return nil
+}
+
+// BringWithMeta retrieves data like Bring together with a copy of its
+// metadata, nil when none was set
+func (b *Bucket[T]) BringWithMeta(id string) (T, map[string]string, bool) {
+	item := b.lockAccess(id)
+	if item == nil {
+		b.unlock()
+		var zero T
+		return zero, nil, false
+	}
+	meta := maps.Clone(item.meta)
+	value, ok := b.readUnlock(item)
+	if !ok {
+		return value, nil, false
+	}
+	return value, meta, true
+}
@@ -0,0 +1,76 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetaRoundTrip(t *testing.T) {
+	b := NewBucket[string]()
+	defer b.Close()
+
+	meta := map[string]string{"etag": "v1", "content-type": "text/plain"}
+	if err := b.NailWithMeta("a", "body", meta); err != nil {
+		t.Fatal(err)
+	}
+	meta["etag"] = "changed" // The bucket holds its own copy
+
+	v, got, ok := b.BringWithMeta("a")
+	if !ok || v != "body" || len(got) != 2 || got["etag"] != "v1" || got["content-type"] != "text/plain" {
+		t.Fatalf("BringWithMeta = %q, %v, %v", v, got, ok)
+	}
+	got["etag"] = "mutated"
+	if _, again, _ := b.BringWithMeta("a"); again["etag"] != "v1" {
+		t.Fatal("mutating the returned map changed the stored metadata")
+	}
+
+	// A plain Nail keeps the metadata, an empty map clears it
+	_ = b.Nail("a", "body2")
+	if _, got, _ := b.BringWithMeta("a"); got["etag"] != "v1" {
+		t.Fatalf("metadata after Nail = %v, want it kept", got)
+	}
+	_ = b.NailWithMeta("a", "body3", nil)
+	if _, got, _ := b.BringWithMeta("a"); got != nil {
+		t.Fatalf("metadata after NailWithMeta(nil) = %v, want nil", got)
+	}
+}
+
+func TestMetaClearedOnEviction(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](1))
+	defer b.Close()
+
+	_ = b.NailWithMeta("a", 1, map[string]string{"source": "db"})
+	_ = b.Nail("b", 2) // Evicts a
+	if _, _, ok := b.BringWithMeta("a"); ok {
+		t.Fatal("a survived eviction")
+	}
+	_ = b.Nail("a", 3)
+	if _, got, ok := b.BringWithMeta("a"); !ok || got != nil {
+		t.Fatalf("re-inserted a has metadata %v, want none", got)
+	}
+
+	_ = b.NailWithMeta("a", 4, map[string]string{"source": "db"})
+	_, _ = b.Unnail("a")
+	_ = b.Nail("a", 5)
+	if _, got, _ := b.BringWithMeta("a"); got != nil {
+		t.Fatalf("metadata survived Unnail: %v", got)
+	}
+}
+
+func TestMetaSurvivesExpireInterceptor(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[string](WithClock[string](clock), WithCleanupDisabled[string](), keepInterceptor[string]("kept"))
+	defer b.Close()
+
+	_ = b.NailWithMeta("kept", "body", map[string]string{"etag": "v1"})
+	_ = b.NailWithMeta("dropped", "body", map[string]string{"etag": "v2"})
+	clock.Advance(10 * time.Minute)
+
+	v, meta, ok := b.BringWithMeta("kept")
+	if !ok || v != "body" || meta["etag"] != "v1" {
+		t.Fatalf("BringWithMeta(kept) = %q, %v, %v, want the value and metadata kept", v, meta, ok)
+	}
+	if _, meta, ok := b.BringWithMeta("dropped"); ok || meta != nil {
+		t.Fatalf("BringWithMeta(dropped) = %v, %v, want a miss", meta, ok)
+	}
+}
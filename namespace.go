@@ -0,0 +1,66 @@
+package heatwave
+
+import "strings"
+
+// namespaceSeparator separates a namespace prefix from the key
+const namespaceSeparator = ":"
+
+// Namespaced is a view of a bucket whose keys are prefixed with a namespace
+// All namespaces of a bucket share its maxSize and eviction strategy.
+type Namespaced[T any] struct {
+	bucket *Bucket[T]
+	prefix string // Namespace followed by the separator
+}
+
+// Namespace returns a view that prefixes every key with prefix + ":"
+func (b *Bucket[T]) Namespace(prefix string) *Namespaced[T] {
+	return &Namespaced[T]{
+		bucket: b,
+		prefix: prefix + namespaceSeparator,
+	}
+}
+
+// Nail stores data under id inside the namespace
+func (n *Namespaced[T]) Nail(id string, data T, opts ...NailOption) error {
+	return n.bucket.Nail(n.prefix+id, data, opts...)
+}
+
+// Bring retrieves data for id inside the namespace
+func (n *Namespaced[T]) Bring(id string) (T, bool) {
+	return n.bucket.Bring(n.prefix + id)
+}
+
+// Delete removes id from the namespace and reports whether it was present
+func (n *Namespaced[T]) Delete(id string) (bool, error) {
+	return n.bucket.Unnail(n.prefix + id)
+}
+
+// ClearNamespace removes every item of the namespace and returns how many
+// live items were removed
+// Nested namespaces are cleared too: the keys of Namespace("a:b") carry the
+// "a:" prefix and are ids of Namespace("a") as well. Expired items met on
+// the way are removed as expired and not counted.
+func (n *Namespaced[T]) ClearNamespace() int {
+	b := n.bucket
+	b.lock()
+	defer b.unlock()
+
+	if b.writable() != nil {
+		return 0
+	}
+
+	now := b.now()
+	removed := 0
+	for key, item := range b.cache {
+		if !strings.HasPrefix(key, n.prefix) {
+			continue
+		}
+		if item.expired(now) {
+			b.removeLocked(item, ReasonExpired)
+			continue
+		}
+		b.removeLocked(item, ReasonDeleted)
+		removed++
+	}
+	return removed
+}
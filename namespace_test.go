@@ -0,0 +1,75 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNamespaceIsolation(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	users := b.Namespace("users")
+	orders := b.Namespace("orders")
+	_ = users.Nail("1", 10)
+	_ = orders.Nail("1", 20)
+
+	if v, ok := users.Bring("1"); !ok || v != 10 {
+		t.Fatalf("users.Bring(1) = %d, %v, want 10, true", v, ok)
+	}
+	if v, ok := orders.Bring("1"); !ok || v != 20 {
+		t.Fatalf("orders.Bring(1) = %d, %v, want 20, true", v, ok)
+	}
+	if v, ok := b.Bring("users:1"); !ok || v != 10 {
+		t.Fatalf("Bring(users:1) = %d, %v, want 10, true", v, ok)
+	}
+
+	if ok, err := users.Delete("1"); !ok || err != nil {
+		t.Fatalf("users.Delete(1) = %v, %v", ok, err)
+	}
+	if _, ok := orders.Bring("1"); !ok {
+		t.Fatal("deleting from one namespace removed the other's key")
+	}
+}
+
+func TestNamespacesShareCapacity(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2))
+	defer b.Close()
+
+	a := b.Namespace("a")
+	c := b.Namespace("c")
+	_ = a.Nail("1", 1)
+	_ = a.Nail("2", 2)
+	_ = c.Nail("1", 3) // evicts a:1, the least recently used key of the bucket
+
+	if b.Size() != 2 {
+		t.Fatalf("Size = %d, want 2", b.Size())
+	}
+	if _, ok := a.Bring("1"); ok {
+		t.Fatal("a:1 survived eviction pressure from another namespace")
+	}
+}
+
+func TestClearNamespace(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int]())
+	defer b.Close()
+
+	a := b.Namespace("a")
+	_ = a.Nail("1", 1)
+	_ = b.NailWithTTL("a:2", 2, time.Second)
+	_ = b.Namespace("a:b").Nail("1", 3)
+	_ = b.Namespace("ab").Nail("1", 4)
+	clock.Advance(2 * time.Second)
+
+	// The expired a:2 is removed but not counted, a:b:1 is nested under a
+	if n := a.ClearNamespace(); n != 2 {
+		t.Fatalf("ClearNamespace = %d, want 2", n)
+	}
+	if b.Size() != 1 {
+		t.Fatalf("Size = %d, want 1", b.Size())
+	}
+	if _, ok := b.Bring("ab:1"); !ok {
+		t.Fatal("ClearNamespace removed a key of a namespace sharing its prefix")
+	}
+}
@@ -0,0 +1,48 @@
+package heatwave
+
+// OverflowPolicy controls what a write of a new key does when the bucket is
+// full
+type OverflowPolicy int
+
+const (
+	// OverflowEvictOldest evicts the updater's next victim to make room
+	OverflowEvictOldest OverflowPolicy = iota
+	// OverflowReject fails the write with ErrCacheFull
+	OverflowReject
+)
+
+// rejectOverflowLocked reports whether an insert must be rejected under
+// OverflowReject
+// Expired items still awaiting cleanup are removed first, since they don't
+// hold a reservation any more. Must be called with b.mutex held
+func (b *Bucket[T]) rejectOverflowLocked() bool {
+	if b.updater.Size() < b.maxSize {
+		return false
+	}
+	b.purgeExpiredLocked()
+	return b.updater.Size() >= b.maxSize
+}
+
+// purgeExpiredLocked removes the expired items, unless an expire interceptor
+// must see them first or the bucket is frozen
+// Must be called with b.mutex held
+func (b *Bucket[T]) purgeExpiredLocked() {
+	if b.expireInterceptor != nil || b.frozen.Load() {
+		return
+	}
+	now := b.now()
+	for b.expiries.Len() > 0 && (*b.expiries)[0].expired(now) {
+		b.removeLocked((*b.expiries)[0], ReasonExpired)
+	}
+}
+
+// WithOverflowPolicy sets what a write of a new key does when the bucket is
+// full, the default is OverflowEvictOldest
+// With OverflowReject the bucket acts as a bounded reservation: new keys are
+// refused with ErrCacheFull until items are removed or expire, while updates
+// of existing keys still succeed. WithSoftMaxSize has no effect then.
+func WithOverflowPolicy[T any](policy OverflowPolicy) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.overflowPolicy = policy
+	}
+}
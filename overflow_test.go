@@ -0,0 +1,86 @@
+package heatwave
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOverflowRejectAllowsUpdates(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2), WithOverflowPolicy[int](OverflowReject))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	if err := b.Nail("c", 3); !errors.Is(err, ErrCacheFull) {
+		t.Fatalf("Nail of a new key into a full bucket = %v, want ErrCacheFull", err)
+	}
+	if exists(b, "c") {
+		t.Fatal("a rejected key was inserted")
+	}
+	if err := b.Nail("a", 10); err != nil {
+		t.Fatalf("Nail of an existing key = %v, want nil", err)
+	}
+	if v, ok := b.Bring("a"); !ok || v != 10 {
+		t.Fatalf("Bring(a) = %d, %v, want 10, true", v, ok)
+	}
+	if !exists(b, "b") {
+		t.Fatal("an update evicted b")
+	}
+
+	// Removing an item frees its reservation
+	_, _ = b.Unnail("b")
+	if err := b.Nail("c", 3); err != nil {
+		t.Fatalf("Nail after a delete = %v, want nil", err)
+	}
+}
+
+func TestOverflowRejectFreesExpiredItems(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithMaxSize[int](2),
+		WithOverflowPolicy[int](OverflowReject),
+	)
+	defer b.Close()
+
+	_ = b.NailWithTTL("a", 1, time.Second)
+	_ = b.Nail("b", 2)
+	clock.Advance(2 * time.Second)
+	if err := b.Nail("c", 3); err != nil {
+		t.Fatalf("Nail with an expired item awaiting cleanup = %v, want nil", err)
+	}
+}
+
+func TestTxnOverflowReject(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](3), WithOverflowPolicy[int](OverflowReject))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	err := b.Txn(func(tx *Tx[int]) error {
+		_ = tx.Set("a", 10)
+		_ = tx.Set("c", 3)
+		return tx.Set("d", 4)
+	})
+	if !errors.Is(err, ErrCacheFull) {
+		t.Fatalf("Txn past capacity = %v, want ErrCacheFull", err)
+	}
+	if v, _ := b.Bring("a"); v != 1 || exists(b, "c") || exists(b, "d") || b.Size() != 2 {
+		t.Fatal("a rejected transaction applied some of its writes")
+	}
+
+	// Deletes in the same transaction make room
+	err = b.Txn(func(tx *Tx[int]) error {
+		tx.Delete("b")
+		_ = tx.Set("c", 3)
+		return tx.Set("d", 4)
+	})
+	if err != nil {
+		t.Fatalf("Txn within capacity = %v, want nil", err)
+	}
+	if b.Size() != 3 || exists(b, "b") {
+		t.Fatalf("Size = %d after the transaction, want 3 without b", b.Size())
+	}
+}
@@ -0,0 +1,102 @@
+package heatwave
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// keyIndexSlack is how many removed entries the key index tolerates before
+// it is worth compacting
+const keyIndexSlack = 64
+
+// keyIndex lists the keys in insertion order for KeysPage
+// Entries of removed items stay behind until the index is compacted, which
+// happens on insert once they outnumber the live keys, so the index stays
+// within twice the bucket size.
+type keyIndex struct {
+	entries []keyEntry // Ordered by seq
+	seq     uint64     // Sequence number of the last insert
+}
+
+// keyEntry is the key of an insert with its sequence number
+type keyEntry struct {
+	seq uint64
+	key string
+}
+
+// indexKeyLocked gives a new item the next sequence number and appends it
+// to the key index
+// Must be called with b.mutex held
+func (b *Bucket[T]) indexKeyLocked(item *CacheItem[T]) {
+	b.keys.seq++
+	item.seq = b.keys.seq
+	b.keys.entries = append(b.keys.entries, keyEntry{seq: item.seq, key: item.key})
+	if n := len(b.keys.entries); n > keyIndexSlack && n > 2*len(b.cache) {
+		b.compactKeyIndexLocked()
+	}
+}
+
+// compactKeyIndexLocked drops the entries of items that are gone
+// Must be called with b.mutex held
+func (b *Bucket[T]) compactKeyIndexLocked() {
+	live := b.keys.entries[:0]
+	for _, e := range b.keys.entries {
+		if item, exists := b.cache[e.key]; exists && item.seq == e.seq {
+			live = append(live, e)
+		}
+	}
+	clear(b.keys.entries[len(live):])
+	b.keys.entries = live
+}
+
+// KeysPage returns up to limit keys and a cursor for the next page
+// Pass an empty cursor to start a scan and the returned cursor to continue
+// it; an empty nextCursor means the scan is complete. Keys are returned in
+// insertion order from an index the bucket maintains, so a page holds the
+// read lock for time proportional to the page, not to the bucket, and open
+// scans cost nothing to keep. Every key that exists for the entire scan is
+// returned exactly once; keys removed during the scan are skipped and keys
+// added during it are returned at the end. A page may hold fewer than limit
+// keys, even none, before the scan is complete when it passes many removed
+// items. A malformed cursor fails with ErrInvalidCursor.
+func (b *Bucket[T]) KeysPage(cursor string, limit int) (keys []string, nextCursor string, err error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("heatwave: invalid page limit %d", limit)
+	}
+	var after uint64
+	if cursor != "" {
+		if after, err = strconv.ParseUint(cursor, 16, 64); err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return nil, "", ErrBucketClosed
+	}
+	if after > b.keys.seq {
+		return nil, "", ErrInvalidCursor
+	}
+
+	entries := b.keys.entries
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].seq > after })
+	// Removed entries count against a budget, so a run of them can't hold
+	// the lock for long
+	budget := max(min(limit, math.MaxInt/4), keyIndexSlack) * 4
+	keys = make([]string, 0, min(limit, len(entries)-i))
+	now := b.now()
+	for ; i < len(entries) && len(keys) < limit && budget > 0; i, budget = i+1, budget-1 {
+		e := entries[i]
+		if item, exists := b.cache[e.key]; exists && item.seq == e.seq && !item.expired(now) {
+			keys = append(keys, e.key)
+		}
+	}
+	if i >= len(entries) {
+		return keys, "", nil
+	}
+	return keys, strconv.FormatUint(entries[i-1].seq, 16), nil
+}
@@ -0,0 +1,133 @@
+package heatwave
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestKeysPageReturnsStableKeysOnce(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](10_000))
+	defer b.Close()
+
+	const stable, volatile = 1000, 500
+	for i := 0; i < stable; i++ {
+		_ = b.Nail("s"+strconv.Itoa(i), i)
+		if i < volatile {
+			_ = b.Nail("v"+strconv.Itoa(i), i)
+		}
+	}
+
+	seen := make(map[string]int)
+	cursor, page := "", 0
+	for {
+		keys, next, err := b.KeysPage(cursor, 50)
+		if err != nil {
+			t.Fatalf("KeysPage: %v", err)
+		}
+		for _, key := range keys {
+			seen[key]++
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+
+		// Mutate the bucket between pages: delete, add and rewrite keys
+		for j := 0; j < 10; j++ {
+			n := page*10 + j
+			_, _ = b.Unnail("v" + strconv.Itoa(n))
+			_ = b.Nail("new"+strconv.Itoa(n), n)
+			_ = b.Nail("s"+strconv.Itoa((n*37)%stable), n)
+		}
+		page++
+	}
+
+	for i := 0; i < stable; i++ {
+		if key := "s" + strconv.Itoa(i); seen[key] != 1 {
+			t.Fatalf("stable key %s returned %d times, want once", key, seen[key])
+		}
+	}
+	for key, n := range seen {
+		if n != 1 {
+			t.Fatalf("key %s returned %d times", key, n)
+		}
+	}
+}
+
+func TestKeysPageCursors(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	for i := 0; i < 10; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+
+	// A limit of MaxInt returns everything in one page without overflowing
+	keys, next, err := b.KeysPage("", math.MaxInt)
+	if err != nil || len(keys) != 10 || next != "" {
+		t.Fatalf("KeysPage with MaxInt = %d keys, %q, %v, want 10 keys and no cursor", len(keys), next, err)
+	}
+	if keys[0] != "0" || keys[9] != "9" {
+		t.Fatalf("keys = %v, want insertion order", keys)
+	}
+
+	for _, cursor := range []string{"not hex", "-1", "ffffffff"} {
+		if _, _, err := b.KeysPage(cursor, 5); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("KeysPage(%q) = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+	if _, _, err := b.KeysPage("", 0); err == nil {
+		t.Fatal("KeysPage with a zero limit succeeded")
+	}
+
+	_ = b.Close()
+	if _, _, err := b.KeysPage("", 5); !errors.Is(err, ErrBucketClosed) {
+		t.Fatalf("KeysPage on a closed bucket = %v, want ErrBucketClosed", err)
+	}
+}
+
+func TestKeysPageAcrossCompaction(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	for i := 0; i < 1000; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+	keys, cursor, _ := b.KeysPage("", 100)
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		seen[key] = true
+	}
+
+	// Removing most keys makes the next insert compact the index
+	for i := 0; i < 1000; i++ {
+		if i%10 != 0 {
+			_, _ = b.Unnail(strconv.Itoa(i))
+		}
+	}
+	_ = b.Nail("late", 0)
+
+	for cursor != "" {
+		var err error
+		keys, cursor, err = b.KeysPage(cursor, 7)
+		if err != nil {
+			t.Fatalf("KeysPage after compaction: %v", err)
+		}
+		for _, key := range keys {
+			if seen[key] {
+				t.Fatalf("key %s returned twice", key)
+			}
+			seen[key] = true
+		}
+	}
+	for i := 0; i < 1000; i += 10 {
+		if !seen[strconv.Itoa(i)] {
+			t.Fatalf("surviving key %d was skipped", i)
+		}
+	}
+	if !seen["late"] {
+		t.Fatal("key added during the scan wasn't returned at the end")
+	}
+}
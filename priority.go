@@ -0,0 +1,174 @@
+package heatwave
+
+import (
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// priorityUpdater evicts items of lower priority before higher ones
+// Priority 0 items stay in the bucket's original updater; every other
+// priority gets its own level built by newLevel. Evict drains the lowest
+// non-empty level first.
+type priorityUpdater[T any] struct {
+	levels     map[int]Updater[T]
+	priorities []int             // Sorted keys of levels
+	base       Updater[T]        // Level of priority 0, the updater that was wrapped
+	newLevel   func() Updater[T] // Builds the updater of a new level
+}
+
+// newPriorityUpdater wraps base as the level for priority 0
+func newPriorityUpdater[T any](base Updater[T], newLevel func() Updater[T]) *priorityUpdater[T] {
+	return &priorityUpdater[T]{
+		levels:     map[int]Updater[T]{0: base},
+		priorities: []int{0},
+		base:       base,
+		newLevel:   newLevel,
+	}
+}
+
+// level returns the updater for priority, creating it if needed
+func (p *priorityUpdater[T]) level(priority int) Updater[T] {
+	if u, exists := p.levels[priority]; exists {
+		return u
+	}
+	u := p.newLevel()
+	p.levels[priority] = u
+	i, _ := slices.BinarySearch(p.priorities, priority)
+	p.priorities = slices.Insert(p.priorities, i, priority)
+	return u
+}
+
+// Bind claims the wrapped updater for the bucket owner
+func (p *priorityUpdater[T]) Bind(owner uint64) error {
+	if bindable, ok := p.base.(BindableUpdater); ok {
+		return bindable.Bind(owner)
+	}
+	return nil
+}
+
+// Unbind releases the claim of owner on the wrapped updater
+func (p *priorityUpdater[T]) Unbind(owner uint64) {
+	if bindable, ok := p.base.(BindableUpdater); ok {
+		bindable.Unbind(owner)
+	}
+}
+
+// seed hands rng to every level that makes random choices
+func (p *priorityUpdater[T]) seed(rng *rand.Rand) {
+	for _, u := range p.levels {
+		if s, ok := u.(seedable); ok {
+			s.seed(rng)
+		}
+	}
+}
+
+// useClock hands now to every level that timestamps accesses
+func (p *priorityUpdater[T]) useClock(now func() time.Time) {
+	for _, u := range p.levels {
+		if c, ok := u.(clocked); ok {
+			c.useClock(now)
+		}
+	}
+}
+
+// Add adds a new item to the level of its priority
+func (p *priorityUpdater[T]) Add(item *CacheItem[T]) {
+	p.level(item.priority).Add(item)
+}
+
+// Access marks an item as accessed within its level
+func (p *priorityUpdater[T]) Access(item *CacheItem[T]) {
+	p.level(item.priority).Access(item)
+}
+
+// Remove removes an item from its level
+func (p *priorityUpdater[T]) Remove(item *CacheItem[T]) {
+	p.level(item.priority).Remove(item)
+}
+
+// Evict returns the eviction candidate of the lowest non-empty level
+func (p *priorityUpdater[T]) Evict() *CacheItem[T] {
+	for _, priority := range p.priorities {
+		u := p.levels[priority]
+		if u.Size() == 0 {
+			continue
+		}
+		if item := u.Evict(); item != nil {
+			return item
+		}
+	}
+	return nil
+}
+
+// Size returns the number of items over all levels
+func (p *priorityUpdater[T]) Size() int {
+	size := 0
+	for _, u := range p.levels {
+		size += u.Size()
+	}
+	return size
+}
+
+// Clear removes all items from every level
+func (p *priorityUpdater[T]) Clear() {
+	for _, u := range p.levels {
+		u.Clear()
+	}
+}
+
+// NailWithPriority stores data with an eviction priority
+// When the bucket is full, items of lower priority are evicted before any
+// item of higher priority; within a priority the usual order applies. Items
+// stored with Nail have priority 0 and negative priorities are evicted
+// first. Rewriting a key with Nail keeps its priority. The first call
+// switches the bucket to priority-aware eviction: priority 0 items keep the
+// configured updater and every other priority gets a fresh updater of the
+// same built-in strategy, or LRU when the updater was set with WithUpdater
+// or SetUpdater.
+// Inspection helpers relying on OrderedUpdater stop reporting an order.
+func (b *Bucket[T]) NailWithPriority(id string, data T, priority int) error {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	b.lockStaged(id, data)
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return err
+	}
+
+	data, err := b.admit(id, data)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := b.updater.(*priorityUpdater[T]); !ok {
+		b.updater = newPriorityUpdater(b.updater, b.newLevelUpdater)
+	}
+	item, err := b.storeLocked(id, data, b.expiryFor(b.outdated), priority)
+	if err != nil {
+		return err
+	}
+	if item.priority != priority {
+		b.updater.Remove(item)
+		item.priority = priority
+		b.updater.Add(item)
+	}
+	return nil
+}
+
+// newLevelUpdater builds the updater of a new priority level, seeded and
+// clocked like the bucket's own
+func (b *Bucket[T]) newLevelUpdater() Updater[T] {
+	var u Updater[T]
+	if b.newUpdater != nil {
+		u = b.newUpdater()
+	} else {
+		u = newLRUUpdater[T]()
+	}
+	b.seedUpdater(u)
+	b.clockUpdater(u)
+	return u
+}
@@ -0,0 +1,74 @@
+package heatwave
+
+import "testing"
+
+func TestPriorityEvictsLowerFirst(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](3))
+	defer b.Close()
+
+	_ = b.NailWithPriority("high", 1, 10)
+	_ = b.Nail("low1", 2)
+	_ = b.Nail("low2", 3)
+	_ = b.NailWithPriority("lowest", 4, -1) // evicts low1
+	_ = b.Nail("low3", 5)                   // evicts lowest
+	_ = b.Nail("low4", 6)                   // evicts low2
+
+	// high is the oldest and least recently used item but outranks the rest
+	for key, want := range map[string]bool{
+		"high": true, "low1": false, "lowest": false, "low2": false, "low3": true, "low4": true,
+	} {
+		if exists(b, key) != want {
+			t.Fatalf("exists(%s) = %v, want %v", key, !want, want)
+		}
+	}
+
+	// Once no lower item is left, higher ones are evicted too
+	_ = b.NailWithPriority("high2", 7, 10)
+	_ = b.NailWithPriority("high3", 8, 10)
+	_ = b.NailWithPriority("high4", 9, 10)
+	if exists(b, "high") || !exists(b, "high4") {
+		t.Fatal("a full bucket of equal priority didn't evict its least recently used item")
+	}
+}
+
+func TestPriorityOfRewrites(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2))
+	defer b.Close()
+
+	_ = b.NailWithPriority("a", 1, 5)
+	_ = b.Nail("a", 2) // keeps priority 5
+	_ = b.Nail("b", 3)
+	_ = b.Nail("c", 4) // evicts b
+	if !exists(b, "a") || exists(b, "b") {
+		t.Fatal("Nail on a prioritized key dropped its priority")
+	}
+
+	_ = b.NailWithPriority("a", 5, -1)
+	_ = b.Nail("d", 6) // evicts a
+	if exists(b, "a") {
+		t.Fatal("NailWithPriority didn't lower the priority of an existing key")
+	}
+}
+
+func TestPriorityLevels(t *testing.T) {
+	b := NewBucket[int](WithFIFOUpdater[int]())
+	defer b.Close()
+
+	_ = b.Nail("zero", 1)
+	_ = b.NailWithPriority("five", 2, 5)
+	p := b.updater.(*priorityUpdater[int])
+	// A new key is added straight to the level of its priority
+	if p.levels[0].Size() != 1 || p.levels[5].Size() != 1 {
+		t.Fatalf("level sizes = %d and %d, want 1 each", p.levels[0].Size(), p.levels[5].Size())
+	}
+	if _, ok := p.levels[5].(*fifo[int]); !ok {
+		t.Fatalf("level 5 uses %T, want the configured FIFO strategy", p.levels[5])
+	}
+
+	custom := NewBucket[int](WithUpdater[int](&stuckUpdater[int]{}))
+	defer custom.Close()
+	_ = custom.NailWithPriority("a", 1, 1)
+	if _, ok := custom.updater.(*priorityUpdater[int]).levels[1].(*lru[int]); !ok {
+		t.Fatal("a custom updater's priority levels don't default to LRU")
+	}
+}
@@ -0,0 +1,52 @@
+package heatwave
+
+// ReadRepair checks a value returned by Bring against its source of truth
+// It returns ok when value is still fresh, otherwise the value to store
+// instead.
+type ReadRepair[T any] func(key string, value T) (fresh T, ok bool)
+
+// startRepair starts a background check of the value Bring returned for
+// version of key, unless one is already running for the key
+func (b *Bucket[T]) startRepair(key string, version uint64, value T) {
+	b.repairMutex.Lock()
+	defer b.repairMutex.Unlock()
+
+	if _, running := b.repairing[key]; running {
+		return
+	}
+	b.repairing[key] = struct{}{}
+	b.spawn(roleReadRepair, func() { b.repair(key, value, version) })
+}
+
+// repair runs the read repair for key and stores the fresh value unless the
+// key was written since it was read
+func (b *Bucket[T]) repair(key string, value T, version uint64) {
+	defer func() {
+		b.repairMutex.Lock()
+		delete(b.repairing, key)
+		b.repairMutex.Unlock()
+	}()
+
+	var fresh T
+	ok := true
+	b.guard(func() { fresh, ok = b.readRepair(key, value) })
+	if ok {
+		return
+	}
+	if _, err := b.NailIfVersion(key, fresh, version); err != nil {
+		b.log(LogDebug, "read repair skipped", "key", key, "err", err)
+	}
+}
+
+// WithReadRepair checks the values returned by Bring hits with validate
+// Bring returns the cached value right away while validate runs on a
+// background goroutine, at most one per key at a time. When validate reports
+// the value stale, the fresh value replaces it with the default TTL, unless
+// the key was written or removed in the meantime. A panic in validate counts
+// as fresh and is recorded in Stats.HookPanics.
+func WithReadRepair[T any](validate ReadRepair[T]) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.readRepair = validate
+		b.repairing = make(map[string]struct{})
+	}
+}
@@ -0,0 +1,57 @@
+package heatwave
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestReadRepair(t *testing.T) {
+	var mutex sync.Mutex
+	source := map[string]int{"a": 2}
+	b := NewBucket[int](
+		WithCleanupDisabled[int](),
+		WithReadRepair(func(key string, value int) (int, bool) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			fresh := source[key]
+			return fresh, fresh == value
+		}),
+	)
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	// The stale value is served right away and repaired in the background
+	if v, _ := b.Bring("a"); v != 1 {
+		t.Fatalf("Bring(a) = %d, want the cached 1", v)
+	}
+	waitFor(t, "read repair", func() bool {
+		v, _ := b.Bring("a")
+		return v == 2
+	})
+	if v, _ := b.Version("a"); v != 2 {
+		t.Fatalf("Version(a) = %d after the repair, want 2", v)
+	}
+}
+
+func TestReadRepairYieldsToWrites(t *testing.T) {
+	release := make(chan struct{})
+	b := NewBucket[int](
+		WithCleanupDisabled[int](),
+		WithReadRepair(func(key string, value int) (int, bool) {
+			<-release
+			return 100, false
+		}),
+	)
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_, _ = b.Bring("a")
+	// A write made while the repair runs wins over the repaired value
+	_ = b.Nail("a", 2)
+	close(release)
+	waitFor(t, "the repair to finish", func() bool { return b.GoroutineCount() == 0 })
+
+	if v, _ := b.Bring("a"); v != 2 {
+		t.Fatalf("Bring(a) = %d, want the newer write 2", v)
+	}
+}
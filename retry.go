@@ -0,0 +1,98 @@
+package heatwave
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy bounds how failed loads are repeated, see WithStoreRetry
+type RetryPolicy struct {
+	Attempts         int           // Tries per load including the first, below 2 disables retries
+	BaseDelay        time.Duration // Wait before the first retry, doubled for every further one
+	MaxDelay         time.Duration // Upper bound of a single wait, zero means none
+	Jitter           float64       // Fraction of each wait that is randomized, from 0 to 1
+	BreakerThreshold int           // Consecutive failed loads that open the breaker, zero disables it
+	BreakerCooldown  time.Duration // How long the open breaker rejects loads before a trial load
+}
+
+// wait returns the randomized wait for the nominal delay d, drawing from
+// random
+func (p *RetryPolicy) wait(d time.Duration, random func() float64) time.Duration {
+	jitter := min(max(p.Jitter, 0), 1)
+	if jitter == 0 {
+		return d
+	}
+	return d - time.Duration(random()*jitter*float64(d))
+}
+
+// randFloat returns a random number in [0, 1) from the bucket's seeded source,
+// or the global one without WithDeterministic
+func (b *Bucket[T]) randFloat() float64 {
+	if b.rng == nil {
+		return rand.Float64()
+	}
+	// The seeded source is shared with eviction and guarded by b.mutex
+	b.lock()
+	defer b.unlock()
+	return b.rng.Float64()
+}
+
+// next returns the nominal delay following d
+func (p *RetryPolicy) next(d time.Duration) time.Duration {
+	d *= 2
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// retrying wraps loader so that failures are retried according to b.retry
+// The waits run on the bucket clock and their jitter comes from the bucket's
+// random source. Waiting stops early when ctx is done, returning the last
+// failure.
+// ErrNotFound is an answer, not a failure, and is never retried.
+func (b *Bucket[T]) retrying(ctx context.Context, loader func() (T, error)) func() (T, error) {
+	policy := b.retry
+	return func() (T, error) {
+		value, err := loader()
+		delay := policy.BaseDelay
+		for attempt := 1; attempt < policy.Attempts && err != nil && !errors.Is(err, ErrNotFound); attempt++ {
+			elapsed, stop := b.after(policy.wait(delay, b.randFloat))
+			select {
+			case <-ctx.Done():
+				stop()
+				return value, err
+			case <-elapsed:
+			}
+			b.counters.loadRetries.Add(1)
+			value, err = loader()
+			delay = policy.next(delay)
+		}
+		return value, err
+	}
+}
+
+// WithStoreRetry retries failed calls to the loader or Source with
+// exponential backoff and jitter, and optionally guards them with a circuit
+// breaker
+// A failed load is retried up to policy.Attempts in total; the whole retried
+// load counts as one outcome for the breaker. While the breaker is open loads
+// fail fast with ErrCircuitOpen, so Bring with WithAutoFill degrades to
+// cache-only. BringContext stops retrying once all of its callers gave up.
+// Retries and breaker openings are counted in Stats, and state changes of the
+// breaker are logged through WithLogger. The bucket has no write-through
+// store, so only reads are retried. Backoff waits run on the clock from
+// WithClock, a ManualClock releases them when advanced, and WithDeterministic
+// makes the jitter reproducible.
+func WithStoreRetry[T any](policy RetryPolicy) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		if policy.Attempts > 1 {
+			b.retry = &policy
+		}
+		if policy.BreakerThreshold > 0 {
+			WithLoaderCircuitBreaker[T](policy.BreakerThreshold, policy.BreakerCooldown)(b)
+		}
+	}
+}
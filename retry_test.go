@@ -0,0 +1,189 @@
+package heatwave
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pendingWaits returns how far the pending After calls of c are from now
+func (c *ManualClock) pendingWaits() []time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	waits := make([]time.Duration, len(c.waiters))
+	for i, w := range c.waiters {
+		waits[i] = w.deadline.Sub(c.now)
+	}
+	return waits
+}
+
+// retryWaits runs a load on b that keeps failing and returns the backoff
+// waits it went through, advancing the clock past each of them
+func retryWaits(t *testing.T, b *Bucket[int], attempts int) []time.Duration {
+	t.Helper()
+	clock := b.Clock().(*ManualClock)
+	var calls atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.GetOrLoad("k", func() (int, error) {
+			calls.Add(1)
+			return 0, errors.New("down")
+		})
+		done <- err
+	}()
+
+	var waits []time.Duration
+	for attempt := 1; attempt < attempts; attempt++ {
+		waitFor(t, "the retry to wait", func() bool { return len(clock.pendingWaits()) == 1 })
+		wait := clock.pendingWaits()[0]
+		waits = append(waits, wait)
+		clock.Advance(wait)
+	}
+	if err := <-done; err == nil {
+		t.Fatal("GetOrLoad succeeded with a failing loader")
+	}
+	if n := calls.Load(); n != int32(attempts) {
+		t.Fatalf("loader called %d times, want %d", n, attempts)
+	}
+	return waits
+}
+
+func TestStoreRetryAttempts(t *testing.T) {
+	b := NewBucket[int](WithDeterministic[int](1), WithStoreRetry[int](RetryPolicy{Attempts: 3}))
+	defer b.Close()
+
+	calls := 0
+	_, err := b.GetOrLoad("a", func() (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("down")
+		}
+		return 7, nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("GetOrLoad = %v after %d calls, want success on the third", err, calls)
+	}
+	if n := b.Stats().LoadRetries; n != 2 {
+		t.Fatalf("LoadRetries = %d, want 2", n)
+	}
+
+	// ErrNotFound is an answer and isn't retried
+	calls = 0
+	_, err = b.GetOrLoad("b", func() (int, error) {
+		calls++
+		return 0, ErrNotFound
+	})
+	if !errors.Is(err, ErrNotFound) || calls != 1 {
+		t.Fatalf("GetOrLoad = %v after %d calls, want ErrNotFound after 1", err, calls)
+	}
+}
+
+func TestStoreRetryBackoff(t *testing.T) {
+	b := NewBucket[int](WithDeterministic[int](1), WithStoreRetry[int](RetryPolicy{
+		Attempts:  5,
+		BaseDelay: time.Second,
+		MaxDelay:  3 * time.Second,
+	}))
+	defer b.Close()
+
+	// Doubling from the base delay up to the cap
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	if waits := retryWaits(t, b, 5); !slices.Equal(waits, want) {
+		t.Fatalf("waits = %v, want %v", waits, want)
+	}
+}
+
+func TestStoreRetryJitterIsSeeded(t *testing.T) {
+	policy := RetryPolicy{Attempts: 4, BaseDelay: time.Second, Jitter: 0.5}
+	run := func(seed int64) []time.Duration {
+		b := NewBucket[int](WithDeterministic[int](seed), WithStoreRetry[int](policy))
+		defer b.Close()
+		return retryWaits(t, b, 4)
+	}
+
+	waits := run(1)
+	for i, wait := range waits {
+		nominal := time.Second << i
+		if wait > nominal || wait < nominal/2 {
+			t.Fatalf("wait %d = %v, want within [%v, %v]", i, wait, nominal/2, nominal)
+		}
+	}
+	if again := run(1); !slices.Equal(again, waits) {
+		t.Fatalf("waits with the same seed = %v, then %v", waits, again)
+	}
+}
+
+func TestStoreRetryStopsOnCancel(t *testing.T) {
+	var calls atomic.Int32
+	b := NewBucket[int](
+		WithDeterministic[int](1),
+		WithStoreRetry[int](RetryPolicy{Attempts: 3, BaseDelay: time.Minute}),
+		WithLoader(func(id string) (int, error) {
+			calls.Add(1)
+			return 0, errors.New("down")
+		}),
+	)
+	defer b.Close()
+	clock := b.Clock().(*ManualClock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.BringContext(ctx, "k")
+		done <- err
+	}()
+	waitFor(t, "the retry to wait", func() bool { return len(clock.pendingWaits()) == 1 })
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("BringContext = %v, want context.Canceled", err)
+	}
+	// Nobody waits any more, so the load gives up instead of retrying
+	waitFor(t, "the load to finish", func() bool { return b.inflightCount() == 0 })
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader called %d times, want 1", n)
+	}
+	if n := b.Stats().LoadRetries; n != 0 {
+		t.Fatalf("LoadRetries = %d, want 0", n)
+	}
+}
+
+func TestStoreRetryWithBreaker(t *testing.T) {
+	b := NewBucket[int](WithDeterministic[int](1), WithStoreRetry[int](RetryPolicy{
+		Attempts:         3,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	}))
+	defer b.Close()
+
+	calls := 0
+	failing := func() (int, error) {
+		calls++
+		return 0, errors.New("down")
+	}
+
+	// A retried load counts once toward the threshold
+	if _, err := b.GetOrLoad("a", failing); err == nil {
+		t.Fatal("GetOrLoad succeeded with a failing loader")
+	}
+	if b.Stats().BreakerOpens != 0 || calls != 3 {
+		t.Fatalf("after one load: BreakerOpens = %d, calls = %d, want 0, 3", b.Stats().BreakerOpens, calls)
+	}
+	if _, err := b.GetOrLoad("b", failing); err == nil {
+		t.Fatal("GetOrLoad succeeded with a failing loader")
+	}
+	if b.Stats().BreakerOpens != 1 || calls != 6 {
+		t.Fatalf("after two loads: BreakerOpens = %d, calls = %d, want 1, 6", b.Stats().BreakerOpens, calls)
+	}
+
+	// The open breaker fails fast, without retries
+	if _, err := b.GetOrLoad("c", failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetOrLoad = %v, want ErrCircuitOpen", err)
+	}
+	if calls != 6 || b.Stats().LoadRetries != 4 {
+		t.Fatalf("calls = %d, LoadRetries = %d, want 6, 4", calls, b.Stats().LoadRetries)
+	}
+}
@@ -0,0 +1,144 @@
+package heatwave
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// bucketIDs hands out bucket identities for updater binding
+var bucketIDs atomic.Uint64
+
+// BindableUpdater is an optional extension of Updater for strategies whose
+// state must belong to a single bucket
+// NewBucketE binds the updater to the new bucket and fails if another bucket
+// holds it, SetUpdater does the same and Close releases it.
+type BindableUpdater interface {
+	// Bind claims the updater for the bucket owner, returning
+	// ErrUpdaterShared if another bucket holds it
+	Bind(owner uint64) error
+	// Unbind releases the claim of owner
+	Unbind(owner uint64)
+}
+
+// UpdaterBinding implements BindableUpdater for embedding in custom updaters
+type UpdaterBinding struct {
+	owner atomic.Uint64 // Zero when unbound
+}
+
+// Bind claims the updater for owner
+func (u *UpdaterBinding) Bind(owner uint64) error {
+	if u.owner.CompareAndSwap(0, owner) || u.owner.Load() == owner {
+		return nil
+	}
+	return ErrUpdaterShared
+}
+
+// Unbind releases the claim of owner, leaving other owners' claims alone
+func (u *UpdaterBinding) Unbind(owner uint64) {
+	u.owner.CompareAndSwap(owner, 0)
+}
+
+// bindUpdater claims u for b if it supports binding
+func (b *Bucket[T]) bindUpdater(u Updater[T]) error {
+	if bindable, ok := u.(BindableUpdater); ok {
+		return bindable.Bind(b.id)
+	}
+	return nil
+}
+
+// unbindUpdater releases the claim of b on u
+func (b *Bucket[T]) unbindUpdater(u Updater[T]) {
+	if bindable, ok := u.(BindableUpdater); ok {
+		bindable.Unbind(b.id)
+	}
+}
+
+// safeUpdater serializes every call to the wrapped updater
+type safeUpdater[T any] struct {
+	mutex sync.Mutex
+	u     Updater[T]
+}
+
+// safeOrderedUpdater is safeUpdater for an OrderedUpdater
+type safeOrderedUpdater[T any] struct {
+	*safeUpdater[T]
+	ordered OrderedUpdater[T]
+}
+
+// SafeUpdater wraps u with its own mutex so that calls from several buckets
+// can't corrupt it
+// Buckets already call their updater under their lock; the wrapper is for an
+// updater deliberately shared between buckets, which then also share its
+// eviction order and size. The wrapper doesn't forward BindableUpdater, so
+// it can be installed in several buckets. An OrderedUpdater stays ordered;
+// Ascend and Descend hold the mutex while fn runs.
+func SafeUpdater[T any](u Updater[T]) Updater[T] {
+	safe := &safeUpdater[T]{u: u}
+	if ordered, ok := u.(OrderedUpdater[T]); ok {
+		return &safeOrderedUpdater[T]{safeUpdater: safe, ordered: ordered}
+	}
+	return safe
+}
+
+// Add adds item under the mutex
+func (s *safeUpdater[T]) Add(item *CacheItem[T]) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.u.Add(item)
+}
+
+// Access marks item as accessed under the mutex
+func (s *safeUpdater[T]) Access(item *CacheItem[T]) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.u.Access(item)
+}
+
+// Remove removes item under the mutex
+func (s *safeUpdater[T]) Remove(item *CacheItem[T]) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.u.Remove(item)
+}
+
+// Evict returns the next victim under the mutex
+func (s *safeUpdater[T]) Evict() *CacheItem[T] {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.u.Evict()
+}
+
+// Size returns the size under the mutex
+func (s *safeUpdater[T]) Size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.u.Size()
+}
+
+// Clear removes all items under the mutex
+func (s *safeUpdater[T]) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.u.Clear()
+}
+
+// Peek returns the next victim under the mutex
+func (s *safeOrderedUpdater[T]) Peek() *CacheItem[T] {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ordered.Peek()
+}
+
+// Ascend walks the eviction order under the mutex
+func (s *safeOrderedUpdater[T]) Ascend(fn func(item *CacheItem[T]) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ordered.Ascend(fn)
+}
+
+// Descend walks the eviction order backwards under the mutex
+func (s *safeOrderedUpdater[T]) Descend(fn func(item *CacheItem[T]) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ordered.Descend(fn)
+}
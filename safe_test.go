@@ -0,0 +1,87 @@
+package heatwave
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestBindableUpdaterRejectsSecondBucket(t *testing.T) {
+	u := &boundUpdater[int]{}
+	first, err := NewBucketE[int](WithUpdater[int](u))
+	if err != nil {
+		t.Fatalf("first NewBucketE = %v", err)
+	}
+	if _, err := NewBucketE[int](WithUpdater[int](u)); !errors.Is(err, ErrUpdaterShared) {
+		t.Fatalf("second NewBucketE = %v, want ErrUpdaterShared", err)
+	}
+
+	other := NewBucket[int]()
+	defer other.Close()
+	if err := other.SetUpdater(u); !errors.Is(err, ErrUpdaterShared) {
+		t.Fatalf("SetUpdater = %v, want ErrUpdaterShared", err)
+	}
+
+	// Closing the owner releases the updater
+	_ = first.Close()
+	second, err := NewBucketE[int](WithUpdater[int](u))
+	if err != nil {
+		t.Fatalf("NewBucketE after the owner closed = %v", err)
+	}
+	_ = second.Close()
+}
+
+func TestSafeUpdaterSharedBetweenBuckets(t *testing.T) {
+	const (
+		writers = 8
+		ops     = 2000
+		keys    = 500
+	)
+	shared := SafeUpdater[int](newLRUUpdater[int]())
+	buckets := []*Bucket[int]{
+		NewBucket[int](WithUpdater[int](shared), WithCleanupDisabled[int](), WithBucketNeverExpire[int]()),
+		NewBucket[int](WithUpdater[int](shared), WithCleanupDisabled[int](), WithBucketNeverExpire[int]()),
+	}
+	for _, b := range buckets {
+		defer b.Close()
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(seed, seed))
+			for i := 0; i < ops; i++ {
+				b := buckets[rng.IntN(len(buckets))]
+				key := strconv.Itoa(rng.IntN(keys))
+				switch rng.IntN(3) {
+				case 0:
+					_ = b.Nail(key, i)
+				case 1:
+					_, _ = b.Bring(key)
+				default:
+					_, _ = b.Unnail(key)
+				}
+			}
+		}(uint64(w))
+	}
+	wg.Wait()
+
+	// The updater holds exactly the items of both buckets; Size reports the
+	// shared updater, so count the bucket maps
+	if got, want := shared.Size(), len(buckets[0].cache)+len(buckets[1].cache); got != want {
+		t.Fatalf("shared updater size = %d, buckets hold %d items", got, want)
+	}
+	ordered := shared.(OrderedUpdater[int])
+	n := 0
+	ordered.Ascend(func(item *CacheItem[int]) bool {
+		n++
+		return true
+	})
+	if n != shared.Size() {
+		t.Fatalf("walked %d items, updater size = %d", n, shared.Size())
+	}
+}
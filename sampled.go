@@ -0,0 +1,115 @@
+package heatwave
+
+import "math/rand/v2"
+
+// defaultSampleSize is the sample size used when a non-positive one is given
+const defaultSampleSize = 5
+
+// sampledEntry tracks an item and the tick of its last access
+type sampledEntry[T any] struct {
+	item       *CacheItem[T]
+	lastAccess uint64
+}
+
+// sampledLRU approximates LRU by sampling, similar to Redis
+// Evict picks sampleSize random items and evicts the least recently used one
+// among them, trading exactness for O(sampleSize) eviction without keeping a
+// global recency order.
+type sampledLRU[T any] struct {
+	entries    []*sampledEntry[T]
+	index      map[*CacheItem[T]]int // Position of each item in entries
+	sampleSize int
+	tick       uint64     // Logical clock incremented on every add and access
+	rng        *rand.Rand // Source of the samples, nil uses the global one
+}
+
+// newSampledLRU creates a new sampled LRU updater
+func newSampledLRU[T any](sampleSize int) *sampledLRU[T] {
+	if sampleSize <= 0 {
+		sampleSize = defaultSampleSize
+	}
+	return &sampledLRU[T]{
+		entries:    make([]*sampledEntry[T], 0),
+		index:      make(map[*CacheItem[T]]int),
+		sampleSize: sampleSize,
+	}
+}
+
+// Add adds a new item as the most recently used one
+func (s *sampledLRU[T]) Add(item *CacheItem[T]) {
+	s.tick++
+	s.index[item] = len(s.entries)
+	s.entries = append(s.entries, &sampledEntry[T]{item: item, lastAccess: s.tick})
+}
+
+// Access records an access to the item
+func (s *sampledLRU[T]) Access(item *CacheItem[T]) {
+	if i, exists := s.index[item]; exists {
+		s.tick++
+		s.entries[i].lastAccess = s.tick
+	}
+}
+
+// Remove removes an item by swapping it with the last entry
+func (s *sampledLRU[T]) Remove(item *CacheItem[T]) {
+	if i, exists := s.index[item]; exists {
+		s.removeAt(i)
+	}
+}
+
+// Evict removes and returns the least recently used item of a random sample
+func (s *sampledLRU[T]) Evict() *CacheItem[T] {
+	if len(s.entries) == 0 {
+		return nil
+	}
+
+	victim := 0
+	if len(s.entries) <= s.sampleSize {
+		// Small enough to be exact
+		for i, e := range s.entries {
+			if e.lastAccess < s.entries[victim].lastAccess {
+				victim = i
+			}
+		}
+	} else {
+		victim = intN(s.rng, len(s.entries))
+		for n := 1; n < s.sampleSize; n++ {
+			i := intN(s.rng, len(s.entries))
+			if s.entries[i].lastAccess < s.entries[victim].lastAccess {
+				victim = i
+			}
+		}
+	}
+
+	item := s.entries[victim].item
+	s.removeAt(victim)
+	return item
+}
+
+// seed makes the samples come from rng
+func (s *sampledLRU[T]) seed(rng *rand.Rand) {
+	s.rng = rng
+}
+
+// Size returns the current size
+func (s *sampledLRU[T]) Size() int {
+	return len(s.entries)
+}
+
+// Clear removes all items from the updater
+func (s *sampledLRU[T]) Clear() {
+	s.entries = make([]*sampledEntry[T], 0)
+	s.index = make(map[*CacheItem[T]]int)
+}
+
+// removeAt removes the entry at position i in O(1)
+func (s *sampledLRU[T]) removeAt(i int) {
+	last := len(s.entries) - 1
+	delete(s.index, s.entries[i].item)
+	if i != last {
+		s.entries[i] = s.entries[last]
+		s.index[s.entries[i].item] = i
+	}
+	s.entries[last] = nil
+	s.entries = s.entries[:last]
+}
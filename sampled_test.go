@@ -0,0 +1,92 @@
+package heatwave
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSampledLRUFollowsRecency(t *testing.T) {
+	const n = 1000
+	var evicted []int
+	b := NewBucket[int](
+		WithDeterministic[int](1),
+		WithMaxSize[int](n),
+		WithSampledLRUUpdater[int](5),
+		WithOnEvict(func(key string, value int, reason RemovalReason) {
+			if reason == ReasonEvicted {
+				evicted = append(evicted, value)
+			}
+		}),
+	)
+	defer b.Close()
+
+	for i := 0; i < n; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+	// Keep the upper half hot, leaving the lower half cold
+	for i := n / 2; i < n; i++ {
+		b.Bring(strconv.Itoa(i))
+	}
+	for i := n; i < n+n/2; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+
+	if len(evicted) != n/2 {
+		t.Fatalf("evicted %d items, want %d", len(evicted), n/2)
+	}
+	cold := 0
+	for _, v := range evicted {
+		if v < n/2 {
+			cold++
+		}
+	}
+	// Exact LRU would evict only cold items; sampling five at a time should
+	// still pick a cold one most of the time
+	if cold < len(evicted)*3/4 {
+		t.Fatalf("only %d of %d evictions hit the cold half", cold, len(evicted))
+	}
+}
+
+func TestSampledLRUSmallBucketIsExact(t *testing.T) {
+	b := NewBucket[int](WithDeterministic[int](1), WithMaxSize[int](3), WithSampledLRUUpdater[int](5))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_ = b.Nail("c", 3)
+	b.Bring("a")
+	_ = b.Nail("d", 4)
+
+	if _, ok := b.Bring("b"); ok {
+		t.Fatal("b should have been evicted as the least recently used item")
+	}
+}
+
+func BenchmarkEviction(b *testing.B) {
+	const size = 100_000
+	for _, tc := range []struct {
+		name string
+		opt  NewBucketOption[int]
+	}{
+		{"LRU", WithUpdater[int](newLRUUpdater[int]())},
+		{"SampledLRU", WithSampledLRUUpdater[int](5)},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			bucket := NewBucket[int](WithMaxSize[int](size), WithCleanupDisabled[int](), tc.opt)
+			defer bucket.Close()
+			for i := 0; i < size; i++ {
+				_ = bucket.Nail(strconv.Itoa(i), i)
+			}
+			keys := make([]string, b.N)
+			for i := range keys {
+				keys[i] = strconv.Itoa(size + i)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Every insert into the full bucket evicts one item
+				_ = bucket.Nail(keys[i], i)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package heatwave
+
+import (
+	"container/heap"
+	"slices"
+	"time"
+)
+
+// preciseSlack is the cadence of WithPreciseExpiry
+const preciseSlack = time.Millisecond
+
+// ExpiryTier sets how closely the cleanup goroutine follows the deadlines of
+// items whose TTL is at most MaxTTL
+type ExpiryTier struct {
+	MaxTTL  time.Duration // Largest TTL in the tier, zero matches every TTL
+	Cadence time.Duration // How late an expiry may be handled, nearby deadlines share one wakeup
+}
+
+// defaultExpiryTiers keeps short-lived items tight and lets long-lived ones
+// batch up
+var defaultExpiryTiers = []ExpiryTier{
+	{MaxTTL: time.Second, Cadence: 10 * time.Millisecond},
+	{MaxTTL: time.Minute, Cadence: 100 * time.Millisecond},
+	{MaxTTL: time.Hour, Cadence: time.Second},
+	{Cadence: 10 * time.Second},
+}
+
+// expiryHeap orders items by deadline, earliest first
+type expiryHeap[T any] []*CacheItem[T]
+
+func (h expiryHeap[T]) Len() int { return len(h) }
+
+func (h expiryHeap[T]) Less(i, j int) bool { return h[i].expiredAt.Before(*h[j].expiredAt) }
+
+func (h expiryHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i + 1
+	h[j].heapIndex = j + 1
+}
+
+func (h *expiryHeap[T]) Push(x any) {
+	item := x.(*CacheItem[T])
+	item.heapIndex = len(*h) + 1
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.heapIndex = 0
+	*h = old[:n-1]
+	return item
+}
+
+// scheduleLocked files item under its current deadline and wakes the cleanup
+// goroutine if the item is now due before its planned wakeup
+// Must be called with b.mutex held after every change of item.expiredAt
+func (b *Bucket[T]) scheduleLocked(item *CacheItem[T]) {
+	switch {
+	case item.expiredAt == nil:
+		if item.heapIndex > 0 {
+			heap.Remove(b.expiries, item.heapIndex-1)
+		}
+		return
+	case item.heapIndex > 0:
+		heap.Fix(b.expiries, item.heapIndex-1)
+	default:
+		heap.Push(b.expiries, item)
+	}
+	if item.heapIndex != 1 {
+		return
+	}
+
+	due := item.expiredAt.Add(b.cadence(item))
+	if !b.wakeAt.IsZero() && !due.Before(b.wakeAt) {
+		return
+	}
+	b.wakeAt = due
+	select {
+	case b.cleanupWake <- struct{}{}:
+	default:
+	}
+}
+
+// unscheduleLocked drops a removed item from the expiry heap
+// The cleanup goroutine isn't woken, an early wakeup just finds nothing due.
+// Must be called with b.mutex held
+func (b *Bucket[T]) unscheduleLocked(item *CacheItem[T]) {
+	if item.heapIndex > 0 {
+		heap.Remove(b.expiries, item.heapIndex-1)
+	}
+}
+
+// rescheduleLocked puts expire candidates that are still cached back into
+// the heap after the interceptor left them undecided
+// Must be called with b.mutex held
+func (b *Bucket[T]) rescheduleLocked(candidates []expireCandidate[T]) {
+	for _, c := range candidates {
+		if item, exists := b.cache[c.key]; exists && item == c.item && item.heapIndex == 0 {
+			b.scheduleLocked(item)
+		}
+	}
+}
+
+// resetExpiriesLocked empties the expiry heap after the cache was replaced
+// Must be called with b.mutex held
+func (b *Bucket[T]) resetExpiriesLocked() {
+	b.expiries = &expiryHeap[T]{}
+	b.intercepting = nil
+}
+
+// cadence returns how late the expiry of item may be handled, based on the
+// tier of its TTL and capped at the cleanup interval
+func (b *Bucket[T]) cadence(item *CacheItem[T]) time.Duration {
+	tiers := b.expiryTiers
+	if tiers == nil {
+		tiers = defaultExpiryTiers
+	}
+	ttl := item.expiredAt.Sub(item.updatedAt)
+	cadence := b.cleanupInterval
+	for _, tier := range tiers {
+		cadence = tier.Cadence
+		if tier.MaxTTL == 0 || ttl <= tier.MaxTTL {
+			break
+		}
+	}
+	return min(cadence, b.cleanupInterval)
+}
+
+// nextWake returns how long the cleanup goroutine sleeps, at most limit
+// The wait is measured on the bucket clock but capped by the real-time
+// limit, so a clock that jumps forward is noticed by the next housekeeping
+// pass and one that jumps back just causes an early wakeup.
+func (b *Bucket[T]) nextWake(limit time.Duration) time.Duration {
+	b.lock()
+	defer b.unlock()
+
+	now := b.now()
+	wait := limit
+	if b.expiries.Len() > 0 && !b.cleanupIsPaused() && !b.frozen.Load() {
+		front := (*b.expiries)[0]
+		wait = max(min(front.expiredAt.Add(b.cadence(front)).Sub(now), limit), 0)
+	}
+	b.wakeAt = now.Add(wait)
+	return wait
+}
+
+// dueExpiry is an item found due during the read phase of cleanup, with the
+// deadline it had then
+type dueExpiry[T any] struct {
+	item      *CacheItem[T]
+	expiredAt *time.Time
+}
+
+// scanExpiriesLocked calls fn for every scheduled item whose deadline is
+// before limit
+// Only the front of the heap is visited: a child never expires before its
+// parent, so subtrees past the limit are skipped. The order is unspecified.
+// Must be called with b.mutex held for reading
+func (b *Bucket[T]) scanExpiriesLocked(limit time.Time, fn func(item *CacheItem[T])) {
+	h := *b.expiries
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if i >= len(h) || !h[i].expiredAt.Before(limit) {
+			continue
+		}
+		fn(h[i])
+		stack = append(stack, 2*i+1, 2*i+2)
+	}
+}
+
+// dueLocked returns the items whose deadline has passed at now, earliest
+// first
+// Must be called with b.mutex held for reading
+func (b *Bucket[T]) dueLocked(now time.Time) []dueExpiry[T] {
+	var due []dueExpiry[T]
+	b.scanExpiriesLocked(now, func(item *CacheItem[T]) {
+		due = append(due, dueExpiry[T]{item: item, expiredAt: item.expiredAt})
+	})
+	slices.SortFunc(due, func(x, y dueExpiry[T]) int {
+		return x.expiredAt.Compare(*y.expiredAt)
+	})
+	return due
+}
+
+// expireDueLocked removes the items of due that are still due at now
+// Items deleted, rewritten or given a new deadline since they were found are
+// left alone; deadlines are replaced rather than modified, so a changed
+// pointer means a Touch or write moved it. With an expire interceptor up to
+// maxInterceptsPerSweep of them are taken off the heap and returned as
+// candidates instead. Must be called with b.mutex held
+func (b *Bucket[T]) expireDueLocked(due []dueExpiry[T], now time.Time) (int, []expireCandidate[T]) {
+	b.intercepting = slices.DeleteFunc(b.intercepting, func(item *CacheItem[T]) bool {
+		return !b.awaitsInterceptLocked(item, now)
+	})
+	removed := 0
+	var candidates []expireCandidate[T]
+	for _, d := range due {
+		item := d.item
+		if b.cache[item.key] != item || item.expiredAt != d.expiredAt || !item.expired(now) {
+			continue
+		}
+		if b.expireInterceptor != nil {
+			if len(candidates) == maxInterceptsPerSweep {
+				break
+			}
+			b.unscheduleLocked(item)
+			b.intercepting = append(b.intercepting, item)
+			candidates = append(candidates, b.candidateLocked(item))
+			continue
+		}
+		b.removeLocked(item, ReasonExpired)
+		removed++
+	}
+	return removed, candidates
+}
+
+// awaitsInterceptLocked reports whether item is an expired item still off
+// the heap while the interceptor decides on it
+// Must be called with b.mutex held for reading
+func (b *Bucket[T]) awaitsInterceptLocked(item *CacheItem[T], now time.Time) bool {
+	return b.cache[item.key] == item && item.heapIndex == 0 && item.expired(now)
+}
+
+// resetTimer stops t, drains a pending tick and re-arms it for d
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+// WithExpiryTiers sets how closely cleanup follows item deadlines per TTL
+// An item uses the first tier whose MaxTTL covers its TTL, or the last tier
+// when none does. Short tiers with a small cadence keep short-lived items
+// tight, while a large cadence for long-lived items lets the cleanup
+// goroutine remove many nearby deadlines in one wakeup. Cadences are capped
+// at the cleanup interval.
+func WithExpiryTiers[T any](tiers ...ExpiryTier) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		if len(tiers) == 0 {
+			b.expiryTiers = nil
+			return
+		}
+		sorted := slices.Clone(tiers)
+		slices.SortStableFunc(sorted, func(x, y ExpiryTier) int {
+			switch {
+			case x.MaxTTL == y.MaxTTL:
+				return 0
+			case x.MaxTTL == 0:
+				return 1
+			case y.MaxTTL == 0:
+				return -1
+			case x.MaxTTL < y.MaxTTL:
+				return -1
+			default:
+				return 1
+			}
+		})
+		b.expiryTiers = sorted
+	}
+}
+
+// WithPreciseExpiry removes items within about a millisecond of their
+// deadline, whatever their TTL
+// It is WithExpiryTiers with a single one-millisecond tier. Deadlines are
+// kept in a min-heap and the cleanup goroutine sleeps until the earliest
+// one, so expire and evict callbacks fire on time without a timer per item.
+// Items due within the same millisecond are removed in one wakeup, so
+// thousands of nearly identical deadlines cost a single pass.
+func WithPreciseExpiry[T any]() NewBucketOption[T] {
+	return WithExpiryTiers[T](ExpiryTier{Cadence: preciseSlack})
+}
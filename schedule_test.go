@@ -0,0 +1,38 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiryIsPromptRegardlessOfCleanupInterval(t *testing.T) {
+	expired := make(chan string, 2)
+	b := NewBucket[int](
+		WithCleanupInterval[int](time.Hour),
+		WithOnExpire(func(key string, value int) {
+			expired <- key
+		}),
+	)
+	defer b.Close()
+
+	// The short-lived item is scheduled after the cleanup goroutine already
+	// went to sleep until the long-lived one's deadline
+	_ = b.NailWithTTL("long", 1, 10*time.Minute)
+	start := time.Now()
+	_ = b.NailWithTTL("short", 2, 20*time.Millisecond)
+
+	select {
+	case key := <-expired:
+		if key != "short" {
+			t.Fatalf("expired %q, want short", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("short-lived item wasn't removed at its deadline")
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("item removed after %v, before its deadline", elapsed)
+	}
+	if b.Size() != 1 {
+		t.Fatalf("Size = %d, want 1", b.Size())
+	}
+}
@@ -0,0 +1,170 @@
+package heatwave
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// ItemView is a read-only view of an item passed to eviction score functions
+type ItemView[T any] struct {
+	Key         string
+	Value       T // Stored value, must not be modified
+	LastAccess  time.Time
+	InsertedAt  time.Time
+	AccessCount uint64 // Accesses since insertion, updates count as accesses
+	Size        int64  // Size measured by the WithMaxValueBytes sizer, zero without one
+}
+
+// scoredEntry tracks an item and its access history
+type scoredEntry[T any] struct {
+	item       *CacheItem[T]
+	insertedAt time.Time
+	lastAccess time.Time
+	accesses   uint64
+}
+
+// scoredUpdater evicts the lowest scoring item of a random sample
+// Like sampledLRU it keeps no global order, so the victim is only
+// approximately the worst item: with sampleSize k it scores below all but a
+// fraction of about 1/(k+1) of the items on average.
+type scoredUpdater[T any] struct {
+	entries    []*scoredEntry[T]
+	index      map[*CacheItem[T]]int // Position of each item in entries
+	sampleSize int
+	score      func(item ItemView[T]) float64
+	sizer      func() func(T) int64 // Returns the bucket's sizer at eviction time
+	rng        *rand.Rand           // Source of the samples, nil uses the global one
+	clock      func() time.Time     // Source of the access timestamps
+}
+
+// newScoredUpdater creates a new scored updater
+func newScoredUpdater[T any](score func(item ItemView[T]) float64, sampleSize int, sizer func() func(T) int64) *scoredUpdater[T] {
+	if sampleSize <= 0 {
+		sampleSize = defaultSampleSize
+	}
+	return &scoredUpdater[T]{
+		entries:    make([]*scoredEntry[T], 0),
+		index:      make(map[*CacheItem[T]]int),
+		sampleSize: sampleSize,
+		score:      score,
+		sizer:      sizer,
+		clock:      time.Now,
+	}
+}
+
+// Add adds a new item
+func (s *scoredUpdater[T]) Add(item *CacheItem[T]) {
+	now := s.clock()
+	s.index[item] = len(s.entries)
+	s.entries = append(s.entries, &scoredEntry[T]{item: item, insertedAt: now, lastAccess: now})
+}
+
+// Access records an access to the item
+func (s *scoredUpdater[T]) Access(item *CacheItem[T]) {
+	if i, exists := s.index[item]; exists {
+		s.entries[i].lastAccess = s.clock()
+		s.entries[i].accesses++
+	}
+}
+
+// Remove removes an item by swapping it with the last entry
+func (s *scoredUpdater[T]) Remove(item *CacheItem[T]) {
+	if i, exists := s.index[item]; exists {
+		s.removeAt(i)
+	}
+}
+
+// Evict removes and returns the lowest scoring item of a random sample
+func (s *scoredUpdater[T]) Evict() *CacheItem[T] {
+	if len(s.entries) == 0 {
+		return nil
+	}
+
+	sizer := s.sizer()
+	victim, worst := -1, 0.0
+	consider := func(i int) {
+		if score := s.score(s.view(s.entries[i], sizer)); victim < 0 || score < worst {
+			victim, worst = i, score
+		}
+	}
+	if len(s.entries) <= s.sampleSize {
+		// Small enough to be exact
+		for i := range s.entries {
+			consider(i)
+		}
+	} else {
+		for n := 0; n < s.sampleSize; n++ {
+			consider(intN(s.rng, len(s.entries)))
+		}
+	}
+
+	item := s.entries[victim].item
+	s.removeAt(victim)
+	return item
+}
+
+// seed makes the samples come from rng
+func (s *scoredUpdater[T]) seed(rng *rand.Rand) {
+	s.rng = rng
+}
+
+// useClock makes the access timestamps come from now
+func (s *scoredUpdater[T]) useClock(now func() time.Time) {
+	s.clock = now
+}
+
+// Size returns the current size
+func (s *scoredUpdater[T]) Size() int {
+	return len(s.entries)
+}
+
+// Clear removes all items from the updater
+func (s *scoredUpdater[T]) Clear() {
+	s.entries = make([]*scoredEntry[T], 0)
+	s.index = make(map[*CacheItem[T]]int)
+}
+
+// view builds the ItemView passed to the score function
+func (s *scoredUpdater[T]) view(e *scoredEntry[T], sizer func(T) int64) ItemView[T] {
+	v := ItemView[T]{
+		Key:         e.item.key,
+		Value:       e.item.value,
+		LastAccess:  e.lastAccess,
+		InsertedAt:  e.insertedAt,
+		AccessCount: e.accesses,
+	}
+	if sizer != nil {
+		v.Size = sizer(e.item.value)
+	}
+	return v
+}
+
+// removeAt removes the entry at position i in O(1)
+func (s *scoredUpdater[T]) removeAt(i int) {
+	last := len(s.entries) - 1
+	delete(s.index, s.entries[i].item)
+	if i != last {
+		s.entries[i] = s.entries[last]
+		s.index[s.entries[i].item] = i
+	}
+	s.entries[last] = nil
+	s.entries = s.entries[:last]
+}
+
+// WithScoredEviction evicts the item with the lowest score when the bucket is
+// full
+// Scoring every item would make eviction O(n), so like Redis the bucket
+// scores sampleSize random items and evicts the worst of them; a
+// non-positive sampleSize uses the default of 5. Larger samples get closer to
+// the exact minimum at a higher eviction cost. score is called with the
+// bucket lock held and must not call back into the bucket.
+func WithScoredEviction[T any](score func(item ItemView[T]) float64, sampleSize int) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.newUpdater = func() Updater[T] {
+			return newScoredUpdater(score, sampleSize, func() func(T) int64 {
+				return b.valueSizer
+			})
+		}
+		b.updater = b.newUpdater()
+	}
+}
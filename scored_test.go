@@ -0,0 +1,97 @@
+package heatwave
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// victimRanks evicts trials times from n items scored by their value, each
+// victim added back, and returns the victims' values
+func victimRanks(n, sampleSize, trials int) []int {
+	s := newScoredUpdater[int](func(item ItemView[int]) float64 {
+		return float64(item.Value)
+	}, sampleSize, func() func(int) int64 { return nil })
+	s.seed(rand.New(rand.NewPCG(1, 2)))
+	for _, item := range newItems(n) {
+		s.Add(item)
+	}
+	ranks := make([]int, trials)
+	for i := range ranks {
+		victim := s.Evict()
+		ranks[i] = victim.value
+		s.Add(victim)
+	}
+	return ranks
+}
+
+func TestScoredEvictionVictimIsBadEnough(t *testing.T) {
+	const n, trials = 1000, 2000
+	mean := func(ranks []int) float64 {
+		sum := 0
+		for _, r := range ranks {
+			sum += r
+		}
+		return float64(sum) / float64(len(ranks)) / n
+	}
+
+	// The victim is the minimum of k uniform samples, so its rank fraction
+	// averages 1/(k+1) and exceeds x with probability (1-x)^k
+	ranks := victimRanks(n, 5, trials)
+	if m := mean(ranks); m < 0.12 || m > 0.22 {
+		t.Fatalf("mean victim rank with 5 samples = %.3f, want about 1/6", m)
+	}
+	high := 0
+	for _, r := range ranks {
+		if r >= n*6/10 {
+			high++
+		}
+	}
+	// (1-0.6)^5 is about 1%
+	if high > trials*3/100 {
+		t.Fatalf("%d of %d victims ranked in the top 40%%, want about 1%%", high, trials)
+	}
+
+	if small, large := mean(victimRanks(n, 2, trials)), mean(victimRanks(n, 20, trials)); large >= small {
+		t.Fatalf("mean victim rank %.3f with 20 samples, not better than %.3f with 2", large, small)
+	}
+}
+
+func TestScoredEvictionItemView(t *testing.T) {
+	var views []ItemView[string]
+	b := NewBucket[string](
+		WithDeterministic[string](1),
+		WithMaxSize[string](2),
+		WithMaxValueBytes[string](1<<10, func(s string) int64 { return int64(len(s)) }),
+		WithScoredEviction[string](func(item ItemView[string]) float64 {
+			views = append(views, item)
+			return float64(item.AccessCount)
+		}, 5),
+	)
+	defer b.Close()
+
+	_ = b.Nail("a", "xx")
+	_ = b.Nail("b", "yyyy")
+	for i := 0; i < 3; i++ {
+		b.Bring("a")
+	}
+	_ = b.Nail("c", "z") // evicts b, accessed least
+
+	if exists(b, "b") || !exists(b, "a") {
+		t.Fatal("scored eviction didn't pick the least accessed item")
+	}
+	if len(views) == 0 {
+		t.Fatal("score function wasn't called")
+	}
+	for _, v := range views {
+		switch v.Key {
+		case "a":
+			if v.AccessCount != 3 || v.Size != 2 || v.Value != "xx" {
+				t.Fatalf("view of a = %+v", v)
+			}
+		case "b":
+			if v.AccessCount != 0 || v.Size != 4 || v.InsertedAt.IsZero() || v.LastAccess != v.InsertedAt {
+				t.Fatalf("view of b = %+v", v)
+			}
+		}
+	}
+}
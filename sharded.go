@@ -0,0 +1,201 @@
+package heatwave
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"reflect"
+)
+
+// defaultShards is the shard count used when a non-positive one is given
+const defaultShards = 16
+
+// ShardedBucket spreads keys over several buckets to reduce lock contention
+// Every shard is a regular Bucket built from its own options; maxSize,
+// the soft limit and the byte budget are totals divided evenly between the
+// shards.
+type ShardedBucket[T any] struct {
+	shards []*Bucket[T]
+	seed   maphash.Seed
+}
+
+// ShardStat holds the statistics of one shard
+type ShardStat struct {
+	Index      int             // Shard index as returned by ShardIndex
+	Contention ContentionStats // Lock wait times, needs WithContentionProfiling
+	Stats
+}
+
+// NewShardedBucket creates a sharded bucket with n shards that all use opts
+// It panics where NewShardedBucketFunc returns an error, in particular when
+// opts hold an instance that can't be shared, such as an updater, append log
+// or spill directory; use NewShardedBucketFunc to give each shard its own.
+func NewShardedBucket[T any](n int, opts ...NewBucketOption[T]) *ShardedBucket[T] {
+	sb, err := NewShardedBucketFunc(n, func(int) []NewBucketOption[T] { return opts })
+	if err != nil {
+		panic(err)
+	}
+	return sb
+}
+
+// NewShardedBucketFunc creates a sharded bucket with n shards, shard i built
+// from shardOpts(i)
+// It fails with ErrShardShared when two shards would share an updater,
+// append log, spill directory or broadcaster, or with the error of a shard
+// that can't be created. Nothing is left running when it fails.
+func NewShardedBucketFunc[T any](n int, shardOpts func(i int) []NewBucketOption[T]) (*ShardedBucket[T], error) {
+	if n <= 0 {
+		n = defaultShards
+	}
+	sb := &ShardedBucket[T]{
+		shards: make([]*Bucket[T], n),
+		seed:   maphash.MakeSeed(),
+	}
+	for i := range sb.shards {
+		shard := configureBucket(shardOpts(i))
+		shard.maxSize = perShard(shard.maxSize, n)
+		shard.softMaxSize = perShard(shard.softMaxSize, n)
+		shard.maxBytes = perShard(shard.maxBytes, n)
+		for _, other := range sb.shards[:i] {
+			if err := shareConflict(shard, other); err != nil {
+				return nil, err
+			}
+		}
+		sb.shards[i] = shard
+	}
+	for i, shard := range sb.shards {
+		if err := shard.start(); err != nil {
+			for _, started := range sb.shards[:i] {
+				_ = started.Close()
+			}
+			return nil, err
+		}
+	}
+	return sb, nil
+}
+
+// shareConflict returns ErrShardShared when a and b share state that must
+// belong to a single bucket
+func shareConflict[T any](a, b *Bucket[T]) error {
+	switch {
+	case sameInstance(a.updater, b.updater):
+		return fmt.Errorf("%w: updater", ErrShardShared)
+	case a.aofPath != "" && a.aofPath == b.aofPath:
+		return fmt.Errorf("%w: append log %s", ErrShardShared, a.aofPath)
+	case a.spillDir != "" && a.spillDir == b.spillDir:
+		return fmt.Errorf("%w: spill directory %s", ErrShardShared, a.spillDir)
+	case a.broadcaster != nil && sameInstance(a.broadcaster, b.broadcaster):
+		return fmt.Errorf("%w: broadcaster", ErrShardShared)
+	}
+	return nil
+}
+
+// sameInstance reports whether a and b refer to the same pointer-like value
+func sameInstance(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() || va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return va.Pointer() == vb.Pointer()
+	}
+	return false
+}
+
+// perShard divides a total limit between n shards, rounding up
+func perShard[N int | int64](total N, n int) N {
+	if total <= 0 {
+		return total
+	}
+	return (total + N(n) - 1) / N(n)
+}
+
+// ShardIndex returns the index of the shard holding id
+func (sb *ShardedBucket[T]) ShardIndex(id string) int {
+	return int(maphash.String(sb.seed, id) % uint64(len(sb.shards)))
+}
+
+// shard returns the shard holding id
+func (sb *ShardedBucket[T]) shard(id string) *Bucket[T] {
+	return sb.shards[sb.ShardIndex(id)]
+}
+
+// Nail stores data in the shard owning id
+func (sb *ShardedBucket[T]) Nail(id string, data T, opts ...NailOption) error {
+	return sb.shard(id).Nail(id, data, opts...)
+}
+
+// Bring retrieves data from the shard owning id
+func (sb *ShardedBucket[T]) Bring(id string) (T, bool) {
+	return sb.shard(id).Bring(id)
+}
+
+// Unnail removes id from the shard owning it
+func (sb *ShardedBucket[T]) Unnail(id string) (bool, error) {
+	return sb.shard(id).Unnail(id)
+}
+
+// Size returns the total size of all shards
+func (sb *ShardedBucket[T]) Size() int {
+	size := 0
+	for _, shard := range sb.shards {
+		size += shard.Size()
+	}
+	return size
+}
+
+// Clear removes all items from every shard
+func (sb *ShardedBucket[T]) Clear() {
+	for _, shard := range sb.shards {
+		shard.Clear()
+	}
+}
+
+// Close closes every shard and returns their errors joined
+func (sb *ShardedBucket[T]) Close() error {
+	var errs []error
+	for _, shard := range sb.shards {
+		if err := shard.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ShardStats returns the statistics of every shard
+// Shards are read one after another, so under concurrent traffic the sum of
+// the shard values may drift slightly from a later Stats call.
+func (sb *ShardedBucket[T]) ShardStats() []ShardStat {
+	out := make([]ShardStat, len(sb.shards))
+	for i, shard := range sb.shards {
+		out[i] = ShardStat{Index: i, Contention: shard.ContentionStats(), Stats: shard.Stats()}
+	}
+	return out
+}
+
+// Stats returns the statistics of all shards summed together
+func (sb *ShardedBucket[T]) Stats() Stats {
+	var total Stats
+	for _, s := range sb.ShardStats() {
+		total.add(s.Stats)
+	}
+	return total
+}
+
+// Balance returns the size of the fullest shard divided by the mean shard
+// size
+// 1 means the keys are spread evenly, the number of shards means they all
+// landed in one. An empty bucket reports 1.
+func (sb *ShardedBucket[T]) Balance() float64 {
+	total, largest := 0, 0
+	for _, shard := range sb.shards {
+		size := shard.Size()
+		total += size
+		largest = max(largest, size)
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(largest) * float64(len(sb.shards)) / float64(total)
+}
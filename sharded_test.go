@@ -0,0 +1,162 @@
+package heatwave
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestShardedBucketSplitsLimits(t *testing.T) {
+	sb := NewShardedBucket[string](4,
+		WithMaxSize[string](10),
+		WithMaxBytes[string](100, func(s string) int64 { return int64(len(s)) }),
+	)
+	defer sb.Close()
+
+	for _, shard := range sb.shards {
+		if shard.maxSize != 3 || shard.maxBytes != 25 {
+			t.Fatalf("shard limits = %d items, %d bytes, want 3 and 25", shard.maxSize, shard.maxBytes)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		_ = sb.Nail(strconv.Itoa(i), "v")
+	}
+	if n := sb.Size(); n > 12 {
+		t.Fatalf("Size = %d, want at most 12", n)
+	}
+}
+
+func TestShardedBucketRejectsSharedState(t *testing.T) {
+	updater := newLRUUpdater[int]()
+	_, err := NewShardedBucketFunc(2, func(int) []NewBucketOption[int] {
+		return []NewBucketOption[int]{WithUpdater[int](updater)}
+	})
+	if !errors.Is(err, ErrShardShared) {
+		t.Fatalf("shared updater = %v, want ErrShardShared", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "shared.aof")
+	_, err = NewShardedBucketFunc(2, func(int) []NewBucketOption[int] {
+		return []NewBucketOption[int]{WithAppendLog[int](path, SyncOSBuffered)}
+	})
+	if !errors.Is(err, ErrShardShared) {
+		t.Fatalf("shared append log = %v, want ErrShardShared", err)
+	}
+
+	dir := t.TempDir()
+	sb, err := NewShardedBucketFunc(2, func(i int) []NewBucketOption[int] {
+		return []NewBucketOption[int]{
+			WithUpdater[int](newLRUUpdater[int]()),
+			WithAppendLog[int](filepath.Join(dir, fmt.Sprintf("shard-%d.aof", i)), SyncOSBuffered),
+		}
+	})
+	if err != nil {
+		t.Fatalf("per-shard state = %v", err)
+	}
+	if err := sb.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+}
+
+// boundUpdater is an updater that can be claimed by one bucket at a time
+type boundUpdater[T any] struct {
+	BaseUpdater[T]
+	UpdaterBinding
+}
+
+func TestShardedBucketFailedShardStopsOthers(t *testing.T) {
+	first, taken := &boundUpdater[int]{}, &boundUpdater[int]{}
+	holder := NewBucket[int](WithUpdater[int](taken))
+	defer holder.Close()
+
+	_, err := NewShardedBucketFunc(2, func(i int) []NewBucketOption[int] {
+		if i == 0 {
+			return []NewBucketOption[int]{WithUpdater[int](first)}
+		}
+		return []NewBucketOption[int]{WithUpdater[int](taken)}
+	})
+	if !errors.Is(err, ErrUpdaterShared) {
+		t.Fatalf("NewShardedBucketFunc = %v, want ErrUpdaterShared", err)
+	}
+	// The shard that had started was closed and released its updater
+	if err := first.Bind(1 << 60); err != nil {
+		t.Fatalf("updater of the started shard still bound: %v", err)
+	}
+}
+
+func TestShardStatsSumToStats(t *testing.T) {
+	sb := NewShardedBucket[int](4)
+	defer sb.Close()
+
+	for i := 0; i < 100; i++ {
+		_ = sb.Nail(strconv.Itoa(i), i)
+	}
+	for i := 0; i < 150; i++ {
+		sb.Bring(strconv.Itoa(i))
+	}
+
+	var size int
+	var hits, misses uint64
+	for i, s := range sb.ShardStats() {
+		if s.Index != i {
+			t.Fatalf("ShardStats()[%d].Index = %d", i, s.Index)
+		}
+		size += s.Size
+		hits += s.Hits
+		misses += s.Misses
+	}
+	total := sb.Stats()
+	if size != 100 || total.Size != size {
+		t.Fatalf("sizes = %d summed, %d aggregate, want 100", size, total.Size)
+	}
+	if hits != 100 || misses != 50 || total.Hits != hits || total.Misses != misses {
+		t.Fatalf("hits/misses = %d/%d summed, %d/%d aggregate, want 100/50", hits, misses, total.Hits, total.Misses)
+	}
+	if b := sb.Balance(); b < 1 || b > 4 {
+		t.Fatalf("Balance = %v, want within [1, 4]", b)
+	}
+}
+
+func TestBalanceReflectsSkew(t *testing.T) {
+	sb := NewShardedBucket[int](4)
+	defer sb.Close()
+
+	// Every key lands in shard 0
+	hot := 0
+	for i := 0; hot < 40; i++ {
+		key := strconv.Itoa(i)
+		if sb.ShardIndex(key) == 0 {
+			_ = sb.Nail(key, i)
+			hot++
+		}
+	}
+	stats := sb.ShardStats()
+	if stats[0].Size != 40 || stats[1].Size != 0 || stats[2].Size != 0 || stats[3].Size != 0 {
+		t.Fatalf("shard sizes = %d %d %d %d, want 40 0 0 0",
+			stats[0].Size, stats[1].Size, stats[2].Size, stats[3].Size)
+	}
+	if b := sb.Balance(); b != 4 {
+		t.Fatalf("Balance = %v with every key in one shard, want 4", b)
+	}
+
+	// Filling the other shards to the same size evens it out
+	sizes := make([]int, 4)
+	for i := 0; sizes[1] < 40 || sizes[2] < 40 || sizes[3] < 40; i++ {
+		key := "k" + strconv.Itoa(i)
+		if shard := sb.ShardIndex(key); shard != 0 && sizes[shard] < 40 {
+			_ = sb.Nail(key, i)
+			sizes[shard]++
+		}
+	}
+	if b := sb.Balance(); b != 1 {
+		t.Fatalf("Balance = %v with equal shards, want 1", b)
+	}
+
+	empty := NewShardedBucket[int](4)
+	defer empty.Close()
+	if b := empty.Balance(); b != 1 {
+		t.Fatalf("Balance of an empty bucket = %v, want 1", b)
+	}
+}
@@ -0,0 +1,604 @@
+package heatwave
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Snapshot header: magic, format version, flags and the time the snapshot
+// was taken
+// Version 4 records item versions; version 3 snapshots, which lack them,
+// are still read.
+const (
+	snapshotMagic      = "HWSNAP"
+	snapshotVersion    = 4
+	snapshotHeaderSize = len(snapshotMagic) + 2 + 8
+)
+
+// Snapshot header flags
+const (
+	snapshotEncrypted byte = 1 << iota // Chunks are sealed with AES-GCM
+)
+
+// snapshotFinal flags the last chunk of a snapshot, so a snapshot cut at a
+// chunk boundary is detected
+const snapshotFinal byte = 1
+
+// snapshotFrameHeader is the size of the length, CRC-32 and flags preceding
+// each chunk
+const snapshotFrameHeader = 4 + 4 + 1
+
+// defaultSnapshotChunkSize is the default target size of a snapshot chunk
+const defaultSnapshotChunkSize = 4 << 20
+
+// snapshotBatchSize is how many items Snapshot copies per read lock
+// acquisition
+const snapshotBatchSize = 1024
+
+// RestoreOption adjusts a single Restore call
+type RestoreOption func(o *restoreOptions)
+
+// restoreOptions holds the per-call settings of a restore
+type restoreOptions struct {
+	resumeAt int
+	onChunk  func(chunk, applied int)
+	policy   MergePolicy      // How conflicts with existing keys are settled
+	resolver MergeResolver    // Settles conflicts instead of policy when set
+	ttl      RestoreTTLPolicy // How restored deadlines are carried over
+}
+
+// RestoreTTLPolicy selects how Restore carries over the deadlines of
+// restored items
+type RestoreTTLPolicy int
+
+const (
+	// RestoreTTLRemaining grants items the time they had left when the
+	// snapshot was taken, counted from the restore, so a restore an hour
+	// later or on a machine with a skewed clock keeps their TTLs
+	RestoreTTLRemaining RestoreTTLPolicy = iota
+	// RestoreTTLAbsolute keeps the wall clock deadlines recorded in the
+	// snapshot
+	RestoreTTLAbsolute
+)
+
+// TTLWith sets how deadlines are carried over, the default is
+// RestoreTTLRemaining
+func TTLWith(policy RestoreTTLPolicy) RestoreOption {
+	return func(o *restoreOptions) {
+		o.ttl = policy
+	}
+}
+
+// deadline returns the deadline of a restored item under the TTL policy,
+// given the time the snapshot was taken and the current time
+func (o *restoreOptions) deadline(expiredAt *time.Time, taken, now time.Time) *time.Time {
+	if expiredAt == nil || o.ttl != RestoreTTLRemaining {
+		return expiredAt
+	}
+	at := now.Add(expiredAt.Sub(taken))
+	return &at
+}
+
+// ResumeAt skips the first chunk chunks of the snapshot, e.g. to resume a
+// restore that failed after OnChunk reported chunk-1 as done
+// Skipped chunks are read but not applied.
+func ResumeAt(chunk int) RestoreOption {
+	return func(o *restoreOptions) {
+		o.resumeAt = chunk
+	}
+}
+
+// OnChunk calls fn after each chunk has been applied with its index, counting
+// from zero, and the number of items it applied
+func OnChunk(fn func(chunk, applied int)) RestoreOption {
+	return func(o *restoreOptions) {
+		o.onChunk = fn
+	}
+}
+
+// Snapshot streams the live items to w
+// The snapshot starts with a header recording the format version and
+// whether it is encrypted, followed by chunks of about the size set with
+// WithSnapshotChunkSize. Each chunk is framed with its length and a CRC-32
+// and holds records in the append log format with values encoded by the
+// bucket codec; the last chunk is flagged, so a truncated snapshot is
+// detected. With WithSnapshotEncryption every chunk is sealed with AES-GCM.
+// The header also records when the snapshot was taken, so Restore can grant
+// items the time they had left rather than their wall clock deadline.
+//
+// Items are copied in batches of the key index, each under a brief read
+// lock, and encoding and writing happen without the lock. This is safe
+// because writes replace an item's value rather than modify it. The
+// snapshot is therefore not point in time: it holds the items inserted
+// before it began that are still live when their batch is copied, each with
+// the value it had then. Memory stays bounded by one batch and one chunk.
+// Spilled values are read back after the lock is released; one removed in
+// the meantime is left out.
+func (b *Bucket[T]) Snapshot(w io.Writer) error {
+	if b.isClosed() {
+		return ErrBucketClosed
+	}
+
+	cursor, taken := b.snapshotCursor()
+	header := snapshotHeader(len(b.sealKeys) > 0, taken)
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	var aead cipher.AEAD
+	if len(b.sealKeys) > 0 {
+		var err error
+		if aead, err = newAEAD(b.sealKeys[0]); err != nil {
+			return err
+		}
+	}
+
+	for index := uint64(0); ; index++ {
+		chunk, err := b.encodeChunk(cursor)
+		if err != nil {
+			return err
+		}
+		more, err := cursor.more()
+		if err != nil {
+			return err
+		}
+		var flags byte
+		if !more {
+			flags = snapshotFinal
+		}
+		if aead != nil {
+			if chunk, err = sealChunk(aead, header, index, flags, chunk); err != nil {
+				return err
+			}
+		}
+		if err := writeChunk(bw, flags, chunk); err != nil {
+			return err
+		}
+		if flags&snapshotFinal != 0 {
+			return bw.Flush()
+		}
+	}
+}
+
+// snapshotCursor walks the key index in batches for Snapshot
+type snapshotCursor[T any] struct {
+	b     *Bucket[T]
+	after uint64         // Sequence number of the last index entry visited
+	last  uint64         // Sequence number of the last insert before the snapshot began
+	items []CacheItem[T] // Shallow copies of the current batch
+	pos   int            // Next item of the batch
+	done  bool           // Whether the index has been walked up to last
+}
+
+// snapshotCursor starts a walk over the items inserted so far and returns
+// it with the time the snapshot is taken
+func (b *Bucket[T]) snapshotCursor() (*snapshotCursor[T], time.Time) {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	return &snapshotCursor[T]{b: b, last: b.keys.seq}, b.now()
+}
+
+// more reports whether items remain, copying the next batch when the
+// current one is used up
+func (c *snapshotCursor[T]) more() (bool, error) {
+	for c.pos == len(c.items) {
+		if c.done {
+			return false, nil
+		}
+		if err := c.fill(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// next returns the next item, more must have reported true
+func (c *snapshotCursor[T]) next() *CacheItem[T] {
+	c.pos++
+	return &c.items[c.pos-1]
+}
+
+// fill copies the next batch of live items under the read lock
+// Removed entries count against a budget so that a run of them can't hold
+// the lock for long; the batch may then be empty.
+func (c *snapshotCursor[T]) fill() error {
+	b := c.b
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return ErrBucketClosed
+	}
+
+	c.items, c.pos = c.items[:0], 0
+	entries := b.keys.entries
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].seq > c.after })
+	now := b.now()
+	for budget := snapshotBatchSize * 4; i < len(entries) && entries[i].seq <= c.last && len(c.items) < snapshotBatchSize && budget > 0; i, budget = i+1, budget-1 {
+		e := entries[i]
+		if item, exists := b.cache[e.key]; exists && item.seq == e.seq && !item.expired(now) {
+			c.items = append(c.items, *item)
+		}
+		c.after = e.seq
+	}
+	c.done = i == len(entries) || entries[i].seq > c.last
+	return nil
+}
+
+// encodeChunk encodes items from cursor until the chunk size is reached
+func (b *Bucket[T]) encodeChunk(cursor *snapshotCursor[T]) ([]byte, error) {
+	size := b.snapshotChunkSize
+	if size <= 0 {
+		size = defaultSnapshotChunkSize
+	}
+	codec := b.codecOrDefault()
+	var chunk []byte
+	for len(chunk) < size {
+		more, err := cursor.more()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+		item := cursor.next()
+		value := item.value
+		if item.spill != nil {
+			if value, err = b.loadSpill(item.spill); err != nil {
+				continue
+			}
+		}
+		encoded, err := codec.Encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("heatwave: encode %q: %w", item.key, err)
+		}
+		chunk = append(chunk, encodeRecord(setRecord(item, encoded))...)
+	}
+	return chunk, nil
+}
+
+// writeChunk frames data as one snapshot chunk
+func writeChunk(w io.Writer, flags byte, data []byte) error {
+	var frame [snapshotFrameHeader]byte
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(data))
+	frame[8] = flags
+	if _, err := w.Write(frame[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// SaveToFile writes a snapshot to path
+// The snapshot is written to a temporary file in the same directory and
+// renamed into place, so path always holds a complete snapshot.
+func (b *Bucket[T]) SaveToFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	err = b.Snapshot(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Restore loads a snapshot written by Snapshot and returns what it did with
+// the items it read
+// The snapshot is read one chunk at a time, so memory stays bounded by the
+// chunk size. Each chunk is verified before any of its items are applied
+// under a single lock acquisition: a malformed or truncated snapshot fails
+// with ErrBadSnapshot and an encrypted one without WithSnapshotEncryption
+// with ErrSnapshotEncrypted. A failure in a later chunk of an unencrypted
+// snapshot leaves the earlier chunks applied; OnChunk and ResumeAt allow
+// picking up where it stopped. An encrypted snapshot is authenticated as a
+// whole before anything is applied, so a wrong key or tampered ciphertext
+// fails with ErrSnapshotDecrypt and leaves the bucket untouched. When r is an
+// io.Seeker this takes a verification pass followed by a second read;
+// otherwise the decrypted chunks are held in memory until all are verified.
+// Restored items keep their original timestamps and versions. Their
+// deadlines follow
+// TTLWith, by default each item gets the time it had left when the snapshot
+// was taken; items already expired under that policy are left out and
+// counted as Expired. A key the bucket already holds is settled by
+// MergeWith or ResolveWith, by default the restored item overwrites it; each
+// key is decided and written under the lock, so the decision can't race a
+// concurrent write.
+func (b *Bucket[T]) Restore(r io.Reader, opts ...RestoreOption) (RestoreStats, error) {
+	var o restoreOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var stats RestoreStats
+	seeker, _ := r.(io.Seeker)
+	var origin int64
+	if seeker != nil {
+		var err error
+		if origin, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seeker = nil
+		}
+	}
+	sr, err := b.newSnapshotReader(r)
+	if err != nil {
+		return stats, err
+	}
+
+	next := sr.next
+	if sr.aeads != nil {
+		// Tampering anywhere must be caught before the first item is applied
+		staged, err := sr.verify(seeker == nil)
+		if err != nil {
+			return stats, err
+		}
+		if seeker != nil {
+			if _, err := seeker.Seek(origin, io.SeekStart); err != nil {
+				return stats, err
+			}
+			if sr, err = b.newSnapshotReader(r); err != nil {
+				return stats, err
+			}
+			next = sr.next
+		} else {
+			next = func(bool) ([]byte, bool, error) {
+				data := staged[0]
+				staged = staged[1:]
+				return data, len(staged) == 0, nil
+			}
+		}
+	}
+
+	for chunk := 0; ; chunk++ {
+		skip := chunk < o.resumeAt
+		data, final, err := next(skip)
+		if err != nil {
+			return stats, err
+		}
+		if !skip {
+			cs, err := b.applyChunk(data, sr.taken, &o)
+			stats.add(cs)
+			if err != nil {
+				return stats, err
+			}
+			if o.onChunk != nil {
+				o.onChunk(chunk, cs.Applied())
+			}
+		}
+		if final {
+			return stats, nil
+		}
+	}
+}
+
+// applyChunk parses the records of a chunk and applies them under the lock
+func (b *Bucket[T]) applyChunk(data []byte, taken time.Time, o *restoreOptions) (RestoreStats, error) {
+	var stats RestoreStats
+	var records []logRecord
+	_, torn, err := readRecords(bytes.NewReader(data), nil, func(rec logRecord) error {
+		if rec.op != logOpSet {
+			return fmt.Errorf("%w: unexpected operation %d", ErrBadSnapshot, rec.op)
+		}
+		records = append(records, rec)
+		return nil
+	})
+	if errors.Is(err, ErrCorruptLog) {
+		return stats, fmt.Errorf("%w: corrupt record", ErrBadSnapshot)
+	}
+	if err != nil {
+		return stats, err
+	}
+	if torn {
+		return stats, fmt.Errorf("%w: corrupt record", ErrBadSnapshot)
+	}
+
+	b.lock()
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return stats, err
+	}
+
+	codec := b.codecOrDefault()
+	now := b.now()
+	for _, rec := range records {
+		rec.expiredAt = o.deadline(rec.expiredAt, taken, now)
+		if rec.expiredAt != nil && now.After(*rec.expiredAt) {
+			stats.Expired++
+			continue
+		}
+		existing, exists := b.cache[rec.key]
+		if exists && !existing.expired(now) {
+			if o.resolver != nil {
+				stats.Resolved++
+			}
+			if o.decide(rec.key, entryMeta(existing), recordMeta(rec)) != DecisionReplace {
+				stats.Skipped++
+				continue
+			}
+		} else {
+			exists = false
+		}
+		ok, err := b.applyRecordLocked(rec, codec)
+		if err != nil {
+			return stats, fmt.Errorf("heatwave: restore %q: %w", rec.key, err)
+		}
+		switch {
+		case !ok:
+			stats.Skipped++
+		case exists:
+			stats.Overwritten++
+		default:
+			stats.Inserted++
+		}
+	}
+	return stats, nil
+}
+
+// LoadFromFile restores the snapshot at path, see Restore
+func (b *Bucket[T]) LoadFromFile(path string, opts ...RestoreOption) (RestoreStats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return RestoreStats{}, err
+	}
+	defer file.Close()
+	return b.Restore(file, opts...)
+}
+
+// snapshotReader reads the chunks of a snapshot
+type snapshotReader struct {
+	r      *bufio.Reader
+	header []byte
+	aeads  []cipher.AEAD // Candidate keys, nil for an unencrypted snapshot
+	aead   cipher.AEAD   // Key that opened the first chunk
+	index  uint64
+	taken  time.Time // When the snapshot was taken
+}
+
+// newSnapshotReader reads and checks the snapshot header
+func (b *Bucket[T]) newSnapshotReader(r io.Reader) (*snapshotReader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, snapshotHeaderSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("%w: short header", ErrBadSnapshot)
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrBadSnapshot)
+	}
+	if version := header[len(snapshotMagic)]; version != snapshotVersion && version != 3 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrBadSnapshot, version)
+	}
+
+	taken := fromUnixNano(int64(binary.BigEndian.Uint64(header[len(snapshotMagic)+2:])))
+	sr := &snapshotReader{r: br, header: header, taken: taken}
+	if header[len(snapshotMagic)+1]&snapshotEncrypted != 0 {
+		if len(b.sealKeys) == 0 {
+			return nil, ErrSnapshotEncrypted
+		}
+		aeads, err := newAEADs(b.sealKeys)
+		if err != nil {
+			return nil, err
+		}
+		sr.aeads = aeads
+	}
+	return sr, nil
+}
+
+// next reads the next chunk and returns its records, decrypted and verified
+// unless skip is set
+func (sr *snapshotReader) next(skip bool) ([]byte, bool, error) {
+	var frame [snapshotFrameHeader]byte
+	if _, err := io.ReadFull(sr.r, frame[:]); err != nil {
+		return nil, false, sr.corrupt(fmt.Sprintf("missing chunk %d", sr.index), err)
+	}
+	size := int64(binary.BigEndian.Uint32(frame[0:4]))
+	flags := frame[8]
+	if flags&^snapshotFinal != 0 {
+		return nil, false, sr.corrupt(fmt.Sprintf("bad flags in chunk %d", sr.index), nil)
+	}
+	index := sr.index
+	sr.index++
+	final := flags&snapshotFinal != 0
+
+	if skip {
+		if _, err := io.CopyN(io.Discard, sr.r, size); err != nil {
+			return nil, false, sr.corrupt(fmt.Sprintf("truncated chunk %d", index), err)
+		}
+		return nil, final, nil
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(sr.r, data); err != nil {
+		return nil, false, sr.corrupt(fmt.Sprintf("truncated chunk %d", index), err)
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(frame[4:8]) {
+		return nil, false, sr.corrupt(fmt.Sprintf("checksum mismatch in chunk %d", index), nil)
+	}
+	if sr.aeads == nil {
+		return data, final, nil
+	}
+
+	if sr.aead != nil {
+		plaintext, err := openChunk(sr.aead, sr.header, index, flags, data)
+		return plaintext, final, err
+	}
+	var err error
+	for _, aead := range sr.aeads {
+		var plaintext []byte
+		if plaintext, err = openChunk(aead, sr.header, index, flags, data); err == nil {
+			sr.aead = aead
+			return plaintext, final, nil
+		}
+	}
+	return nil, false, err
+}
+
+// verify reads and authenticates the remaining chunks, returning their
+// records when keep is set
+func (sr *snapshotReader) verify(keep bool) ([][]byte, error) {
+	var staged [][]byte
+	for {
+		data, final, err := sr.next(false)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			staged = append(staged, data)
+		}
+		if final {
+			return staged, nil
+		}
+	}
+}
+
+// corrupt returns the error for a damaged snapshot, ErrSnapshotDecrypt for an
+// encrypted one since tampering shows up as damage
+func (sr *snapshotReader) corrupt(msg string, err error) error {
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	if sr.aeads != nil {
+		return fmt.Errorf("%w: %s", ErrSnapshotDecrypt, msg)
+	}
+	return fmt.Errorf("%w: %s", ErrBadSnapshot, msg)
+}
+
+// snapshotHeader returns the header of a snapshot
+func snapshotHeader(encrypted bool, taken time.Time) []byte {
+	var flags byte
+	if encrypted {
+		flags |= snapshotEncrypted
+	}
+	header := append([]byte(snapshotMagic), snapshotVersion, flags)
+	return binary.BigEndian.AppendUint64(header, uint64(unixNano(taken)))
+}
+
+// WithSnapshotChunkSize sets the target size in bytes of snapshot chunks
+// A chunk is closed once it reaches size, so a single large item may exceed
+// it. Larger chunks mean fewer, larger writes, smaller ones less memory. A non-positive size uses the default of 4 MiB.
+func WithSnapshotChunkSize[T any](size int) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.snapshotChunkSize = size
+	}
+}
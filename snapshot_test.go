@@ -0,0 +1,296 @@
+package heatwave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshotKeepsVersions(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := b.NailVersioned("a", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = b.NailVersioned("b", 1)
+
+	var buf bytes.Buffer
+	if err := b.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	r := NewBucket[int]()
+	defer r.Close()
+	if _, err := r.Restore(&buf); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if v, ok := r.Version("a"); !ok || v != 3 {
+		t.Fatalf("Version(a) = %d, %v after restore, want 3, true", v, ok)
+	}
+	if v, ok := r.Version("b"); !ok || v != 1 {
+		t.Fatalf("Version(b) = %d, %v after restore, want 1, true", v, ok)
+	}
+	if v, err := r.NailIfVersion("a", 10, 3); err != nil || v != 4 {
+		t.Fatalf("NailIfVersion(a, 3) = %d, %v, want 4, nil", v, err)
+	}
+}
+
+// paddedCodec encodes an int followed by pad filler bytes, so a small bucket
+// produces a snapshot as large as one holding big values
+type paddedCodec struct {
+	pad int
+}
+
+func (c paddedCodec) Encode(value int) ([]byte, error) {
+	data := make([]byte, 8+c.pad)
+	binary.BigEndian.PutUint64(data, uint64(value))
+	return data, nil
+}
+
+func (c paddedCodec) Decode(data []byte) (int, error) {
+	if len(data) != 8+c.pad {
+		return 0, fmt.Errorf("value of %d bytes, want %d", len(data), 8+c.pad)
+	}
+	return int(binary.BigEndian.Uint64(data)), nil
+}
+
+// countingWriter counts the bytes written through it and tracks the peak
+// heap while they flow
+type countingWriter struct {
+	w        io.Writer
+	n        int64
+	writes   int
+	peakHeap uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	if c.writes++; c.writes%64 == 0 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		c.peakHeap = max(c.peakHeap, ms.HeapAlloc)
+	}
+	return c.w.Write(p)
+}
+
+func TestSnapshotStreamsLargeBucket(t *testing.T) {
+	if testing.Short() {
+		t.Skip("streams 256 MiB through a snapshot")
+	}
+	const (
+		items = 4 << 10
+		pad   = 64 << 10 // 256 MiB of encoded values in total
+	)
+	codec := paddedCodec{pad: pad}
+	opts := []NewBucketOption[int]{WithMaxSize[int](items), WithCodec[int](codec), WithSnapshotChunkSize[int](1 << 20)}
+	b := NewBucket[int](opts...)
+	defer b.Close()
+	for i := 0; i < items; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+	r := NewBucket[int](opts...)
+	defer r.Close()
+
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	base := ms.HeapAlloc
+
+	// The snapshot is restored as it is written, it is never held in memory
+	pr, pw := io.Pipe()
+	cw := &countingWriter{w: pw}
+	go func() {
+		pw.CloseWithError(b.Snapshot(cw))
+	}()
+	stats, err := r.Restore(pr)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if cw.n < items*pad {
+		t.Fatalf("snapshot wrote %d bytes, want at least %d", cw.n, items*pad)
+	}
+	if stats.Inserted != items || r.Size() != items {
+		t.Fatalf("Inserted = %d, Size = %d, want %d", stats.Inserted, r.Size(), items)
+	}
+	if v, _ := r.Bring("1234"); v != 1234 {
+		t.Fatalf("Bring(1234) = %d, want 1234", v)
+	}
+	if grown := cw.peakHeap - min(base, cw.peakHeap); grown > 64<<20 {
+		t.Fatalf("heap grew by %d MiB while streaming a %d MiB snapshot", grown>>20, cw.n>>20)
+	}
+}
+
+func TestRestoreResumeAt(t *testing.T) {
+	b := NewBucket[int](WithSnapshotChunkSize[int](64))
+	defer b.Close()
+	for i := 0; i < 100; i++ {
+		_ = b.Nail(strconv.Itoa(i), i)
+	}
+	var buf bytes.Buffer
+	if err := b.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// The first attempt breaks off in the middle of the stream
+	r := NewBucket[int]()
+	defer r.Close()
+	last := -1
+	_, err := r.Restore(bytes.NewReader(data[:len(data)/2]), OnChunk(func(chunk, applied int) {
+		last = chunk
+	}))
+	if !errors.Is(err, ErrBadSnapshot) {
+		t.Fatalf("Restore of a truncated snapshot = %v, want ErrBadSnapshot", err)
+	}
+	if last < 1 {
+		t.Fatalf("last applied chunk = %d, want several chunks", last)
+	}
+	partial := r.Size()
+	if partial == 0 || partial == 100 {
+		t.Fatalf("Size = %d after a partial restore", partial)
+	}
+
+	stats, err := r.Restore(bytes.NewReader(data), ResumeAt(last+1))
+	if err != nil {
+		t.Fatalf("resumed Restore: %v", err)
+	}
+	if stats.Overwritten != 0 || stats.Inserted != 100-partial {
+		t.Fatalf("resumed Restore = %+v, want %d inserts and no overwrites", stats, 100-partial)
+	}
+	if r.Size() != 100 {
+		t.Fatalf("Size = %d, want 100", r.Size())
+	}
+}
+
+func TestClearSnapshotStress(t *testing.T) {
+	const keys = 500
+	b := NewBucket[int](WithMaxSize[int](keys / 2))
+	defer b.Close()
+
+	// Values encode their key, so a torn read or write shows up as a mismatch
+	check := func(key string, value int) {
+		if strconv.Itoa(value%keys) != key {
+			t.Errorf("%s holds %d", key, value)
+		}
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				fn(i)
+			}
+		}()
+	}
+	for w := 0; w < 4; w++ {
+		run(func(i int) {
+			k := (i*7 + w) % keys
+			_ = b.Nail(strconv.Itoa(k), i*keys+k)
+		})
+		run(func(i int) {
+			key := strconv.Itoa((i*13 + w) % keys)
+			if v, ok := b.Bring(key); ok {
+				check(key, v)
+			}
+		})
+	}
+	run(func(i int) {
+		b.Clear()
+		time.Sleep(time.Millisecond)
+	})
+
+	waitFor(t, "writers to start", func() bool { return b.Size() > 0 })
+	for deadline := time.Now().Add(200 * time.Millisecond); time.Now().Before(deadline); {
+		var buf bytes.Buffer
+		if err := b.Snapshot(&buf); err != nil {
+			t.Fatalf("Snapshot: %v", err)
+		}
+		r := NewBucket[int](WithMaxSize[int](keys))
+		if _, err := r.Restore(&buf); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		r.Range(func(key string, value int) bool {
+			check(key, value)
+			return true
+		})
+		_ = r.Close()
+	}
+	close(stop)
+	wg.Wait()
+
+	if err := b.CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreTTLWithClockSkew(t *testing.T) {
+	src := NewManualClock(time.Unix(1000, 0))
+	b := NewBucket[int](WithClock[int](src), WithCleanupDisabled[int](), WithBucketNeverExpire[int]())
+	defer b.Close()
+	_ = b.NailWithTTL("short", 1, 10*time.Second)
+	_ = b.NailWithTTL("long", 2, time.Hour)
+	_ = b.Nail("forever", 3)
+
+	var buf bytes.Buffer
+	if err := b.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// The restoring machine's clock runs 30 minutes ahead
+	restore := func(opts ...RestoreOption) (*Bucket[int], *ManualClock, RestoreStats) {
+		t.Helper()
+		dst := NewManualClock(time.Unix(1000, 0).Add(30 * time.Minute))
+		r := NewBucket[int](WithClock[int](dst), WithCleanupDisabled[int]())
+		stats, err := r.Restore(bytes.NewReader(data), opts...)
+		if err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		return r, dst, stats
+	}
+
+	r, dst, stats := restore()
+	defer r.Close()
+	if stats.Inserted != 3 || stats.Expired != 0 {
+		t.Fatalf("Restore = %+v, want 3 inserted", stats)
+	}
+	info, _ := r.ItemInfo("short")
+	if want := dst.Now().Add(10 * time.Second); !info.ExpiresAt.Equal(want) {
+		t.Fatalf("short expires at %v, want %v", info.ExpiresAt, want)
+	}
+	dst.Advance(11 * time.Second)
+	if _, ok := r.Bring("short"); ok {
+		t.Fatal("short outlived the time it had left")
+	}
+
+	a, _, stats := restore(TTLWith(RestoreTTLAbsolute))
+	defer a.Close()
+	if stats.Inserted != 2 || stats.Expired != 1 {
+		t.Fatalf("absolute Restore = %+v, want 2 inserted and 1 expired", stats)
+	}
+	info, _ = a.ItemInfo("long")
+	if want := time.Unix(1000, 0).Add(time.Hour); !info.ExpiresAt.Equal(want) {
+		t.Fatalf("long expires at %v, want the recorded %v", info.ExpiresAt, want)
+	}
+	if _, ok := a.Bring("forever"); !ok {
+		t.Fatal("item without a deadline wasn't restored")
+	}
+}
@@ -0,0 +1,51 @@
+package heatwave
+
+// Source is a secondary store the bucket reads through on a miss
+type Source[T any] interface {
+	// Fetch returns the value for id and whether it exists
+	Fetch(id string) (T, bool, error)
+}
+
+// sourceLoader adapts the bucket's Source to a Loader
+// A value the source doesn't have is reported as ErrNotFound.
+func (b *Bucket[T]) sourceLoader(id string) (T, error) {
+	value, found, err := b.source.Fetch(id)
+	if err != nil {
+		return value, err
+	}
+	if !found {
+		return value, ErrNotFound
+	}
+	return value, nil
+}
+
+// fill loads a missing id through the loader or source for Bring
+func (b *Bucket[T]) fill(id string) (T, bool) {
+	loader := b.resolveLoader()
+	if loader == nil {
+		var zero T
+		return zero, false
+	}
+	value, err := b.load(id, func() (T, error) {
+		return loader(id)
+	})
+	return value, err == nil
+}
+
+// WithSource sets a Source used by Load when no loader is configured, and by
+// Bring when WithAutoFill is enabled
+func WithSource[T any](s Source[T]) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.source = s
+	}
+}
+
+// WithAutoFill makes Bring fill misses from the loader or Source, turning the
+// bucket into a read-through cache
+// Loaded values are stored before Bring returns them. Load errors are
+// reported as a miss.
+func WithAutoFill[T any]() NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.autoFill = true
+	}
+}
@@ -0,0 +1,65 @@
+package heatwave
+
+import (
+	"errors"
+	"testing"
+)
+
+// mapSource is a Source backed by a map that counts fetches
+type mapSource struct {
+	values  map[string]int
+	err     error
+	fetches int
+}
+
+func (s *mapSource) Fetch(id string) (int, bool, error) {
+	s.fetches++
+	if s.err != nil {
+		return 0, false, s.err
+	}
+	v, ok := s.values[id]
+	return v, ok, nil
+}
+
+func TestSourceReadThrough(t *testing.T) {
+	src := &mapSource{values: map[string]int{"a": 1}}
+	b := NewBucket[int](WithSource[int](src), WithAutoFill[int]())
+	defer b.Close()
+
+	if v, ok := b.Bring("a"); !ok || v != 1 {
+		t.Fatalf("Bring(a) = %d, %v, want 1, true", v, ok)
+	}
+	// The fetched value was cached
+	if v, ok := b.Bring("a"); !ok || v != 1 || src.fetches != 1 {
+		t.Fatalf("second Bring(a) = %d, %v after %d fetches, want a cache hit", v, ok, src.fetches)
+	}
+	if _, ok := b.Bring("missing"); ok {
+		t.Fatal("Bring of a key the source lacks reported a hit")
+	}
+	if exists(b, "missing") {
+		t.Fatal("a missing source key was cached")
+	}
+}
+
+func TestSourceErrorIsMiss(t *testing.T) {
+	src := &mapSource{err: errors.New("store down")}
+	b := NewBucket[int](WithSource[int](src), WithAutoFill[int]())
+	defer b.Close()
+
+	if _, ok := b.Bring("a"); ok {
+		t.Fatal("Bring reported a hit although the source failed")
+	}
+	if src.fetches != 1 {
+		t.Fatalf("fetches = %d, want 1", src.fetches)
+	}
+}
+
+func TestSourceWithoutAutoFill(t *testing.T) {
+	src := &mapSource{values: map[string]int{"a": 1}}
+	b := NewBucket[int](WithSource[int](src))
+	defer b.Close()
+
+	if _, ok := b.Bring("a"); ok || src.fetches != 0 {
+		t.Fatalf("Bring without WithAutoFill = %v after %d fetches, want a plain miss", ok, src.fetches)
+	}
+}
@@ -0,0 +1,276 @@
+package heatwave
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// spillPattern names spill files, leftovers are removed on startup
+const spillPattern = "*.spill"
+
+// spillFile is the on-disk location of a spilled value
+type spillFile struct {
+	path string
+	size int64 // Encoded size on disk
+}
+
+// spillStats tracks the spilled entries of a bucket
+type spillStats struct {
+	entries int
+	bytes   int64
+}
+
+// storeValueLocked sets the value of item, moving it to the spill file
+// staged for the write if there is one
+// Must be called with b.mutex held after accountLocked
+func (b *Bucket[T]) storeValueLocked(item *CacheItem[T], data T) {
+	b.dropSpillLocked(item)
+	item.value = data
+	spill := b.stagedSpill
+	if spill == nil {
+		return
+	}
+	b.stagedSpill = nil
+
+	var zero T
+	item.value = zero
+	item.spill = spill
+	b.spilled.entries++
+	b.spilled.bytes += spill.size
+	// The value no longer takes memory, so it leaves the byte budget
+	b.totalBytes -= item.size
+	item.size = 0
+}
+
+// stageSpill writes data to a new spill file when it exceeds the spill
+// threshold and returns the file, or nil to keep data in memory
+// It doesn't touch the bucket state, so writers call it before taking the
+// lock. A failed spill is logged and keeps the value in memory.
+func (b *Bucket[T]) stageSpill(id string, data T) *spillFile {
+	if b.spillDir == "" || !b.exceedsSpill(data) {
+		return nil
+	}
+	encoded, err := b.codecOrDefault().Encode(data)
+	if err != nil {
+		b.log(LogError, "spill encode failed", "key", id, "err", err)
+		return nil
+	}
+	if b.valueSizer == nil && int64(len(encoded)) <= b.spillThreshold {
+		return nil
+	}
+	path, err := writeSpill(b.spillDir, encoded)
+	if err != nil {
+		b.log(LogError, "spill write failed", "key", id, "err", err)
+		return nil
+	}
+	return &spillFile{path: path, size: int64(len(encoded))}
+}
+
+// lockStaged spills data for a write of id and takes the write lock with the
+// file staged, so the encoding and file write happen outside the lock
+// The next storeValueLocked takes the file over; unlock deletes it when the
+// write doesn't happen.
+func (b *Bucket[T]) lockStaged(id string, data T) {
+	spill := b.stageSpill(id, data)
+	b.lock()
+	b.stagedSpill = spill
+}
+
+// stageLocked stages spill for the next storeValueLocked, deleting a file
+// staged earlier that wasn't taken over
+// Must be called with b.mutex held
+func (b *Bucket[T]) stageLocked(spill *spillFile) {
+	if b.stagedSpill != nil {
+		b.removeSpill(b.stagedSpill)
+	}
+	b.stagedSpill = spill
+}
+
+// stageSpills spills the values of n writes that go into the bucket under a
+// single lock acquisition, write returns the key and value of the i-th one
+// or false when it stores nothing
+// A nil entry keeps its value in memory, the result is nil without spilling.
+func (b *Bucket[T]) stageSpills(n int, write func(i int) (string, T, bool)) []*spillFile {
+	if b.spillDir == "" {
+		return nil
+	}
+	spills := make([]*spillFile, n)
+	for i := range spills {
+		if id, data, ok := write(i); ok {
+			spills[i] = b.stageSpill(id, data)
+		}
+	}
+	return spills
+}
+
+// removeSpills deletes the staged files that weren't taken over
+func (b *Bucket[T]) removeSpills(spills []*spillFile) {
+	for _, spill := range spills {
+		if spill != nil {
+			b.removeSpill(spill)
+		}
+	}
+}
+
+// exceedsSpill reports whether data may be over the spill threshold
+// The sizer decides when there is one, otherwise the encoded size does.
+func (b *Bucket[T]) exceedsSpill(data T) bool {
+	return b.valueSizer == nil || b.valueSizer(data) > b.spillThreshold
+}
+
+// writeSpill writes encoded to a new spill file in dir and returns its path
+// Partially written files are removed.
+func writeSpill(dir string, encoded []byte) (string, error) {
+	f, err := os.CreateTemp(dir, spillPattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(encoded)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// valueOf returns the value of item, reading it back from disk if spilled
+// Reads that hold the lock anyway use it, a spill file that can't be read is
+// logged and yields the zero value. Bring reads spilled values with
+// readUnlock instead.
+func (b *Bucket[T]) valueOf(item *CacheItem[T]) T {
+	if item.spill == nil {
+		return item.value
+	}
+	value, err := b.loadSpill(item.spill)
+	if err != nil {
+		b.log(LogError, "spill read failed", "key", item.key, "err", err)
+	}
+	return value
+}
+
+// readUnlock returns the value of item, found live under the write lock, and
+// releases the lock
+// A spilled value is read back after the lock is released, so a slow disk
+// doesn't block the bucket. A spill file that can't be read counts as a miss:
+// the item and its file are dropped and ok is false.
+func (b *Bucket[T]) readUnlock(item *CacheItem[T]) (value T, ok bool) {
+	for {
+		spill := item.spill
+		if spill == nil {
+			value = b.readValue(item)
+			b.unlock()
+			return value, true
+		}
+		b.unlock()
+
+		value, err := b.loadSpill(spill)
+		if err == nil {
+			// The value was decoded just now, so nobody else holds it
+			return value, true
+		}
+
+		b.lock()
+		current, exists := b.cache[item.key]
+		if exists && current == item && item.spill == spill {
+			b.log(LogError, "spill read failed, dropping the item", "key", item.key, "err", err)
+			b.counters.hits.Add(^uint64(0))
+			b.counters.misses.Add(1)
+			b.removeLocked(item, ReasonDeleted)
+			b.unlock()
+			var zero T
+			return zero, false
+		}
+		// The key was written or removed while the file was read, which
+		// deletes the old file, so read what is there now
+		if !exists || current.expired(b.expiryNow()) {
+			b.unlock()
+			var zero T
+			return zero, false
+		}
+		item = current
+	}
+}
+
+// loadSpill reads and decodes a spilled value
+func (b *Bucket[T]) loadSpill(spill *spillFile) (T, error) {
+	var zero T
+	encoded, err := os.ReadFile(spill.path)
+	if err != nil {
+		return zero, err
+	}
+	return b.codecOrDefault().Decode(encoded)
+}
+
+// dropSpillLocked deletes the spill file of item, if any
+// Must be called with b.mutex held
+func (b *Bucket[T]) dropSpillLocked(item *CacheItem[T]) {
+	if item.spill == nil {
+		return
+	}
+	b.removeSpill(item.spill)
+	b.spilled.entries--
+	b.spilled.bytes -= item.spill.size
+	item.spill = nil
+}
+
+// removeSpill deletes a spill file, logging failures
+func (b *Bucket[T]) removeSpill(spill *spillFile) {
+	if err := os.Remove(spill.path); err != nil && !os.IsNotExist(err) {
+		b.log(LogWarn, "spill remove failed", "path", spill.path, "err", err)
+	}
+}
+
+// dropAllSpillsLocked deletes the spill files of every cached item before the
+// cache is discarded, must be called with b.mutex held
+func (b *Bucket[T]) dropAllSpillsLocked() {
+	if b.spilled.entries == 0 {
+		return
+	}
+	for _, item := range b.cache {
+		b.dropSpillLocked(item)
+	}
+}
+
+// openSpill creates the spill directory and deletes spill files left behind
+// by an earlier process
+func (b *Bucket[T]) openSpill() {
+	if err := os.MkdirAll(b.spillDir, 0o755); err != nil {
+		b.log(LogError, "spill directory create failed", "dir", b.spillDir, "err", err)
+		return
+	}
+	paths, err := filepath.Glob(filepath.Join(b.spillDir, spillPattern))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			b.log(LogWarn, "stale spill remove failed", "path", path, "err", err)
+		}
+	}
+}
+
+// WithSpillover keeps values larger than threshold bytes on disk
+// Such values are encoded with the bucket codec and written to a file in dir;
+// the item keeps only the path and its metadata, and Bring reads the file
+// back transparently. Files are deleted when their item is evicted, expired,
+// replaced or deleted and when the bucket is cleared or closed. Spilled
+// values don't count toward WithMaxBytes. With a sizer from WithMaxBytes or
+// WithMaxValueBytes the sizer picks the candidates, otherwise every value is
+// encoded to measure it.
+//
+// Values are encoded and written before the write takes the bucket lock, and
+// Bring reads them back after releasing it. dir must belong to this bucket
+// alone: NewBucket removes the spill files it finds there, which are
+// leftovers of a crashed process. A spill file that Bring can't read is
+// logged and counts as a miss, its item is dropped. BringRef returns a
+// pointer to a fresh copy for spilled values, and WithScoredEviction sees
+// their zero value.
+func WithSpillover[T any](dir string, threshold int64) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.spillDir = dir
+		b.spillThreshold = threshold
+	}
+}
@@ -0,0 +1,223 @@
+package heatwave
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// spillFiles returns the spill files in dir
+func spillFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, spillPattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func newSpillBucket(t *testing.T, dir string, opts ...NewBucketOption[string]) *Bucket[string] {
+	t.Helper()
+	opts = append([]NewBucketOption[string]{WithSpillover[string](dir, 16)}, opts...)
+	b := NewBucket[string](opts...)
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func TestSpilloverReadBack(t *testing.T) {
+	dir := t.TempDir()
+	b := newSpillBucket(t, dir)
+	big := strings.Repeat("x", 100)
+
+	if err := b.Nail("big", big); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Nail("small", "y"); err != nil {
+		t.Fatal(err)
+	}
+	if files := spillFiles(t, dir); len(files) != 1 {
+		t.Fatalf("%d spill files, want 1", len(files))
+	}
+
+	if value, ok := b.Bring("big"); !ok || value != big {
+		t.Fatalf("Bring(big) = %q, %v", value, ok)
+	}
+	if ref, ok := b.BringRef("big"); !ok || *ref != big {
+		t.Fatalf("BringRef(big) = %v, %v", ref, ok)
+	}
+	if value, ok := b.Bring("small"); !ok || value != "y" {
+		t.Fatalf("Bring(small) = %q, %v", value, ok)
+	}
+
+	stats := b.Stats()
+	// The JSON encoding adds the quotes
+	if stats.Spilled != 1 || stats.SpilledBytes != int64(len(big)+2) {
+		t.Fatalf("Spilled = %d, SpilledBytes = %d, want 1, %d", stats.Spilled, stats.SpilledBytes, len(big)+2)
+	}
+}
+
+func TestSpilloverBatchWrites(t *testing.T) {
+	dir := t.TempDir()
+	b := newSpillBucket(t, dir)
+	big := strings.Repeat("x", 100)
+
+	if err := b.Warm([]WarmEntry[string]{{Key: "a", Value: big}, {Key: "b", Value: "y"}}); err != nil {
+		t.Fatal(err)
+	}
+	err := b.Txn(func(tx *Tx[string]) error {
+		tx.Set("c", big)
+		tx.Set("d", "y")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if files := spillFiles(t, dir); len(files) != 2 {
+		t.Fatalf("%d spill files, want 2", len(files))
+	}
+	for _, key := range []string{"a", "c"} {
+		if value, ok := b.Bring(key); !ok || value != big {
+			t.Fatalf("Bring(%s) = %q, %v", key, value, ok)
+		}
+	}
+}
+
+func TestSpilloverRemovesFiles(t *testing.T) {
+	big := strings.Repeat("x", 100)
+
+	tests := []struct {
+		name   string
+		opts   []NewBucketOption[string]
+		remove func(t *testing.T, b *Bucket[string], clock *ManualClock)
+	}{
+		{
+			name: "evict",
+			opts: []NewBucketOption[string]{WithMaxSize[string](1)},
+			remove: func(t *testing.T, b *Bucket[string], _ *ManualClock) {
+				if err := b.Nail("other", "y"); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "expire",
+			opts: []NewBucketOption[string]{WithBucketExpire[string](time.Minute)},
+			remove: func(t *testing.T, b *Bucket[string], clock *ManualClock) {
+				clock.Advance(time.Hour)
+				if removed := b.CleanupNow(); removed != 1 {
+					t.Fatalf("CleanupNow removed %d, want 1", removed)
+				}
+			},
+		},
+		{
+			name: "replace",
+			remove: func(t *testing.T, b *Bucket[string], _ *ManualClock) {
+				if err := b.Nail("big", "y"); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "delete",
+			remove: func(t *testing.T, b *Bucket[string], _ *ManualClock) {
+				if _, err := b.Unnail("big"); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "close",
+			remove: func(t *testing.T, b *Bucket[string], _ *ManualClock) {
+				b.Close()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			clock := NewManualClock(time.Unix(0, 0))
+			opts := append([]NewBucketOption[string]{WithClock[string](clock), WithDeterministic[string](1)}, tt.opts...)
+			b := newSpillBucket(t, dir, opts...)
+
+			if err := b.Nail("big", big); err != nil {
+				t.Fatal(err)
+			}
+			if files := spillFiles(t, dir); len(files) != 1 {
+				t.Fatalf("%d spill files, want 1", len(files))
+			}
+			tt.remove(t, b, clock)
+			if files := spillFiles(t, dir); len(files) != 0 {
+				t.Fatalf("spill files left: %v", files)
+			}
+			if stats := b.Stats(); stats.Spilled != 0 || stats.SpilledBytes != 0 {
+				t.Fatalf("Spilled = %d, SpilledBytes = %d, want 0", stats.Spilled, stats.SpilledBytes)
+			}
+		})
+	}
+}
+
+func TestSpilloverRefusedWriteRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+	b := newSpillBucket(t, dir)
+
+	// The value is spilled before the deadline is checked under the lock
+	err := b.NailUntil("big", strings.Repeat("x", 100), time.Now().Add(-time.Hour))
+	if err != ErrDeadlinePassed {
+		t.Fatalf("NailUntil = %v, want ErrDeadlinePassed", err)
+	}
+	if files := spillFiles(t, dir); len(files) != 0 {
+		t.Fatalf("spill files left: %v", files)
+	}
+}
+
+func TestSpilloverRemovesLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	leftover := filepath.Join(dir, "1234.spill")
+	other := filepath.Join(dir, "notes.txt")
+	for _, path := range []string{leftover, other} {
+		if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newSpillBucket(t, dir)
+
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Fatalf("leftover spill file still there: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("unrelated file removed: %v", err)
+	}
+}
+
+func TestSpilloverUnreadableFileIsMiss(t *testing.T) {
+	dir := t.TempDir()
+	b := newSpillBucket(t, dir)
+
+	if err := b.Nail("big", strings.Repeat("x", 100)); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range spillFiles(t, dir) {
+		if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if value, ok := b.Bring("big"); ok {
+		t.Fatalf("Bring = %q, true for an unreadable spill file", value)
+	}
+	if exists(b, "big") {
+		t.Fatal("item with an unreadable spill file kept")
+	}
+	if files := spillFiles(t, dir); len(files) != 0 {
+		t.Fatalf("spill files left: %v", files)
+	}
+	stats := b.Stats()
+	if stats.Hits != 0 || stats.Misses != 1 || stats.Spilled != 0 {
+		t.Fatalf("Hits = %d, Misses = %d, Spilled = %d, want 0, 1, 0", stats.Hits, stats.Misses, stats.Spilled)
+	}
+}
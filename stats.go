@@ -0,0 +1,196 @@
+package heatwave
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time view of bucket counters
+type Stats struct {
+	Hits          uint64 // Bring calls that found a live item
+	Misses        uint64 // Bring calls that found nothing or an expired item
+	Evictions     uint64 // Items removed to make room for new ones
+	Expirations   uint64 // Items removed because their TTL passed
+	Size          int    // Items held, including expired ones not yet cleaned up
+	LiveSize      int    // Items held that have not expired
+	Bytes         int64  // Total value size, only tracked with a sizer
+	Spilled       int    // Items whose value is spilled to disk
+	SpilledBytes  int64  // On-disk size of the spilled values
+	HookPanics    uint64 // Panics recovered from user hooks
+	PublishErrors uint64 // Invalidation events the broadcaster failed to publish
+	LoadRetries   uint64 // Loader calls repeated under WithStoreRetry
+	BreakerOpens  uint64 // Times the loader circuit breaker opened
+
+	// Latency counters, only populated with WithLatencyMetrics
+	NailCount  uint64        // Timed writes: the Nail variants and Txn commits
+	NailTime   time.Duration // Total time spent in timed writes
+	BringCount uint64        // Timed Bring calls
+	BringTime  time.Duration // Total time spent in timed Bring calls
+}
+
+// add accumulates other into s
+func (s *Stats) add(other Stats) {
+	s.Hits += other.Hits
+	s.Misses += other.Misses
+	s.Evictions += other.Evictions
+	s.Expirations += other.Expirations
+	s.Size += other.Size
+	s.LiveSize += other.LiveSize
+	s.Bytes += other.Bytes
+	s.Spilled += other.Spilled
+	s.SpilledBytes += other.SpilledBytes
+	s.HookPanics += other.HookPanics
+	s.PublishErrors += other.PublishErrors
+	s.LoadRetries += other.LoadRetries
+	s.BreakerOpens += other.BreakerOpens
+	s.NailCount += other.NailCount
+	s.NailTime += other.NailTime
+	s.BringCount += other.BringCount
+	s.BringTime += other.BringTime
+}
+
+// AvgNail returns the average Nail latency, zero when nothing was timed
+func (s Stats) AvgNail() time.Duration {
+	if s.NailCount == 0 {
+		return 0
+	}
+	return s.NailTime / time.Duration(s.NailCount)
+}
+
+// AvgBring returns the average Bring latency, zero when nothing was timed
+func (s Stats) AvgBring() time.Duration {
+	if s.BringCount == 0 {
+		return 0
+	}
+	return s.BringTime / time.Duration(s.BringCount)
+}
+
+// counters holds the bucket's atomic statistics counters
+type counters struct {
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	evictions     atomic.Uint64
+	expirations   atomic.Uint64
+	hookPanics    atomic.Uint64
+	publishErrors atomic.Uint64
+	loadRetries   atomic.Uint64
+	breakerOpens  atomic.Uint64
+
+	nailCount  atomic.Uint64
+	nailNanos  atomic.Int64
+	bringCount atomic.Uint64
+	bringNanos atomic.Int64
+}
+
+// reset zeroes every counter
+func (c *counters) reset() {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.evictions.Store(0)
+	c.expirations.Store(0)
+	c.hookPanics.Store(0)
+	c.publishErrors.Store(0)
+	c.loadRetries.Store(0)
+	c.breakerOpens.Store(0)
+	c.nailCount.Store(0)
+	c.nailNanos.Store(0)
+	c.bringCount.Store(0)
+	c.bringNanos.Store(0)
+}
+
+// addNail records the latency of one Nail call
+func (c *counters) addNail(d time.Duration) {
+	c.nailCount.Add(1)
+	c.nailNanos.Add(int64(d))
+}
+
+// addBring records the latency of one Bring call
+func (c *counters) addBring(d time.Duration) {
+	c.bringCount.Add(1)
+	c.bringNanos.Add(int64(d))
+}
+
+// Stats returns the current statistics of the bucket
+func (b *Bucket[T]) Stats() Stats {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	s := Stats{
+		Hits:          b.counters.hits.Load(),
+		Misses:        b.counters.misses.Load(),
+		Evictions:     b.counters.evictions.Load(),
+		Expirations:   b.counters.expirations.Load(),
+		HookPanics:    b.counters.hookPanics.Load(),
+		PublishErrors: b.counters.publishErrors.Load(),
+		LoadRetries:   b.counters.loadRetries.Load(),
+		BreakerOpens:  b.counters.breakerOpens.Load(),
+		NailCount:     b.counters.nailCount.Load(),
+		NailTime:      time.Duration(b.counters.nailNanos.Load()),
+		BringCount:    b.counters.bringCount.Load(),
+		BringTime:     time.Duration(b.counters.bringNanos.Load()),
+	}
+	if b.isClosed() {
+		return s
+	}
+	s.Size = b.updater.Size()
+	s.LiveSize = b.liveSizeLocked()
+	s.Bytes = b.totalBytes
+	s.Spilled = b.spilled.entries
+	s.SpilledBytes = b.spilled.bytes
+	return s
+}
+
+// ResetStats zeroes all statistics counters without touching cached items
+// Counters updated under the bucket lock are reset together, so a
+// measurement window started after ResetStats sees a consistent zero state.
+func (b *Bucket[T]) ResetStats() {
+	b.lock()
+	defer b.unlock()
+
+	b.counters.reset()
+	if b.latency != nil {
+		b.latency.reset()
+	}
+	if b.contention != nil {
+		b.contention.reset()
+	}
+}
+
+// LiveSize returns the number of items that have not expired
+// Unlike Size it excludes expired items the cleanup goroutine hasn't removed
+// yet. Only the due front of the expiry heap is visited, so the cost grows
+// with the number of such items rather than with the size of the bucket.
+func (b *Bucket[T]) LiveSize() int {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return 0
+	}
+	return b.liveSizeLocked()
+}
+
+// liveSizeLocked counts unexpired items
+// Every item with a deadline is on the expiry heap except those handed to
+// the expire interceptor, so the expired ones are the due front of the heap
+// plus the pending intercepts. Must be called with b.mutex held
+func (b *Bucket[T]) liveSizeLocked() int {
+	now := b.now()
+	live := len(b.cache)
+	b.scanExpiriesLocked(now, func(*CacheItem[T]) { live-- })
+	for _, item := range b.intercepting {
+		if b.awaitsInterceptLocked(item, now) {
+			live--
+		}
+	}
+	return live
+}
+
+// WithLatencyMetrics times Nail and Bring and reports the totals in Stats
+// Every Nail variant and Txn commit counts as a Nail. Timing uses the
+// monotonic clock and is skipped entirely when not enabled.
+func WithLatencyMetrics[T any]() NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.latencyMetrics = true
+	}
+}
@@ -0,0 +1,153 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyMetrics(t *testing.T) {
+	b := NewBucket[int](WithLatencyMetrics[int]())
+	defer b.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := b.Nail("k", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.NailWithTTL("t", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	b.Bring("k")
+	b.Bring("missing")
+
+	s := b.Stats()
+	if s.NailCount != 4 {
+		t.Fatalf("NailCount = %d, want 4", s.NailCount)
+	}
+	if s.BringCount != 2 {
+		t.Fatalf("BringCount = %d, want 2", s.BringCount)
+	}
+	if s.NailTime <= 0 || s.BringTime <= 0 {
+		t.Fatalf("NailTime = %v, BringTime = %v, want both positive", s.NailTime, s.BringTime)
+	}
+	if s.AvgNail() != s.NailTime/4 {
+		t.Fatalf("AvgNail = %v, want %v", s.AvgNail(), s.NailTime/4)
+	}
+
+	before := s.NailTime
+	_ = b.Nail("k", 9)
+	if after := b.Stats().NailTime; after <= before {
+		t.Fatalf("NailTime didn't accumulate: %v then %v", before, after)
+	}
+}
+
+func TestLatencyMetricsDisabled(t *testing.T) {
+	b := NewBucket[int]()
+	defer b.Close()
+
+	_ = b.Nail("k", 1)
+	b.Bring("k")
+	if s := b.Stats(); s.NailCount != 0 || s.BringCount != 0 || s.NailTime != 0 {
+		t.Fatalf("untimed bucket reported %+v", s)
+	}
+}
+
+func TestResetStats(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_ = b.Nail("c", 3) // evicts a
+	b.Bring("b")
+	b.Bring("a")
+
+	if s := b.Stats(); s.Hits != 1 || s.Misses != 1 || s.Evictions != 1 {
+		t.Fatalf("Stats before reset = %+v", s)
+	}
+
+	b.ResetStats()
+	s := b.Stats()
+	if s.Hits != 0 || s.Misses != 0 || s.Evictions != 0 || s.Expirations != 0 {
+		t.Fatalf("counters after ResetStats = %+v, want zero", s)
+	}
+	if s.Size != 2 {
+		t.Fatalf("Size after ResetStats = %d, want 2", s.Size)
+	}
+	if v, ok := b.Bring("c"); !ok || v != 3 {
+		t.Fatalf("Bring(c) after ResetStats = %d, %v", v, ok)
+	}
+	if s := b.Stats(); s.Hits != 1 {
+		t.Fatalf("Hits after one more Bring = %d, want 1", s.Hits)
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](4))
+
+	if u := b.Utilization(); u != 0 {
+		t.Fatalf("Utilization of an empty bucket = %v, want 0", u)
+	}
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	if u := b.Utilization(); u != 0.5 {
+		t.Fatalf("Utilization when half full = %v, want 0.5", u)
+	}
+	for _, key := range []string{"c", "d", "e"} {
+		_ = b.Nail(key, 0)
+	}
+	if u := b.Utilization(); u != 1 {
+		t.Fatalf("Utilization when full = %v, want 1", u)
+	}
+	_ = b.Close()
+	if u := b.Utilization(); u != 0 {
+		t.Fatalf("Utilization of a closed bucket = %v, want 0", u)
+	}
+
+	for _, size := range []int{0, -1} {
+		z := NewBucket[int](WithMaxSize[int](size))
+		_ = z.Nail("a", 1)
+		if u := z.Utilization(); u != 0 {
+			t.Fatalf("Utilization with maxSize %d = %v, want 0", size, u)
+		}
+		_ = z.Close()
+	}
+}
+
+func TestLiveSizeExcludesExpired(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []NewBucketOption[int]
+	}{
+		{"Plain", nil},
+		{"Interceptor", []NewBucketOption[int]{
+			WithExpireInterceptor(func(key string, value int) (time.Duration, bool) {
+				return time.Minute, key == "kept"
+			}),
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := NewManualClock(time.Unix(0, 0))
+			b := NewBucket[int](append([]NewBucketOption[int]{WithClock[int](clock), WithCleanupDisabled[int]()}, tc.opts...)...)
+			defer b.Close()
+
+			_ = b.Nail("live", 1)
+			_ = b.NailWithTTL("kept", 2, time.Second)
+			_ = b.NailWithTTL("gone", 3, time.Second)
+			clock.Advance(2 * time.Second)
+
+			if b.Size() != 3 || b.LiveSize() != 1 || b.Stats().LiveSize != 1 {
+				t.Fatalf("Size = %d, LiveSize = %d, Stats().LiveSize = %d, want 3, 1, 1",
+					b.Size(), b.LiveSize(), b.Stats().LiveSize)
+			}
+			b.CleanupNow()
+			want := 1
+			if tc.opts != nil {
+				want = 2 // The interceptor kept one
+			}
+			if b.Size() != want || b.LiveSize() != want {
+				t.Fatalf("after cleanup Size = %d, LiveSize = %d, want %d", b.Size(), b.LiveSize(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package heatwave
+
+import "time"
+
+// Touch resets the expiry of id to the bucket default TTL
+// It reports whether id was present and unexpired. The value and the
+// eviction order are left untouched.
+func (b *Bucket[T]) Touch(id string) bool {
+	return b.TouchMany([]string{id}) == 1
+}
+
+// TouchMany resets the expiry of every present, unexpired key in ids to the
+// bucket default TTL under a single lock acquisition
+// It returns how many keys were touched; missing and expired keys are skipped.
+// WithMaxLifetime still caps the new expiry.
+func (b *Bucket[T]) TouchMany(ids []string) int {
+	b.lock()
+	defer b.unlock()
+
+	if b.writable() != nil {
+		return 0
+	}
+
+	now := b.now()
+	touched := 0
+	for _, id := range ids {
+		item, exists := b.cache[id]
+		if !exists || item.expired(now) {
+			continue
+		}
+		item.expiredAt = b.capLifetime(item.createdAt, b.expiryFor(b.outdated))
+		b.scheduleLocked(item)
+		b.logExpiryLocked(item)
+		touched++
+	}
+	return touched
+}
+
+// BringAndExtend retrieves data like Bring and, when id is live, moves its
+// expiry to now plus by
+// Unlike a sliding TTL it only affects this call. The new expiry replaces
+// the old one even if that was later, and is still capped by
+// WithMaxLifetime. A non-positive by or a frozen bucket leaves the expiry as
+// it was.
+func (b *Bucket[T]) BringAndExtend(id string, by time.Duration) (T, bool) {
+	item := b.lockAccess(id)
+	if item == nil {
+		b.unlock()
+		var zero T
+		return zero, false
+	}
+	if by > 0 && b.writable() == nil {
+		expiredAt := b.now().Add(by)
+		item.expiredAt = b.capLifetime(item.createdAt, &expiredAt)
+		b.scheduleLocked(item)
+		b.logExpiryLocked(item)
+	}
+	return b.readUnlock(item)
+}
+
+// DefaultTTL returns the TTL applied by Nail, zero when items never expire
+func (b *Bucket[T]) DefaultTTL() time.Duration {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.outdated == nil {
+		return 0
+	}
+	return *b.outdated
+}
+
+// SetDefaultTTL changes the TTL applied by subsequent writes
+// A zero or negative d means items never expire, as with WithBucketExpire.
+// With refreshExisting set, live items are rescheduled so that they keep the
+// fraction of the old default TTL they had left, capped at the whole new TTL;
+// items that never expired get the full new TTL when the old default was
+// never expire. A closed bucket fails with ErrBucketClosed, and a frozen one
+// with ErrBucketFrozen when refreshExisting is set; the default TTL is left
+// as it was then.
+func (b *Bucket[T]) SetDefaultTTL(d time.Duration, refreshExisting bool) error {
+	b.lock()
+	defer b.unlock()
+
+	if b.isClosed() {
+		return ErrBucketClosed
+	}
+	if refreshExisting && b.frozen.Load() {
+		return ErrBucketFrozen
+	}
+
+	old := b.outdated
+	if d > 0 {
+		b.outdated = &d
+	} else {
+		b.outdated = nil
+	}
+	if !refreshExisting {
+		return nil
+	}
+
+	now := b.now()
+	for _, item := range b.cache {
+		if item.expired(now) {
+			continue
+		}
+		switch {
+		case b.outdated == nil:
+			item.expiredAt = b.capLifetime(item.createdAt, nil)
+		case item.expiredAt == nil:
+			if old == nil {
+				item.expiredAt = b.capLifetime(item.createdAt, b.expiryFor(b.outdated))
+			}
+		case old != nil:
+			fraction := min(float64(item.expiredAt.Sub(now))/float64(*old), 1)
+			expiredAt := now.Add(time.Duration(fraction * float64(d)))
+			item.expiredAt = b.capLifetime(item.createdAt, &expiredAt)
+		}
+		b.scheduleLocked(item)
+		b.logExpiryLocked(item)
+	}
+	return nil
+}
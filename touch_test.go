@@ -0,0 +1,288 @@
+package heatwave
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTouchMany(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithBucketExpire[int](time.Minute))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_ = b.NailWithTTL("short", 3, time.Second)
+	clock.Advance(2 * time.Second)
+	_ = b.Nail("c", 4)
+
+	if n := b.TouchMany([]string{"a", "b", "short", "missing"}); n != 2 {
+		t.Fatalf("TouchMany = %d, want 2", n)
+	}
+	// a and b now expire a minute after the touch, c a minute after its write
+	clock.Advance(59 * time.Second)
+	if !exists(b, "a") || !exists(b, "b") || !exists(b, "c") {
+		t.Fatal("touched or fresh keys expired early")
+	}
+	clock.Advance(2 * time.Second)
+	if exists(b, "a") || exists(b, "b") {
+		t.Fatal("touched keys outlived the default TTL")
+	}
+	if exists(b, "short") || exists(b, "missing") {
+		t.Fatal("TouchMany revived an expired or missing key")
+	}
+	if n := b.TouchMany(nil); n != 0 {
+		t.Fatalf("TouchMany(nil) = %d, want 0", n)
+	}
+}
+
+func TestKeepExpiry(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithBucketExpire[int](time.Minute))
+	defer b.Close()
+
+	_ = b.Nail("kept", 1)
+	_ = b.Nail("reset", 1)
+	clock.Advance(40 * time.Second)
+	_ = b.Nail("kept", 2, KeepExpiry())
+	_ = b.Nail("reset", 2)
+	_ = b.Nail("new", 1, KeepExpiry()) // New keys get the regular TTL
+
+	clock.Advance(21 * time.Second)
+	if exists(b, "kept") {
+		t.Fatal("KeepExpiry update restarted the TTL")
+	}
+	if !exists(b, "reset") || !exists(b, "new") {
+		t.Fatal("plain update or new key expired early")
+	}
+}
+
+func TestUpdateKeepsExpiry(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithBucketExpire[int](time.Minute),
+		WithUpdateKeepsExpiry[int](),
+	)
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	clock.Advance(40 * time.Second)
+	_ = b.Nail("a", 2)
+	if v, _ := b.Bring("a"); v != 2 {
+		t.Fatalf("Bring(a) = %d, want the updated value 2", v)
+	}
+	clock.Advance(21 * time.Second)
+	if exists(b, "a") {
+		t.Fatal("update restarted the TTL under WithUpdateKeepsExpiry")
+	}
+}
+
+func TestMaxLifetimeCapsRewritesAndTouches(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithBucketExpire[int](time.Minute),
+		WithMaxLifetime[int](90*time.Second),
+	)
+	defer b.Close()
+
+	_ = b.Nail("rewritten", 1)
+	_ = b.Nail("touched", 1)
+	_ = b.Nail("extended", 1)
+	for i := 0; i < 4; i++ {
+		clock.Advance(20 * time.Second)
+		_ = b.Nail("rewritten", i)
+		b.TouchMany([]string{"touched"})
+		b.BringAndExtend("extended", time.Hour)
+	}
+	// 80s after insertion all three are alive, past 90s none is
+	for _, key := range []string{"rewritten", "touched", "extended"} {
+		if !exists(b, key) {
+			t.Fatalf("%s expired before its max lifetime", key)
+		}
+	}
+	clock.Advance(11 * time.Second)
+	for _, key := range []string{"rewritten", "touched", "extended"} {
+		if exists(b, key) {
+			t.Fatalf("%s outlived its max lifetime", key)
+		}
+	}
+}
+
+func TestZeroTTLNeverExpires(t *testing.T) {
+	for name, opt := range map[string]NewBucketOption[int]{
+		"WithBucketExpire(0)":   WithBucketExpire[int](0),
+		"WithBucketExpire(-1)":  WithBucketExpire[int](-time.Second),
+		"WithBucketNeverExpire": WithBucketNeverExpire[int](),
+	} {
+		t.Run(name, func(t *testing.T) {
+			clock := NewManualClock(time.Unix(0, 0))
+			b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithMaxSize[int](2), opt)
+			defer b.Close()
+
+			_ = b.Nail("a", 1)
+			_ = b.NailWithTTL("b", 2, 0)
+			if info, _ := b.ItemInfo("a"); !info.ExpiresAt.IsZero() {
+				t.Fatalf("ExpiresAt = %v, want zero", info.ExpiresAt)
+			}
+			if ttl := b.DefaultTTL(); ttl != 0 {
+				t.Fatalf("DefaultTTL = %v, want 0", ttl)
+			}
+
+			clock.Advance(100 * 365 * 24 * time.Hour)
+			if n := b.CleanupNow(); n != 0 {
+				t.Fatalf("CleanupNow removed %d items", n)
+			}
+			if !exists(b, "a") || !exists(b, "b") {
+				t.Fatal("an item without TTL expired")
+			}
+
+			// Capacity eviction still applies
+			_ = b.Nail("c", 3)
+			if exists(b, "a") || b.Size() != 2 {
+				t.Fatal("an item without TTL wasn't evicted for capacity")
+			}
+		})
+	}
+}
+
+func TestBringAndExtend(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithBucketExpire[int](time.Minute))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	clock.Advance(30 * time.Second)
+	if v, ok := b.BringAndExtend("a", 10*time.Minute); !ok || v != 1 {
+		t.Fatalf("BringAndExtend = %d, %v, want 1, true", v, ok)
+	}
+	want := clock.Now().Add(10 * time.Minute)
+	if info, _ := b.ItemInfo("a"); !info.ExpiresAt.Equal(want) {
+		t.Fatalf("ExpiresAt = %v, want %v", info.ExpiresAt, want)
+	}
+
+	// The new expiry replaces a later one too
+	if _, ok := b.BringAndExtend("a", time.Second); !ok {
+		t.Fatal("BringAndExtend missed a live key")
+	}
+	clock.Advance(2 * time.Second)
+	if exists(b, "a") {
+		t.Fatal("a outlived its shortened expiry")
+	}
+
+	// Plain reads don't extend anything
+	_ = b.Nail("b", 2)
+	clock.Advance(30 * time.Second)
+	b.Bring("b")
+	clock.Advance(31 * time.Second)
+	if exists(b, "b") {
+		t.Fatal("Bring extended the expiry of b")
+	}
+
+	if _, ok := b.BringAndExtend("missing", time.Minute); ok {
+		t.Fatal("BringAndExtend found a missing key")
+	}
+	_ = b.Nail("c", 3)
+	before, _ := b.ItemInfo("c")
+	if v, ok := b.BringAndExtend("c", 0); !ok || v != 3 {
+		t.Fatalf("BringAndExtend(c, 0) = %d, %v, want 3, true", v, ok)
+	}
+	if after, _ := b.ItemInfo("c"); !after.ExpiresAt.Equal(before.ExpiresAt) {
+		t.Fatalf("non-positive extension moved the expiry from %v to %v", before.ExpiresAt, after.ExpiresAt)
+	}
+}
+
+func TestBringAndExtendExpireInterceptor(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), keepInterceptor[int]("kept"))
+	defer b.Close()
+
+	_ = b.NailWithTTL("kept", 1, time.Second)
+	_ = b.NailWithTTL("dropped", 2, time.Second)
+	clock.Advance(2 * time.Second)
+
+	if v, ok := b.BringAndExtend("kept", time.Hour); !ok || v != 1 {
+		t.Fatalf("BringAndExtend(kept) = %d, %v, want 1, true", v, ok)
+	}
+	// The extension applies on top of the interceptor's TTL
+	want := clock.Now().Add(time.Hour)
+	if info, _ := b.ItemInfo("kept"); !info.ExpiresAt.Equal(want) {
+		t.Fatalf("ExpiresAt = %v, want %v", info.ExpiresAt, want)
+	}
+
+	if _, ok := b.BringAndExtend("dropped", time.Hour); ok {
+		t.Fatal("BringAndExtend returned an item the interceptor let expire")
+	}
+	if exists(b, "dropped") {
+		t.Fatal("dropped is still held")
+	}
+}
+
+func TestSetDefaultTTL(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithBucketExpire[int](time.Minute))
+	defer b.Close()
+
+	_ = b.Nail("old", 1)
+	clock.Advance(30 * time.Second)
+
+	// Without refresh only later writes get the new TTL
+	if err := b.SetDefaultTTL(time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+	if d := b.DefaultTTL(); d != time.Hour {
+		t.Fatalf("DefaultTTL = %v, want 1h", d)
+	}
+	_ = b.Nail("new", 2)
+	if info, _ := b.ItemInfo("old"); !info.ExpiresAt.Equal(time.Unix(60, 0)) {
+		t.Fatalf("old expires at %v, want its original deadline", info.ExpiresAt)
+	}
+	if info, _ := b.ItemInfo("new"); !info.ExpiresAt.Equal(clock.Now().Add(time.Hour)) {
+		t.Fatalf("new expires at %v, want an hour from now", info.ExpiresAt)
+	}
+
+	// With refresh old keeps the half of the old TTL it had left, now half
+	// of the new one
+	_ = b.SetDefaultTTL(time.Minute, false)
+	if err := b.SetDefaultTTL(10*time.Minute, true); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := b.ItemInfo("old"); !info.ExpiresAt.Equal(clock.Now().Add(5 * time.Minute)) {
+		t.Fatalf("refreshed old expires at %v, want five minutes from now", info.ExpiresAt)
+	}
+	// Remaining time is capped at the whole new TTL
+	if info, _ := b.ItemInfo("new"); !info.ExpiresAt.Equal(clock.Now().Add(10 * time.Minute)) {
+		t.Fatalf("refreshed new expires at %v, want ten minutes from now", info.ExpiresAt)
+	}
+
+	// Zero means never expire
+	_ = b.SetDefaultTTL(0, true)
+	if info, _ := b.ItemInfo("old"); !info.ExpiresAt.IsZero() || b.DefaultTTL() != 0 {
+		t.Fatalf("after SetDefaultTTL(0) old expires at %v, DefaultTTL = %v", info.ExpiresAt, b.DefaultTTL())
+	}
+}
+
+func TestSetDefaultTTLRefused(t *testing.T) {
+	b := NewBucket[int](WithBucketExpire[int](time.Minute))
+
+	b.Freeze()
+	if err := b.SetDefaultTTL(time.Hour, true); !errors.Is(err, ErrBucketFrozen) {
+		t.Fatalf("refreshing SetDefaultTTL on a frozen bucket = %v, want ErrBucketFrozen", err)
+	}
+	if d := b.DefaultTTL(); d != time.Minute {
+		t.Fatalf("DefaultTTL = %v after a refused call, want 1m", d)
+	}
+	if err := b.SetDefaultTTL(time.Hour, false); err != nil || b.DefaultTTL() != time.Hour {
+		t.Fatalf("SetDefaultTTL without refresh on a frozen bucket = %v, DefaultTTL %v", err, b.DefaultTTL())
+	}
+	b.Unfreeze()
+
+	_ = b.Close()
+	if err := b.SetDefaultTTL(time.Second, false); !errors.Is(err, ErrBucketClosed) {
+		t.Fatalf("SetDefaultTTL on a closed bucket = %v, want ErrBucketClosed", err)
+	}
+}
@@ -0,0 +1,45 @@
+package heatwave
+
+// TraceHook receives tracing callbacks without tying heatwave to a tracer
+// Start methods return a function that is called when the operation ends,
+// so callers can open a span in the start method and close it in the
+// returned function. Hooks never run while the bucket lock is held, and
+// panics inside them are recovered and counted in Stats.HookPanics.
+type TraceHook interface {
+	// OnBringStart is called before a Bring, the returned func receives the outcome
+	OnBringStart(key string) func(hit bool)
+	// OnLoadStart is called before a loader runs, the returned func receives its error
+	OnLoadStart(key string) func(err error)
+	// OnEvict is called after an item was removed from the bucket
+	OnEvict(key string, reason RemovalReason)
+}
+
+// traceBringStart calls OnBringStart and returns a panic-safe end function
+func (b *Bucket[T]) traceBringStart(key string) func(hit bool) {
+	var end func(hit bool)
+	b.guard(func() { end = b.traceHook.OnBringStart(key) })
+	return func(hit bool) {
+		if end != nil {
+			b.guard(func() { end(hit) })
+		}
+	}
+}
+
+// traceLoadStart calls OnLoadStart and returns a panic-safe end function
+func (b *Bucket[T]) traceLoadStart(key string) func(err error) {
+	var end func(err error)
+	b.guard(func() { end = b.traceHook.OnLoadStart(key) })
+	return func(err error) {
+		if end != nil {
+			b.guard(func() { end(err) })
+		}
+	}
+}
+
+// WithTraceHook installs a tracing hook
+// A nil hook disables tracing at no cost.
+func WithTraceHook[T any](h TraceHook) NewBucketOption[T] {
+	return func(b *Bucket[T]) {
+		b.traceHook = h
+	}
+}
@@ -0,0 +1,79 @@
+package heatwave
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// recordingHook logs every trace callback
+type recordingHook struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (h *recordingHook) add(event string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.events = append(h.events, event)
+}
+
+func (h *recordingHook) OnBringStart(key string) func(hit bool) {
+	h.add("bring " + key)
+	return func(hit bool) { h.add(fmt.Sprintf("bring %s hit=%v", key, hit)) }
+}
+
+func (h *recordingHook) OnLoadStart(key string) func(err error) {
+	h.add("load " + key)
+	return func(err error) { h.add(fmt.Sprintf("load %s err=%v", key, err)) }
+}
+
+func (h *recordingHook) OnEvict(key string, reason RemovalReason) {
+	h.add(fmt.Sprintf("evict %s %v", key, reason))
+}
+
+func TestTraceHookPairsStartAndEnd(t *testing.T) {
+	hook := &recordingHook{}
+	b := NewBucket[int](WithMaxSize[int](1), WithTraceHook[int](hook))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	b.Bring("a")
+	b.Bring("b")
+	_, _ = b.GetOrLoad("c", func() (int, error) { return 0, errors.New("down") })
+	_ = b.Nail("d", 4)
+
+	want := []string{
+		"bring a", "bring a hit=true",
+		"bring b", "bring b hit=false",
+		"bring c", "bring c hit=false",
+		"load c", "load c err=down",
+		fmt.Sprintf("evict a %v", ReasonEvicted),
+	}
+	if !slices.Equal(hook.events, want) {
+		t.Fatalf("events = %q, want %q", hook.events, want)
+	}
+}
+
+// panickingHook panics in every callback
+type panickingHook struct{}
+
+func (panickingHook) OnBringStart(string) func(bool) { panic("start") }
+func (panickingHook) OnLoadStart(string) func(error) { panic("start") }
+func (panickingHook) OnEvict(string, RemovalReason)  { panic("evict") }
+
+func TestTraceHookPanicsAreRecovered(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](1), WithTraceHook[int](panickingHook{}))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	if v, ok := b.Bring("a"); !ok || v != 1 {
+		t.Fatalf("Bring = %d, %v", v, ok)
+	}
+	_ = b.Nail("b", 2)
+	if n := b.Stats().HookPanics; n != 2 {
+		t.Fatalf("HookPanics = %d, want 2", n)
+	}
+}
@@ -0,0 +1,73 @@
+package heatwave
+
+import "time"
+
+// TransformingBucket is a view of a bucket that stores values in one form
+// and exposes them in another, e.g. compressed bytes exposed as strings or
+// IDs exposed as hydrated objects
+// encode runs on every write and decode on every read, outside the bucket
+// lock. The underlying bucket keeps its own limits, eviction and callbacks,
+// which see the stored form.
+type TransformingBucket[S, E any] struct {
+	bucket *Bucket[S]
+	encode func(E) (S, error)
+	decode func(S) (E, error)
+}
+
+// NewTransformingBucket returns a view of b that converts values with encode
+// on the way in and decode on the way out
+func NewTransformingBucket[S, E any](b *Bucket[S], encode func(E) (S, error), decode func(S) (E, error)) *TransformingBucket[S, E] {
+	return &TransformingBucket[S, E]{bucket: b, encode: encode, decode: decode}
+}
+
+// Bucket returns the underlying bucket
+func (t *TransformingBucket[S, E]) Bucket() *Bucket[S] {
+	return t.bucket
+}
+
+// Nail encodes data and stores it under id
+// An encode error is returned as is and leaves the bucket unchanged.
+func (t *TransformingBucket[S, E]) Nail(id string, data E, opts ...NailOption) error {
+	stored, err := t.encode(data)
+	if err != nil {
+		return err
+	}
+	return t.bucket.Nail(id, stored, opts...)
+}
+
+// NailWithTTL is Nail with a TTL that overrides the bucket default
+func (t *TransformingBucket[S, E]) NailWithTTL(id string, data E, ttl time.Duration, opts ...NailOption) error {
+	stored, err := t.encode(data)
+	if err != nil {
+		return err
+	}
+	return t.bucket.NailWithTTL(id, stored, ttl, opts...)
+}
+
+// Bring retrieves and decodes the value for id
+// A value that fails to decode is reported as a miss, use BringDecoded to
+// see the error.
+func (t *TransformingBucket[S, E]) Bring(id string) (E, bool) {
+	value, ok, err := t.BringDecoded(id)
+	return value, ok && err == nil
+}
+
+// BringDecoded retrieves and decodes the value for id, returning the decode
+// error of a value that was found
+func (t *TransformingBucket[S, E]) BringDecoded(id string) (E, bool, error) {
+	var zero E
+	stored, ok := t.bucket.Bring(id)
+	if !ok {
+		return zero, false, nil
+	}
+	value, err := t.decode(stored)
+	if err != nil {
+		return zero, true, err
+	}
+	return value, true, nil
+}
+
+// Unnail removes id and reports whether it was present
+func (t *TransformingBucket[S, E]) Unnail(id string) (bool, error) {
+	return t.bucket.Unnail(id)
+}
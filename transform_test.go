@@ -0,0 +1,59 @@
+package heatwave
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func gzipString(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipString(data []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	s, err := io.ReadAll(zr)
+	return string(s), err
+}
+
+func TestTransformingBucketGzip(t *testing.T) {
+	b := NewBucket[[]byte]()
+	defer b.Close()
+	tb := NewTransformingBucket(b, gzipString, gunzipString)
+
+	text := strings.Repeat("heatwave ", 1000)
+	if err := tb.Nail("doc", text); err != nil {
+		t.Fatalf("Nail: %v", err)
+	}
+	if v, ok := tb.Bring("doc"); !ok || v != text {
+		t.Fatalf("Bring(doc) = %d bytes, %v, want the original %d bytes", len(v), ok, len(text))
+	}
+
+	stored, _ := b.Bring("doc")
+	if len(stored) >= len(text) || !bytes.HasPrefix(stored, []byte{0x1f, 0x8b}) {
+		t.Fatalf("stored %d bytes, want gzip data smaller than %d", len(stored), len(text))
+	}
+
+	// Data that doesn't decode is a miss for Bring and an error for BringDecoded
+	_ = b.Nail("raw", []byte("not gzip"))
+	if _, ok := tb.Bring("raw"); ok {
+		t.Fatal("Bring returned an undecodable value")
+	}
+	if _, ok, err := tb.BringDecoded("raw"); !ok || err == nil {
+		t.Fatalf("BringDecoded(raw) = %v, %v, want true and an error", ok, err)
+	}
+}
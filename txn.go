@@ -0,0 +1,196 @@
+package heatwave
+
+import "time"
+
+// txWrite is a pending write inside a transaction
+type txWrite[T any] struct {
+	value   T
+	deleted bool
+}
+
+// Tx is a transaction over a bucket
+// Writes are buffered in a private write-set and only become visible to other
+// goroutines when the transaction commits.
+type Tx[T any] struct {
+	bucket *Bucket[T]
+	writes map[string]*txWrite[T]
+	order  []string // Keys in the order they were first written
+}
+
+// Get returns the value for id, seeing the transaction's own writes
+// Reads of the bucket don't change access order.
+func (tx *Tx[T]) Get(id string) (T, bool) {
+	if w, ok := tx.writes[id]; ok {
+		if w.deleted {
+			var zero T
+			return zero, false
+		}
+		return w.value, true
+	}
+	return tx.bucket.peek(id)
+}
+
+// Set buffers a write of data under id
+func (tx *Tx[T]) Set(id string, data T) error {
+	data, err := tx.bucket.admit(id, data)
+	if err != nil {
+		return err
+	}
+	tx.write(id, &txWrite[T]{value: data})
+	return nil
+}
+
+// Delete buffers the removal of id
+func (tx *Tx[T]) Delete(id string) {
+	tx.write(id, &txWrite[T]{deleted: true})
+}
+
+// write records w as the latest write for id
+func (tx *Tx[T]) write(id string, w *txWrite[T]) {
+	if _, ok := tx.writes[id]; !ok {
+		tx.order = append(tx.order, id)
+	}
+	tx.writes[id] = w
+}
+
+// Txn runs fn in a transaction and commits its writes if fn returns nil
+// The commit applies all writes under a single lock acquisition, so readers
+// never observe a partial transaction. Room for the new keys is made before
+// any write is applied, so the commit only evicts items it writes when it
+// inserts more keys than the bucket holds. When
+// there isn't enough room, under OverflowReject or with WithStrictCapacity
+// and nothing left to evict, the commit fails with ErrCacheFull and none of
+// the writes are applied. If fn returns an error or panics the bucket is left
+// untouched.
+func (b *Bucket[T]) Txn(fn func(tx *Tx[T]) error) error {
+	if err := b.writable(); err != nil {
+		return err
+	}
+
+	tx := &Tx[T]{bucket: b, writes: make(map[string]*txWrite[T])}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return b.commit(tx)
+}
+
+// commit applies a transaction's write-set
+func (b *Bucket[T]) commit(tx *Tx[T]) error {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	spills := b.stageSpills(len(tx.order), func(i int) (string, T, bool) {
+		w := tx.writes[tx.order[i]]
+		return tx.order[i], w.value, !w.deleted
+	})
+	b.lock()
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		b.removeSpills(spills)
+		return err
+	}
+
+	if err := b.reserveLocked(tx); err != nil {
+		b.removeSpills(spills)
+		return err
+	}
+
+	expiredAt := b.expiryFor(b.outdated)
+	now := b.expiryNow()
+	for i, id := range tx.order {
+		w := tx.writes[id]
+		item, exists := b.cache[id]
+		if spills != nil {
+			b.stageLocked(spills[i])
+		}
+		switch {
+		case w.deleted:
+			if exists {
+				b.removeLocked(item, ReasonDeleted)
+			}
+		case exists && !item.expired(now):
+			if !b.unchangedLocked(item, w.value, expiredAt) {
+				b.updateLocked(item, w.value, expiredAt)
+			}
+		default:
+			// An expired item is replaced by a fresh one, as in setLocked
+			if exists {
+				b.removeLocked(item, ReasonExpired)
+			}
+			b.insertLocked(id, w.value, expiredAt, 0)
+		}
+	}
+	// Only left over capacity when the transaction alone exceeds it
+	for b.updater.Size() > b.maxSize {
+		evictedItem := b.updater.Evict()
+		if evictedItem == nil {
+			break
+		}
+		b.forgetLocked(evictedItem, ReasonEvicted)
+	}
+	b.trimBytesLocked(0)
+	return nil
+}
+
+// reserveLocked makes room for the keys tx inserts, evicting or, under
+// OverflowReject, failing with ErrCacheFull when the bucket would be over
+// capacity after the commit
+// Must be called with b.mutex held
+func (b *Bucket[T]) reserveLocked(tx *Tx[T]) error {
+	if b.overflowPolicy == OverflowReject {
+		if b.projectedSizeLocked(tx) <= b.maxSize {
+			return nil
+		}
+		b.purgeExpiredLocked()
+		if b.projectedSizeLocked(tx) > b.maxSize {
+			return ErrCacheFull
+		}
+		return nil
+	}
+	for b.projectedSizeLocked(tx) > b.maxSize {
+		evictedItem := b.updater.Evict()
+		if evictedItem == nil {
+			if b.strictCapacity {
+				return ErrCacheFull
+			}
+			break
+		}
+		b.forgetLocked(evictedItem, ReasonEvicted)
+	}
+	return nil
+}
+
+// projectedSizeLocked returns the number of items after tx is applied
+// A write replacing an expired item leaves the count unchanged.
+// Must be called with b.mutex held
+func (b *Bucket[T]) projectedSizeLocked(tx *Tx[T]) int {
+	size := b.updater.Size()
+	for id, w := range tx.writes {
+		_, exists := b.cache[id]
+		switch {
+		case w.deleted && exists:
+			size--
+		case !w.deleted && !exists:
+			size++
+		}
+	}
+	return size
+}
+
+// peek returns the live value for id without changing access order
+func (b *Bucket[T]) peek(id string) (T, bool) {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	var zero T
+	if b.isClosed() {
+		return zero, false
+	}
+	item, exists := b.cache[id]
+	if !exists || item.expired(b.now()) {
+		return zero, false
+	}
+	return b.readValue(item), true
+}
@@ -0,0 +1,85 @@
+package heatwave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTxnReplacesExpiredItem(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	var expired []string
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithCleanupDisabled[int](),
+		WithBucketExpire[int](time.Minute),
+		WithMaxSize[int](1),
+		WithOnExpire(func(key string, value int) {
+			expired = append(expired, key)
+		}),
+	)
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	clock.Advance(2 * time.Minute)
+
+	// The expired item doesn't count against capacity, so the commit fits
+	// without evicting anything
+	err := b.Txn(func(tx *Tx[int]) error {
+		if _, ok := tx.Get("a"); ok {
+			t.Error("Tx.Get returned an expired item")
+		}
+		return tx.Set("a", 2)
+	})
+	if err != nil {
+		t.Fatalf("Txn: %v", err)
+	}
+	if v, ok := b.Bring("a"); !ok || v != 2 {
+		t.Fatalf("Bring(a) = %d, %v, want 2, true", v, ok)
+	}
+	if b.Size() != 1 {
+		t.Fatalf("Size = %d, want 1", b.Size())
+	}
+	if len(expired) != 1 || expired[0] != "a" {
+		t.Fatalf("expired = %v, want [a]", expired)
+	}
+	if ev := b.Stats().Evictions; ev != 0 {
+		t.Fatalf("Evictions = %d, want 0", ev)
+	}
+
+	// The replacement carries a fresh deadline
+	clock.Advance(30 * time.Second)
+	if _, ok := b.Bring("a"); !ok {
+		t.Fatal("replacement inherited the expired deadline")
+	}
+}
+
+func TestTxnSkipsEqualWrites(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](
+		WithClock[int](clock),
+		WithValueEquality[int](func(a, b int) bool { return a == b }),
+	)
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	before, _ := b.ItemInfo("a")
+	clock.Advance(time.Second)
+
+	if err := b.Txn(func(tx *Tx[int]) error { return tx.Set("a", 1) }); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := b.ItemInfo("a"); info != before {
+		t.Fatalf("equal write changed the item: %+v, want %+v", info, before)
+	}
+
+	if err := b.Txn(func(tx *Tx[int]) error { return tx.Set("a", 2) }); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := b.ItemInfo("a")
+	if !info.UpdatedAt.After(before.UpdatedAt) {
+		t.Fatalf("UpdatedAt = %v after a changed write, want after %v", info.UpdatedAt, before.UpdatedAt)
+	}
+	if v, _ := b.Bring("a"); v != 2 {
+		t.Fatalf("Bring(a) = %d, want 2", v)
+	}
+}
@@ -1,5 +1,7 @@
 package heatwave
 
+import "reflect"
+
 // Updater interface defines the update strategy for cache items
 type Updater[T any] interface {
 	// Add adds a new item to the update strategy
@@ -15,3 +17,60 @@ type Updater[T any] interface {
 	// Clear removes all items from the updater
 	Clear()
 }
+
+// OrderedUpdater is an optional extension of Updater for strategies that keep
+// their items in a well-defined eviction order
+type OrderedUpdater[T any] interface {
+	Updater[T]
+	// Peek returns the item Evict would return next without removing it
+	Peek() *CacheItem[T]
+	// Ascend calls fn for each item from the next eviction candidate to the
+	// most protected one, stopping early when fn returns false
+	Ascend(fn func(item *CacheItem[T]) bool)
+	// Descend calls fn for each item from the most protected one to the next
+	// eviction candidate, stopping early when fn returns false
+	Descend(fn func(item *CacheItem[T]) bool)
+}
+
+// SetUpdater replaces the eviction strategy without losing the contents
+// Every item is registered with u under the write lock, in the old
+// updater's eviction order when it implements OrderedUpdater and in map
+// order otherwise, and the old updater is cleared. u should be empty. An
+// updater bound to another bucket is rejected with ErrUpdaterShared.
+func (b *Bucket[T]) SetUpdater(u Updater[T]) error {
+	if u == nil {
+		return ErrNilUpdater
+	}
+
+	b.lock()
+	defer b.unlock()
+
+	if b.isClosed() {
+		return ErrBucketClosed
+	}
+	if reflect.TypeOf(u).Comparable() && u == b.updater {
+		return nil
+	}
+
+	if err := b.bindUpdater(u); err != nil {
+		return err
+	}
+	b.clockUpdater(u)
+	old := b.updater
+	if ordered, ok := old.(OrderedUpdater[T]); ok {
+		ordered.Ascend(func(item *CacheItem[T]) bool {
+			u.Add(item)
+			return true
+		})
+	} else {
+		for _, item := range b.cache {
+			u.Add(item)
+		}
+	}
+	old.Clear()
+	b.unbindUpdater(old)
+	b.seedUpdater(u)
+	b.updater = u
+	b.newUpdater = nil
+	return nil
+}
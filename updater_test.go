@@ -0,0 +1,302 @@
+package heatwave
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// updaterFactories builds every updater shipped with the package
+var updaterFactories = map[string]func() Updater[int]{
+	"Base":        func() Updater[int] { return &BaseUpdater[int]{} },
+	"LRU":         func() Updater[int] { return newLRUUpdater[int]() },
+	"FIFO":        func() Updater[int] { return newFIFO[int]() },
+	"SampledLRU":  func() Updater[int] { return newSampledLRU[int](3) },
+	"DecayingLFU": func() Updater[int] { return newDecayingLFU[int](time.Minute) },
+	"Scored": func() Updater[int] {
+		return newScoredUpdater[int](func(item ItemView[int]) float64 {
+			return float64(item.LastAccess.UnixNano())
+		}, 3, func() func(int) int64 { return nil })
+	},
+	"Priority": func() Updater[int] {
+		return newPriorityUpdater[int](newLRUUpdater[int](), func() Updater[int] { return newLRUUpdater[int]() })
+	},
+}
+
+// newItems returns n distinct items
+func newItems(n int) []*CacheItem[int] {
+	items := make([]*CacheItem[int], n)
+	for i := range items {
+		items[i] = &CacheItem[int]{key: strconv.Itoa(i), value: i}
+	}
+	return items
+}
+
+func TestUpdaterConformance(t *testing.T) {
+	for name, factory := range updaterFactories {
+		t.Run(name, func(t *testing.T) {
+			u := factory()
+			if u.Size() != 0 || u.Evict() != nil {
+				t.Fatal("new updater isn't empty")
+			}
+
+			items := newItems(10)
+			for _, item := range items {
+				u.Add(item)
+			}
+			if u.Size() != 10 {
+				t.Fatalf("Size = %d after 10 adds, want 10", u.Size())
+			}
+			u.Access(items[3])
+
+			removed := items[5]
+			u.Remove(removed)
+			u.Remove(removed)
+			u.Remove(&CacheItem[int]{key: "unknown"})
+			if u.Size() != 9 {
+				t.Fatalf("Size = %d after removing one item, want 9", u.Size())
+			}
+
+			seen := make(map[*CacheItem[int]]bool)
+			for i := 0; i < 9; i++ {
+				item := u.Evict()
+				if item == nil || item == removed || seen[item] {
+					t.Fatalf("Evict #%d returned %v, want a distinct live item", i, item)
+				}
+				seen[item] = true
+				if u.Size() != 8-i {
+					t.Fatalf("Size = %d after %d evictions, want %d", u.Size(), i+1, 8-i)
+				}
+			}
+			if item := u.Evict(); item != nil {
+				t.Fatalf("Evict on an empty updater = %v, want nil", item)
+			}
+
+			for _, item := range items[:3] {
+				u.Add(item)
+			}
+			u.Clear()
+			if u.Size() != 0 || u.Evict() != nil {
+				t.Fatal("Clear left items behind")
+			}
+		})
+	}
+}
+
+func TestFIFOEvictsInInsertionOrder(t *testing.T) {
+	f := newFIFO[int]()
+	items := newItems(5)
+	for _, item := range items {
+		f.Add(item)
+	}
+	// Access is a no-op, so reads don't protect an item
+	f.Access(items[0])
+	f.Remove(items[2])
+
+	for _, want := range []int{0, 1, 3, 4} {
+		if got := f.Evict(); got != items[want] {
+			t.Fatalf("Evict = %v, want item %d", got, want)
+		}
+	}
+	// Evicted and removed items are no longer referenced
+	if len(f.nodeMap) != 0 {
+		t.Fatalf("nodeMap holds %d items after evicting everything", len(f.nodeMap))
+	}
+}
+
+func TestFIFOBucketEvictionOrder(t *testing.T) {
+	var evicted []string
+	b := NewBucket[int](
+		WithMaxSize[int](3),
+		WithFIFOUpdater[int](),
+		WithOnEvict(func(key string, value int, reason RemovalReason) {
+			if reason == ReasonEvicted {
+				evicted = append(evicted, key)
+			}
+		}),
+	)
+	defer b.Close()
+
+	for i, key := range []string{"a", "b", "c"} {
+		_ = b.Nail(key, i)
+	}
+	// Neither reads nor updates move an item, and a deleted key leaves the
+	// order of the others unchanged
+	b.Bring("a")
+	_ = b.Nail("b", 10)
+	_, _ = b.Unnail("c")
+	for i, key := range []string{"d", "e", "f", "g"} {
+		_ = b.Nail(key, i)
+	}
+
+	want := []string{"a", "b", "d"}
+	if len(evicted) != len(want) {
+		t.Fatalf("evicted %v, want %v", evicted, want)
+	}
+	for i := range want {
+		if evicted[i] != want[i] {
+			t.Fatalf("evicted %v, want %v", evicted, want)
+		}
+	}
+}
+
+// BenchmarkFIFORemove measures removing and re-adding an item of a FIFO
+// holding 100k items
+func BenchmarkFIFORemove(b *testing.B) {
+	const size = 100_000
+	f := newFIFO[int]()
+	items := newItems(size)
+	for _, item := range items {
+		f.Add(item)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Walk the items in a stride so removals hit the whole list
+		item := items[i*7919%size]
+		f.Remove(item)
+		f.Add(item)
+	}
+}
+
+// BenchmarkFIFORemoveScaling shows that Remove doesn't depend on the number
+// of items
+func BenchmarkFIFORemoveScaling(b *testing.B) {
+	for _, size := range []int{1_000, 10_000, 100_000} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			f := newFIFO[int]()
+			items := newItems(size)
+			for _, item := range items {
+				f.Add(item)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				item := items[i*7919%size]
+				f.Remove(item)
+				f.Add(item)
+			}
+		})
+	}
+}
+
+func TestSetUpdaterKeepsEvictionOrder(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](3))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_ = b.Nail("c", 3)
+	_, _ = b.Bring("a") // LRU order is now b, c, a
+
+	if err := b.SetUpdater(newFIFO[int]()); err != nil {
+		t.Fatalf("SetUpdater: %v", err)
+	}
+	if err := b.SetUpdater(nil); err != ErrNilUpdater {
+		t.Fatalf("SetUpdater(nil) = %v, want ErrNilUpdater", err)
+	}
+
+	_ = b.Nail("d", 4)
+	if _, ok := b.Bring("b"); ok {
+		t.Fatal("FIFO didn't inherit the LRU order: b survived")
+	}
+	_ = b.Nail("e", 5)
+	if _, ok := b.Bring("c"); ok {
+		t.Fatal("FIFO didn't inherit the LRU order: c survived")
+	}
+	if _, ok := b.Bring("a"); !ok {
+		t.Fatal("a was evicted before b and c")
+	}
+}
+
+func TestSetUpdaterConcurrent(t *testing.T) {
+	const workers = 4
+	b := NewBucket[int](WithMaxSize[int](100))
+	defer b.Close()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				key := strconv.Itoa((w*1000 + i) % 250)
+				if i%3 == 0 {
+					_, _ = b.Bring(key)
+				} else {
+					_ = b.Nail(key, i)
+				}
+			}
+		}(w)
+	}
+
+	waitFor(t, "writers to fill the bucket", func() bool { return b.Size() == 100 })
+	factories := []func() Updater[int]{
+		func() Updater[int] { return newFIFO[int]() },
+		func() Updater[int] { return newDecayingLFU[int](time.Minute) },
+		func() Updater[int] { return newLRUUpdater[int]() },
+	}
+	for i := 0; i < 60; i++ {
+		if err := b.SetUpdater(factories[i%len(factories)]()); err != nil {
+			t.Fatalf("SetUpdater: %v", err)
+		}
+		if err := b.CheckInvariants(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if err := b.CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+	// Every item is known to the updater: a full round of inserts pushes out
+	// all of them
+	if b.Size() != b.updater.Size() {
+		t.Fatalf("Size = %d, updater size = %d", b.Size(), b.updater.Size())
+	}
+	for i := 0; i < 100; i++ {
+		_ = b.Nail("fresh"+strconv.Itoa(i), i)
+	}
+	if b.Size() != 100 {
+		t.Fatalf("Size = %d, want 100", b.Size())
+	}
+	for i := 0; i < 100; i++ {
+		if _, ok := b.Bring("fresh" + strconv.Itoa(i)); !ok {
+			t.Fatalf("fresh%d was evicted while older items remained", i)
+		}
+	}
+}
+
+func TestWithNilUpdater(t *testing.T) {
+	b := NewBucket[int](WithMaxSize[int](2), WithUpdater[int](nil))
+	defer b.Close()
+
+	_ = b.Nail("a", 1)
+	_ = b.Nail("b", 2)
+	_, _ = b.Bring("a")
+	if err := b.Nail("c", 3); err != nil {
+		t.Fatalf("Nail with a nil updater = %v", err)
+	}
+	// The LRU default stays in place
+	if exists(b, "b") || !exists(b, "a") {
+		t.Fatal("nil updater didn't fall back to LRU eviction")
+	}
+
+	// A nil updater doesn't undo an earlier strategy option either
+	f := NewBucket[int](WithMaxSize[int](2), WithFIFOUpdater[int](), WithUpdater[int](nil))
+	defer f.Close()
+	_ = f.Nail("a", 1)
+	_ = f.Nail("b", 2)
+	_, _ = f.Bring("a")
+	_ = f.Nail("c", 3)
+	if exists(f, "a") {
+		t.Fatal("WithUpdater(nil) replaced the FIFO strategy")
+	}
+}
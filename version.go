@@ -0,0 +1,89 @@
+package heatwave
+
+import (
+	"fmt"
+	"time"
+)
+
+// VersionMismatchError is returned by NailIfVersion when the stored version
+// differs from the expected one
+// It matches ErrVersionMismatch with errors.Is.
+type VersionMismatchError struct {
+	Key      string
+	Expected uint64
+	Actual   uint64 // Zero when the key is absent
+}
+
+// Error implements error
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("heatwave: version mismatch for %q: expected %d, got %d", e.Key, e.Expected, e.Actual)
+}
+
+// Is makes errors.Is(err, ErrVersionMismatch) succeed
+func (e *VersionMismatchError) Is(target error) bool {
+	return target == ErrVersionMismatch
+}
+
+// NailVersioned stores data like Nail and returns the item's new version
+// Versions start at 1 when a key is inserted, increment on every update and
+// start over after the key is deleted, evicted or expired.
+func (b *Bucket[T]) NailVersioned(id string, data T) (uint64, error) {
+	return b.nailVersion(id, data, nil)
+}
+
+// NailIfVersion stores data only if the stored version equals expectedVersion
+// An expectedVersion of zero requires the key to be absent. On success the
+// new version is returned, otherwise a *VersionMismatchError.
+func (b *Bucket[T]) NailIfVersion(id string, data T, expectedVersion uint64) (uint64, error) {
+	return b.nailVersion(id, data, &expectedVersion)
+}
+
+// Version returns the current version of a live item
+func (b *Bucket[T]) Version(id string) (uint64, bool) {
+	b.rlock()
+	defer b.mutex.RUnlock()
+
+	if b.isClosed() {
+		return 0, false
+	}
+	item, exists := b.cache[id]
+	if !exists || item.expired(b.now()) {
+		return 0, false
+	}
+	return item.version, true
+}
+
+// nailVersion stores data, checking the version first when expected is set
+func (b *Bucket[T]) nailVersion(id string, data T, expected *uint64) (uint64, error) {
+	if b.timed() {
+		defer b.observeNail(time.Now())
+	}
+
+	b.lockStaged(id, data)
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		return 0, err
+	}
+
+	data, err := b.admit(id, data)
+	if err != nil {
+		return 0, err
+	}
+
+	if expected != nil {
+		var actual uint64
+		if item, exists := b.cache[id]; exists && !item.expired(b.now()) {
+			actual = item.version
+		}
+		if actual != *expected {
+			return 0, &VersionMismatchError{Key: id, Expected: *expected, Actual: actual}
+		}
+	}
+
+	item, err := b.setLocked(id, data, b.expiryFor(b.outdated))
+	if err != nil {
+		return 0, err
+	}
+	return item.version, nil
+}
@@ -0,0 +1,78 @@
+package heatwave
+
+import "time"
+
+// warmChunkSize bounds how many entries Warm inserts per lock acquisition
+const warmChunkSize = 256
+
+// WarmEntry describes one item to preload into a bucket
+type WarmEntry[T any] struct {
+	Key   string
+	Value T
+	TTL   time.Duration // Per-item TTL, zero uses the bucket default
+}
+
+// Warm seeds the bucket with entries in slice order
+// The last entry ends up as the most recently used one. When there are more
+// entries than maxSize, the leading entries are dropped instead of being
+// inserted and evicted again. The lock is taken once per chunk so that
+// concurrent readers are not starved during a large warm-up.
+func (b *Bucket[T]) Warm(entries []WarmEntry[T]) error {
+	if err := b.writable(); err != nil {
+		return err
+	}
+
+	b.rlock()
+	maxSize := b.maxSize
+	b.mutex.RUnlock()
+	if maxSize > 0 && len(entries) > maxSize {
+		entries = entries[len(entries)-maxSize:]
+	}
+
+	admitted := make([]WarmEntry[T], len(entries))
+	for i, e := range entries {
+		value, err := b.admit(e.Key, e.Value)
+		if err != nil {
+			return err
+		}
+		admitted[i] = WarmEntry[T]{Key: e.Key, Value: value, TTL: e.TTL}
+	}
+	entries = admitted
+
+	for start := 0; start < len(entries); start += warmChunkSize {
+		end := min(start+warmChunkSize, len(entries))
+		if err := b.warmChunk(entries[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warmChunk inserts a chunk of warm entries under a single lock acquisition
+func (b *Bucket[T]) warmChunk(entries []WarmEntry[T]) error {
+	spills := b.stageSpills(len(entries), func(i int) (string, T, bool) {
+		return entries[i].Key, entries[i].Value, true
+	})
+	b.lock()
+	defer b.unlock()
+
+	if err := b.writable(); err != nil {
+		b.removeSpills(spills)
+		return err
+	}
+
+	for i, e := range entries {
+		ttl := b.outdated
+		if e.TTL > 0 {
+			ttl = &e.TTL
+		}
+		if spills != nil {
+			b.stageLocked(spills[i])
+		}
+		if _, err := b.setLocked(e.Key, e.Value, b.expiryFor(ttl)); err != nil {
+			b.removeSpills(spills[i+1:])
+			return err
+		}
+	}
+	return nil
+}
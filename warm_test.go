@@ -0,0 +1,89 @@
+package heatwave
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWarmTrimsToMaxSize(t *testing.T) {
+	var evicted int
+	b := NewBucket[int](
+		WithMaxSize[int](3),
+		WithOnEvict(func(key string, value int, reason RemovalReason) { evicted++ }),
+	)
+	defer b.Close()
+
+	var entries []WarmEntry[int]
+	for i := 0; i < 5; i++ {
+		entries = append(entries, WarmEntry[int]{Key: strconv.Itoa(i), Value: i})
+	}
+	if err := b.Warm(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	// The leading entries were dropped rather than inserted and evicted
+	if evicted != 0 {
+		t.Fatalf("Warm evicted %d items, want none", evicted)
+	}
+	if got := strings.Join(b.MostRecent(10), ","); got != "4,3,2" {
+		t.Fatalf("MostRecent = %s, want 4,3,2", got)
+	}
+}
+
+func TestWarmTTL(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	b := NewBucket[int](WithClock[int](clock), WithCleanupDisabled[int](), WithBucketExpire[int](time.Hour))
+	defer b.Close()
+
+	_ = b.Warm([]WarmEntry[int]{
+		{Key: "default", Value: 1},
+		{Key: "short", Value: 2, TTL: time.Second},
+	})
+	clock.Advance(2 * time.Second)
+	if !exists(b, "default") || exists(b, "short") {
+		t.Fatal("warm entries didn't get their TTLs")
+	}
+}
+
+func TestWarmRejectsInadmissibleEntry(t *testing.T) {
+	b := NewBucket[int](WithMaxKeyLength[int](3))
+	defer b.Close()
+
+	err := b.Warm([]WarmEntry[int]{
+		{Key: "a", Value: 1},
+		{Key: "too long", Value: 2},
+	})
+	if !errors.Is(err, ErrKeyTooLong) {
+		t.Fatalf("Warm = %v, want ErrKeyTooLong", err)
+	}
+	// Entries are checked before any is inserted
+	if b.Size() != 0 {
+		t.Fatalf("Size = %d after a rejected warm-up, want 0", b.Size())
+	}
+}
+
+func TestWarmChunksLocking(t *testing.T) {
+	const n = 2*warmChunkSize + 1
+	b := NewBucket[int](WithCleanupDisabled[int](), WithContentionProfiling[int](1))
+	defer b.Close()
+
+	entries := make([]WarmEntry[int], n)
+	for i := range entries {
+		entries[i] = WarmEntry[int]{Key: strconv.Itoa(i), Value: i}
+	}
+	if err := b.Warm(entries); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.ContentionStats(); s.Write.Count != 3 {
+		t.Fatalf("Warm of %d entries took the write lock %d times, want 3", n, s.Write.Count)
+	}
+	if b.Size() != n {
+		t.Fatalf("Size = %d, want %d", b.Size(), n)
+	}
+	if key, _, _ := b.Newest(); key != strconv.Itoa(n-1) {
+		t.Fatalf("Newest = %s, want the last entry", key)
+	}
+}